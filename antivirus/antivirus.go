@@ -0,0 +1,57 @@
+package antivirus
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+)
+
+// Result adalah hasil pemindaian satu file.
+type Result struct {
+	Infected  bool   `json:"infected"`
+	Signature string `json:"signature"`
+}
+
+// Scanner memindai isi file sebelum disimpan ke storage.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+var active Scanner
+
+// Active mengembalikan scanner yang sedang dipakai, nil jika pemindaian dimatikan.
+func Active() Scanner {
+	return active
+}
+
+// SetScanner mengganti scanner aktif, misalnya dengan Fake saat testing.
+func SetScanner(s Scanner) {
+	active = s
+}
+
+// FailOpen menentukan apakah upload tetap diterima saat scanner tidak bisa dihubungi.
+// Default-nya tidak (fail closed), aktifkan dengan ANTIVIRUS_FAIL_OPEN=true.
+func FailOpen() bool {
+	return os.Getenv("ANTIVIRUS_FAIL_OPEN") == "true"
+}
+
+// Init memilih scanner dari ANTIVIRUS_DRIVER: clamd, fake, atau kosong (mati).
+func Init() {
+	switch os.Getenv("ANTIVIRUS_DRIVER") {
+	case "clamd":
+		clamd, err := NewClamdFromEnv()
+		if err != nil {
+			log.Fatal("❌ Konfigurasi clamd tidak valid:", err)
+		}
+		active = clamd
+	case "fake":
+		active = Fake{}
+	default:
+		active = nil
+		log.Println("⚠️  Antivirus tidak aktif (ANTIVIRUS_DRIVER kosong)")
+		return
+	}
+	log.Printf("✅ Antivirus aktif: %s\n", active.Name())
+}
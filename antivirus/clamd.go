@@ -0,0 +1,125 @@
+package antivirus
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ukuran potongan data yang dikirim per perintah INSTREAM
+const clamdChunkSize = 64 << 10
+
+// Clamd berbicara dengan daemon ClamAV memakai protokol INSTREAM.
+type Clamd struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdFromEnv membaca CLAMD_ADDRESS (tcp://host:3310 atau unix:///path/clamd.ctl)
+// dan CLAMD_TIMEOUT_SECONDS (default 120).
+func NewClamdFromEnv() (*Clamd, error) {
+	addr := os.Getenv("CLAMD_ADDRESS")
+	if addr == "" {
+		addr = "tcp://127.0.0.1:3310"
+	}
+
+	network, address, found := strings.Cut(addr, "://")
+	if !found || (network != "tcp" && network != "unix") {
+		return nil, fmt.Errorf("CLAMD_ADDRESS harus berformat tcp://host:port atau unix:///path")
+	}
+
+	timeout := 120 * time.Second
+	if sec, err := strconv.Atoi(os.Getenv("CLAMD_TIMEOUT_SECONDS")); err == nil && sec > 0 {
+		timeout = time.Duration(sec) * time.Second
+	}
+
+	return &Clamd{network: network, address: address, timeout: timeout}, nil
+}
+
+func (c *Clamd) Name() string { return "clamd" }
+
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return Result{}, fmt.Errorf("gagal terhubung ke clamd: %v", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, fmt.Errorf("gagal mengirim perintah ke clamd: %v", err)
+	}
+
+	// Setiap potongan diawali panjang 4 byte big-endian, diakhiri potongan kosong
+	buf := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return c.earlyReply(conn, err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return c.earlyReply(conn, err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return Result{}, readErr
+		}
+	}
+
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return c.earlyReply(conn, err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return Result{}, fmt.Errorf("gagal membaca balasan clamd: %v", err)
+	}
+	return parseClamdReply(reply)
+}
+
+// earlyReply membaca balasan clamd ketika koneksi ditutup di tengah stream,
+// biasanya karena StreamMaxLength terlampaui.
+func (c *Clamd) earlyReply(conn net.Conn, writeErr error) (Result, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return Result{}, fmt.Errorf("gagal mengirim data ke clamd: %v", writeErr)
+	}
+	return parseClamdReply(reply)
+}
+
+// parseClamdReply mengurai balasan seperti "stream: OK" atau
+// "stream: Eicar-Test-Signature FOUND".
+func parseClamdReply(reply string) (Result, error) {
+	reply = strings.TrimRight(reply, "\x00\n")
+	reply = strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case reply == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("clamd error: %s", reply)
+	}
+}
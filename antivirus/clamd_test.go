@@ -0,0 +1,165 @@
+package antivirus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClamdReply(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    Result
+		wantErr bool
+	}{
+		{"stream: OK\x00", Result{}, false},
+		{"stream: OK\n", Result{}, false},
+		{"stream: Eicar-Test-Signature FOUND\x00", Result{Infected: true, Signature: "Eicar-Test-Signature"}, false},
+		{"stream: Win.Trojan.Agent-123 FOUND", Result{Infected: true, Signature: "Win.Trojan.Agent-123"}, false},
+		{"INSTREAM size limit exceeded. ERROR\x00", Result{}, true},
+		{"", Result{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseClamdReply(tt.reply)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseClamdReply(%q) error = %v, wantErr %v", tt.reply, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseClamdReply(%q) = %+v, want %+v", tt.reply, got, tt.want)
+		}
+	}
+}
+
+// fakeClamd menerima satu koneksi INSTREAM, mencatat panjang setiap potongan
+// dan isi yang diterima, lalu membalas seperti clamd.
+func fakeClamd(t *testing.T) (addr string, chunks chan []int, data chan []byte) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	chunks = make(chan []int, 1)
+	data = make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		cmd, err := r.ReadString(0)
+		if err != nil || cmd != "zINSTREAM\x00" {
+			conn.Write([]byte("UNKNOWN COMMAND\x00"))
+			return
+		}
+		var sizes []int
+		var body bytes.Buffer
+		size := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(r, size); err != nil {
+				return
+			}
+			n := int(binary.BigEndian.Uint32(size))
+			sizes = append(sizes, n)
+			if n == 0 {
+				break
+			}
+			if _, err := io.CopyN(&body, r, int64(n)); err != nil {
+				return
+			}
+		}
+		chunks <- sizes
+		data <- body.Bytes()
+		if bytes.Contains(body.Bytes(), []byte(eicarSignature)) {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+			return
+		}
+		conn.Write([]byte("stream: OK\x00"))
+	}()
+	return ln.Addr().String(), chunks, data
+}
+
+func TestClamdScanFraming(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       []byte
+		wantChunks []int
+		want       Result
+	}{
+		{"file kosong", nil, []int{0}, Result{}},
+		{"satu potongan", []byte("isi surat"), []int{9, 0}, Result{}},
+		{"dua potongan", bytes.Repeat([]byte("a"), clamdChunkSize+10), []int{clamdChunkSize, 10, 0}, Result{}},
+		{"EICAR", []byte(eicarSignature), []int{len(eicarSignature), 0}, Result{Infected: true, Signature: "Eicar-Test-Signature"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, chunks, data := fakeClamd(t)
+			clamd := &Clamd{network: "tcp", address: addr, timeout: 5 * time.Second}
+
+			got, err := clamd.Scan(context.Background(), bytes.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan = %+v, want %+v", got, tt.want)
+			}
+			sizes := <-chunks
+			if len(sizes) != len(tt.wantChunks) {
+				t.Fatalf("potongan = %v, want %v", sizes, tt.wantChunks)
+			}
+			for i := range sizes {
+				if sizes[i] != tt.wantChunks[i] {
+					t.Errorf("potongan = %v, want %v", sizes, tt.wantChunks)
+					break
+				}
+			}
+			if received := <-data; !bytes.Equal(received, tt.body) {
+				t.Errorf("isi diterima %d byte, want %d", len(received), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestClamdScanUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	clamd := &Clamd{network: "tcp", address: addr, timeout: time.Second}
+	if _, err := clamd.Scan(context.Background(), strings.NewReader("isi")); err == nil {
+		t.Fatal("Scan tanpa clamd seharusnya error")
+	}
+}
+
+func TestFakeScan(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Result
+	}{
+		{"bersih", "%PDF-1.4 surat biasa", Result{}},
+		{"EICAR", eicarSignature, Result{Infected: true, Signature: "Eicar-Test-Signature"}},
+		{"EICAR di tengah file", "%PDF-1.4\n" + eicarSignature + "\n%%EOF", Result{Infected: true, Signature: "Eicar-Test-Signature"}},
+	}
+	for _, tt := range tests {
+		got, err := Fake{}.Scan(context.Background(), strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: Scan = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
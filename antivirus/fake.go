@@ -0,0 +1,27 @@
+package antivirus
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// String uji EICAR standar, dikenali semua antivirus sebagai "virus" palsu
+const eicarSignature = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Fake adalah scanner lokal untuk development dan test tanpa clamd.
+// File dianggap terinfeksi jika mengandung string uji EICAR.
+type Fake struct{}
+
+func (Fake) Name() string { return "fake" }
+
+func (Fake) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Result{}, err
+	}
+	if bytes.Contains(data, []byte(eicarSignature)) {
+		return Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+	}
+	return Result{}, nil
+}
@@ -0,0 +1,149 @@
+// Perintah import-documents mengimpor register surat lama dari spreadsheet
+// (CSV/XLSX) dan arsip ZIP berisi file scan langsung dari disk server, sama
+// seperti POST /api/imports tetapi tanpa batas ukuran upload.
+//
+//	go run ./cmd/import-documents -user admin -spreadsheet register.xlsx -archive scan.zip -dry-run
+//	go run ./cmd/import-documents -user admin -spreadsheet register.xlsx -archive scan.zip -skip-invalid
+//	go run ./cmd/import-documents -resume <id impor> -retry-failed
+//
+// Impor yang dijalankan lewat CLI tercatat di tabel yang sama sehingga hasil
+// per baris juga bisa dilihat di GET /api/imports/:id/rows.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dinsos_kuburaya/antivirus"
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/controllers"
+	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/storage"
+)
+
+func main() {
+	username := flag.String("user", "", "username admin yang tercatat sebagai pengimpor")
+	sheetPath := flag.String("spreadsheet", "", "file .csv atau .xlsx")
+	archivePath := flag.String("archive", "", "arsip .zip berisi file scan")
+	mappingArg := flag.String("mapping", "", `pemetaan kolom JSON {"field": "judul kolom"} atau @file.json`)
+	letterType := flag.String("letter-type", "", "jenis surat jika kolom jenis surat kosong (masuk/keluar)")
+	visibility := flag.String("visibility", "public", "public atau private")
+	dryRun := flag.Bool("dry-run", false, "hanya validasi, tidak membuat dokumen")
+	skipInvalid := flag.Bool("skip-invalid", false, "lewati baris yang tidak valid")
+	resumeID := flag.String("resume", "", "lanjutkan impor yang sudah ada berdasarkan id")
+	retryFailed := flag.Bool("retry-failed", false, "ulangi baris yang gagal saat melanjutkan impor")
+	flag.Parse()
+
+	config.ConnectDatabase()
+	if err := config.DB.AutoMigrate(&models.DocumentImport{}, &models.DocumentImportRow{}); err != nil {
+		log.Fatal("Gagal migrasi tabel impor:", err)
+	}
+	storage.Init()
+	antivirus.Init()
+
+	var imp models.DocumentImport
+	if *resumeID != "" {
+		if err := config.DB.First(&imp, "id = ?", *resumeID).Error; err != nil {
+			log.Fatalf("Impor %s tidak ditemukan", *resumeID)
+		}
+	} else {
+		imp = prepare(*username, *sheetPath, *archivePath, *mappingArg, strings.ToLower(*letterType), *visibility)
+		if *dryRun {
+			return
+		}
+	}
+
+	if imp.Status == "completed" && !(*retryFailed && imp.FailedRows > 0) {
+		log.Fatal("Impor sudah selesai")
+	}
+	if imp.InvalidRows > 0 && !*skipInvalid {
+		log.Fatalf("Ada %d baris tidak valid. Perbaiki spreadsheet atau jalankan dengan -skip-invalid", imp.InvalidRows)
+	}
+	if err := controllers.MarkDocumentImportRunning(&imp, *retryFailed); err != nil {
+		log.Fatal("Gagal memulai impor:", err)
+	}
+
+	log.Printf("⏳ Mengimpor %s (id %s)...", imp.SpreadsheetName, imp.ID)
+	if err := controllers.RunDocumentImport(imp.ID); err != nil {
+		log.Fatal("Impor gagal:", err)
+	}
+	config.DB.First(&imp, "id = ?", imp.ID)
+	log.Printf("✅ Impor selesai: %d berhasil, %d gagal, %d tidak valid", imp.ImportedRows, imp.FailedRows, imp.InvalidRows)
+	printRows(imp.ID, "failed")
+}
+
+// prepare menjalankan validasi (dry run) dan mencetak laporannya.
+func prepare(username, sheetPath, archivePath, mappingArg, letterType, visibility string) models.DocumentImport {
+	if username == "" || sheetPath == "" || archivePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var user models.User
+	if err := config.DB.First(&user, "username = ? AND role = ?", username, "admin").Error; err != nil {
+		log.Fatalf("Admin dengan username %s tidak ditemukan", username)
+	}
+
+	var mapping map[string]string
+	if mappingArg != "" {
+		raw := []byte(mappingArg)
+		if strings.HasPrefix(mappingArg, "@") {
+			data, err := os.ReadFile(strings.TrimPrefix(mappingArg, "@"))
+			if err != nil {
+				log.Fatal("Gagal membaca file mapping:", err)
+			}
+			raw = data
+		}
+		if err := json.Unmarshal(raw, &mapping); err != nil {
+			log.Fatal("Mapping harus berupa JSON:", err)
+		}
+	}
+	if visibility != "public" && visibility != "private" {
+		log.Fatal("visibility harus public atau private")
+	}
+
+	sheetAbs, _ := filepath.Abs(sheetPath)
+	archiveAbs, _ := filepath.Abs(archivePath)
+	report, err := controllers.PrepareDocumentImport(user, controllers.DocumentImportInput{
+		SpreadsheetPath: sheetAbs,
+		SpreadsheetName: filepath.Base(sheetPath),
+		ArchivePath:     archiveAbs,
+		ArchiveName:     filepath.Base(archivePath),
+		Mapping:         mapping,
+		LetterType:      letterType,
+		Visibility:      visibility,
+	})
+	if err != nil {
+		log.Fatal("Validasi gagal: ", err)
+	}
+
+	imp := report.Import
+	fmt.Printf("Impor %s\n", imp.ID)
+	fmt.Println("Pemetaan kolom:")
+	for key, header := range report.Mapping {
+		fmt.Printf("  %-22s <- %s\n", key, header)
+	}
+	if len(report.UnmappedColumns) > 0 {
+		fmt.Printf("Kolom tidak dipakai: %s\n", strings.Join(report.UnmappedColumns, ", "))
+	}
+	if len(report.UnusedFiles) > 0 {
+		fmt.Printf("File di arsip yang tidak dipakai: %d\n", len(report.UnusedFiles))
+	}
+	fmt.Printf("Baris: %d total, %d valid, %d tidak valid\n", imp.TotalRows, imp.ValidRows, imp.InvalidRows)
+	printRows(imp.ID, "invalid")
+	return imp
+}
+
+func printRows(importID, status string) {
+	var rows []models.DocumentImportRow
+	config.DB.Where("import_id = ? AND status = ?", importID, status).Order("row_number ASC").Find(&rows)
+	for _, row := range rows {
+		var errs []string
+		_ = json.Unmarshal([]byte(row.Errors), &errs)
+		fmt.Printf("  baris %d: %s\n", row.RowNumber, strings.Join(errs, "; "))
+	}
+}
@@ -18,6 +18,7 @@ type CloudinaryResponse struct {
 	PublicID     string `json:"public_id"`
 	SecureURL    string `json:"secure_url"`
 	ResourceType string `json:"resource_type"`
+	Type         string `json:"type"`
 	Format       string `json:"format"`
 }
 
@@ -27,8 +28,10 @@ type CloudinaryErrorResponse struct {
 	} `json:"error"`
 }
 
-// UploadToCloudinary
-func UploadToCloudinary(file io.Reader, fileName, folder, resourceType string) (CloudinaryResponse, error) {
+// UploadToCloudinary mengupload file dengan delivery type tertentu (upload,
+// authenticated atau private). File authenticated hanya bisa diambil lewat
+// URL yang ditandatangani.
+func UploadToCloudinary(file io.Reader, fileName, folder, resourceType, deliveryType string) (CloudinaryResponse, error) {
 	cloudName := os.Getenv("CLOUDINARY_CLOUD_NAME")
 	apiKey := os.Getenv("CLOUDINARY_API_KEY")
 	apiSecret := os.Getenv("CLOUDINARY_API_SECRET")
@@ -45,46 +48,55 @@ func UploadToCloudinary(file io.Reader, fileName, folder, resourceType string) (
 	// Timestamp
 	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
 
-	// Signature
+	// Signature, parameter harus urut menurut nama
 	var signatureString string
 	if folder != "" {
-		signatureString = fmt.Sprintf("folder=%s&timestamp=%s%s", folder, timestamp, apiSecret)
+		signatureString = fmt.Sprintf("folder=%s&timestamp=%s&type=%s%s", folder, timestamp, deliveryType, apiSecret)
 	} else {
-		signatureString = fmt.Sprintf("timestamp=%s%s", timestamp, apiSecret)
+		signatureString = fmt.Sprintf("timestamp=%s&type=%s%s", timestamp, deliveryType, apiSecret)
 	}
 
 	h := sha1.New()
 	h.Write([]byte(signatureString))
 	signature := hex.EncodeToString(h.Sum(nil))
 
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
+	// Body multipart ditulis lewat pipe supaya file di-stream langsung ke
+	// Cloudinary tanpa ditampung dulu di memori
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
 
-	part, err := writer.CreateFormFile("file", fileName)
-	if err != nil {
-		return CloudinaryResponse{}, fmt.Errorf("failed to create form file: %v", err) // Perubahan
-	}
-	if _, err := io.Copy(part, file); err != nil {
-		return CloudinaryResponse{}, fmt.Errorf("failed to copy file: %v", err) // Perubahan
-	}
+	go func() {
+		part, err := writer.CreateFormFile("file", fileName)
+		if err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to create form file: %v", err))
+			return
+		}
+		if _, err := io.Copy(part, file); err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to copy file: %v", err))
+			return
+		}
 
-	writer.WriteField("api_key", apiKey)
-	writer.WriteField("timestamp", timestamp)
-	writer.WriteField("signature", signature)
+		writer.WriteField("api_key", apiKey)
+		writer.WriteField("timestamp", timestamp)
+		writer.WriteField("signature", signature)
+		writer.WriteField("type", deliveryType)
 
-	if folder != "" {
-		writer.WriteField("folder", folder)
-	}
+		if folder != "" {
+			writer.WriteField("folder", folder)
+		}
 
-	writer.Close()
+		pw.CloseWithError(writer.Close())
+	}()
 
-	req, err := http.NewRequest("POST", url, &body)
+	req, err := http.NewRequest("POST", url, pr)
 	if err != nil {
+		pr.Close()
 		return CloudinaryResponse{}, fmt.Errorf("failed to create request: %v", err)
 	}
 	req.Header.Add("Content-Type", writer.FormDataContentType())
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	// Timeout lebih panjang karena file besar di-stream langsung
+	client := &http.Client{Timeout: 10 * time.Minute}
 
 	fmt.Println("📤 Sending file to Cloudinary...")
 
@@ -120,8 +132,8 @@ type CloudinaryDeleteResponse struct {
 	Result string `json:"result"`
 }
 
-// DeleteFromCloudinary - menghapus file
-func DeleteFromCloudinary(publicID string, resourceType string) error {
+// DeleteFromCloudinary - menghapus file. deliveryType harus sama dengan saat upload.
+func DeleteFromCloudinary(publicID, resourceType, deliveryType string) error {
 	cloudName := os.Getenv("CLOUDINARY_CLOUD_NAME")
 	apiKey := os.Getenv("CLOUDINARY_API_KEY")
 	apiSecret := os.Getenv("CLOUDINARY_API_SECRET")
@@ -137,7 +149,7 @@ func DeleteFromCloudinary(publicID string, resourceType string) error {
 	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
 
 	// Signature
-	signatureString := fmt.Sprintf("public_id=%s&timestamp=%s%s", publicID, timestamp, apiSecret)
+	signatureString := fmt.Sprintf("public_id=%s&timestamp=%s&type=%s%s", publicID, timestamp, deliveryType, apiSecret)
 	h := sha1.New()
 	h.Write([]byte(signatureString))
 	signature := hex.EncodeToString(h.Sum(nil))
@@ -149,6 +161,7 @@ func DeleteFromCloudinary(publicID string, resourceType string) error {
 	writer.WriteField("api_key", apiKey)
 	writer.WriteField("timestamp", timestamp)
 	writer.WriteField("signature", signature)
+	writer.WriteField("type", deliveryType)
 	writer.Close()
 
 	// Request
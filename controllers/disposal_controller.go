@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// disposalNotPending dikembalikan jika usulan sudah disetujui atau ditolak.
+func disposalNotPending(p models.DisposalProposal) error {
+	return &requestError{
+		Status:  http.StatusConflict,
+		Code:    "invalid_transition",
+		Message: "Usulan pemusnahan sudah " + p.Status,
+		Details: map[string]interface{}{"status": p.Status},
+	}
+}
+
+// defaultDisposalRecordNumber dipakai jika admin tidak mengisi nomor berita acara.
+func defaultDisposalRecordNumber(p models.DisposalProposal, t time.Time) string {
+	return fmt.Sprintf("BA-PMS/%s/%s", t.Format("20060102"), strings.ToUpper(p.ID[:8]))
+}
+
+// ======================================================
+// GET DISPOSAL PROPOSALS (ADMIN)
+// ======================================================
+func GetDisposalProposals(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 20
+	}
+
+	query := config.DB.Model(&models.DisposalProposal{})
+	if status := c.Query("status"); status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var proposals []models.DisposalProposal
+	if err := query.Preload("ProposedBy").Preload("ReviewedBy").
+		Order("created_at DESC").
+		Limit(perPage).Offset((page - 1) * perPage).
+		Find(&proposals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil usulan pemusnahan: " + err.Error()})
+		return
+	}
+
+	lastPage := int((total + int64(perPage) - 1) / int64(perPage))
+	c.JSON(http.StatusOK, gin.H{
+		"data":         proposals,
+		"total":        total,
+		"current_page": page,
+		"last_page":    lastPage,
+		"per_page":     perPage,
+	})
+}
+
+// ======================================================
+// GET DISPOSAL PROPOSAL / BERITA ACARA (ADMIN)
+// ======================================================
+func GetDisposalProposal(c *gin.Context) {
+	var proposal models.DisposalProposal
+	if err := config.DB.Preload("ProposedBy").Preload("ReviewedBy").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("classification_code ASC, letter_date ASC") }).
+		First(&proposal, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usulan pemusnahan tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": proposal})
+}
+
+// ======================================================
+// CREATE DISPOSAL PROPOSAL (ADMIN)
+// ======================================================
+// Body: title, note, document_ids. Jika document_ids kosong, semua arsip
+// berstatus expired dimasukkan ke usulan.
+func CreateDisposalProposal(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		Title       string   `json:"title" binding:"required"`
+		Note        string   `json:"note"`
+		DocumentIDs []string `json:"document_ids"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, id := range input.DocumentIDs {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	input.DocumentIDs = ids
+
+	proposal := models.DisposalProposal{
+		Title:        strings.TrimSpace(input.Title),
+		Note:         strings.TrimSpace(input.Note),
+		Status:       "pending",
+		ProposedByID: &user.ID,
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("retention_status = ?", retentionExpired)
+		if len(input.DocumentIDs) > 0 {
+			query = query.Where("id IN ?", input.DocumentIDs)
+		}
+		var docs []models.Document
+		if err := query.Order("classification_code ASC, created_at ASC").Find(&docs).Error; err != nil {
+			return err
+		}
+
+		// Dokumen yang dipilih harus semuanya sudah habis masa retensinya dan bernasib musnah
+		if len(input.DocumentIDs) > 0 && len(docs) != len(input.DocumentIDs) {
+			found := map[string]bool{}
+			for _, d := range docs {
+				found[d.ID] = true
+			}
+			var invalid []string
+			for _, id := range input.DocumentIDs {
+				if !found[id] {
+					invalid = append(invalid, id)
+				}
+			}
+			return &requestError{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "not_disposable",
+				Message: "Sebagian dokumen tidak ditemukan atau belum siap dimusnahkan (retention_status harus expired)",
+				Details: map[string]interface{}{"document_ids": invalid},
+			}
+		}
+		if len(docs) == 0 {
+			return &requestError{Status: http.StatusUnprocessableEntity, Code: "not_disposable", Message: "Tidak ada arsip yang siap dimusnahkan"}
+		}
+
+		proposal.ItemCount = len(docs)
+		if err := tx.Create(&proposal).Error; err != nil {
+			return err
+		}
+		items := make([]models.DisposalItem, 0, len(docs))
+		docIDs := make([]string, 0, len(docs))
+		for _, d := range docs {
+			items = append(items, models.DisposalItem{
+				ProposalID:         proposal.ID,
+				DocumentID:         d.ID,
+				RegisterNumber:     d.RegisterNumber,
+				SenderLetterNumber: d.SenderLetterNumber,
+				Subject:            d.Subject,
+				Sender:             d.Sender,
+				LetterType:         d.LetterType,
+				ClassificationCode: d.ClassificationCode,
+				LetterDate:         d.LetterDate,
+				FileName:           d.FileName,
+				RetentionExpiresAt: d.RetentionExpiresAt,
+			})
+			docIDs = append(docIDs, d.ID)
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+		proposal.Items = items
+		return tx.Model(&models.Document{}).Where("id IN ?", docIDs).
+			UpdateColumn("retention_status", retentionProposed).Error
+	})
+	if err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			respondFileError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat usulan pemusnahan: " + err.Error()})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "PROPOSE_DISPOSAL",
+		fmt.Sprintf("Mengusulkan pemusnahan %d arsip: %s", proposal.ItemCount, proposal.Title))
+
+	go func() {
+		var admins []models.User
+		if err := config.DB.Where("role = ? AND id <> ?", "admin", user.ID).Find(&admins).Error; err == nil {
+			for _, admin := range admins {
+				msg := fmt.Sprintf("%s mengusulkan pemusnahan %d arsip: %s", user.Name, proposal.ItemCount, proposal.Title)
+				_ = CreateNotification(admin.ID, msg, "/dashboard/retention/disposals/"+proposal.ID)
+			}
+		}
+	}()
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Usulan pemusnahan dibuat", "data": proposal})
+}
+
+// ======================================================
+// APPROVE DISPOSAL PROPOSAL (ADMIN)
+// ======================================================
+// Body (JSON, opsional): record_number (nomor berita acara), note.
+// Dokumen dihapus permanen beserta semua file, versi, preview, lampiran dan
+// file tanda tangannya; catatan verifikasi tanda tangan tetap disimpan.
+func ApproveDisposalProposal(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		RecordNumber string `json:"record_number"`
+		Note         string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&input)
+
+	var proposal models.DisposalProposal
+	var destroyed []models.Document
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&proposal, "id = ?", c.Param("id")).Error; err != nil {
+			return &requestError{Status: http.StatusNotFound, Code: "not_found", Message: "Usulan pemusnahan tidak ditemukan"}
+		}
+		if proposal.Status != "pending" {
+			return disposalNotPending(proposal)
+		}
+
+		var ids []string
+		if err := tx.Model(&models.DisposalItem{}).Where("proposal_id = ?", proposal.ID).
+			Pluck("document_id", &ids).Error; err != nil {
+			return err
+		}
+		// Dokumen di recycle bin ikut dimusnahkan
+		if len(ids) > 0 {
+			if err := tx.Unscoped().Where("id IN ? AND retention_status = ?", ids, retentionProposed).
+				Find(&destroyed).Error; err != nil {
+				return err
+			}
+		}
+		for i := range destroyed {
+			if err := hardDeleteDocument(tx, &destroyed[i]); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		proposal.Status = "approved"
+		proposal.ReviewedByID = &user.ID
+		proposal.ReviewNote = strings.TrimSpace(input.Note)
+		proposal.ReviewedAt = &now
+		proposal.RecordNumber = strings.TrimSpace(input.RecordNumber)
+		if proposal.RecordNumber == "" {
+			proposal.RecordNumber = defaultDisposalRecordNumber(proposal, now)
+		}
+		return tx.Model(&proposal).Updates(map[string]interface{}{
+			"status":         proposal.Status,
+			"reviewed_by_id": user.ID,
+			"review_note":    proposal.ReviewNote,
+			"reviewed_at":    now,
+			"record_number":  proposal.RecordNumber,
+		}).Error
+	})
+	if err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			respondFileError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyetujui pemusnahan: " + err.Error()})
+		return
+	}
+
+	// File baru dihapus setelah transaksi berhasil
+	for _, doc := range destroyed {
+		deleteDocumentVersions(doc.ID, documentDownloadable(doc))
+		deleteDocumentPreviews(doc.ID)
+		deleteDocumentAttachments(doc.ID)
+		deleteDocumentSignatureFiles(doc.ID)
+	}
+
+	CreateActivityLog(user.ID, user.Name, "APPROVE_DISPOSAL",
+		fmt.Sprintf("Menyetujui pemusnahan %d arsip (berita acara %s): %s", len(destroyed), proposal.RecordNumber, proposal.Title))
+
+	if proposal.ProposedByID != nil && *proposal.ProposedByID != user.ID {
+		proposerID := *proposal.ProposedByID
+		go func() {
+			msg := fmt.Sprintf("%s menyetujui usulan pemusnahan: %s (%s)", user.Name, proposal.Title, proposal.RecordNumber)
+			_ = CreateNotification(proposerID, msg, "/dashboard/retention/disposals/"+proposal.ID)
+		}()
+	}
+
+	config.DB.Preload("ProposedBy").Preload("ReviewedBy").Preload("Items").First(&proposal, "id = ?", proposal.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Pemusnahan disetujui, berita acara dibuat",
+		"destroyed": len(destroyed),
+		"data":      proposal,
+	})
+}
+
+// ======================================================
+// REJECT DISPOSAL PROPOSAL (ADMIN)
+// ======================================================
+// Body (JSON): note (wajib). Dokumen kembali berstatus expired.
+func RejectDisposalProposal(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		Note string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&input)
+	input.Note = strings.TrimSpace(input.Note)
+	if input.Note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Catatan wajib diisi untuk menolak usulan"})
+		return
+	}
+
+	var proposal models.DisposalProposal
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&proposal, "id = ?", c.Param("id")).Error; err != nil {
+			return &requestError{Status: http.StatusNotFound, Code: "not_found", Message: "Usulan pemusnahan tidak ditemukan"}
+		}
+		if proposal.Status != "pending" {
+			return disposalNotPending(proposal)
+		}
+
+		items := tx.Model(&models.DisposalItem{}).Select("document_id").Where("proposal_id = ?", proposal.ID)
+		if err := tx.Unscoped().Model(&models.Document{}).
+			Where("id IN (?) AND retention_status = ?", items, retentionProposed).
+			UpdateColumn("retention_status", retentionExpired).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		proposal.Status = "rejected"
+		proposal.ReviewedByID = &user.ID
+		proposal.ReviewNote = input.Note
+		proposal.ReviewedAt = &now
+		return tx.Model(&proposal).Updates(map[string]interface{}{
+			"status":         proposal.Status,
+			"reviewed_by_id": user.ID,
+			"review_note":    input.Note,
+			"reviewed_at":    now,
+		}).Error
+	})
+	if err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			respondFileError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menolak usulan: " + err.Error()})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "REJECT_DISPOSAL",
+		fmt.Sprintf("Menolak usulan pemusnahan: %s. Catatan: %s", proposal.Title, input.Note))
+
+	if proposal.ProposedByID != nil && *proposal.ProposedByID != user.ID {
+		proposerID := *proposal.ProposedByID
+		go func() {
+			msg := fmt.Sprintf("%s menolak usulan pemusnahan: %s. Catatan: %s", user.Name, proposal.Title, input.Note)
+			_ = CreateNotification(proposerID, msg, "/dashboard/retention/disposals/"+proposal.ID)
+		}()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usulan pemusnahan ditolak", "data": proposal})
+}
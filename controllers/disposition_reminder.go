@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Jenis pengingat disposisi
+const (
+	reminderDueSoon   = "due_soon"
+	reminderOverdue   = "overdue"
+	reminderEscalated = "escalated"
+)
+
+// envDays membaca jumlah hari dari environment, 0 diperbolehkan.
+func envDays(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+// DispositionReminderInterval dibaca dari DISPOSITION_REMINDER_INTERVAL_MINUTES (default 60 menit).
+func DispositionReminderInterval() time.Duration {
+	minutes := 60
+	if v, err := strconv.Atoi(os.Getenv("DISPOSITION_REMINDER_INTERVAL_MINUTES")); err == nil && v > 0 {
+		minutes = v
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Pengingat dikirim DISPOSITION_REMINDER_DAYS_BEFORE hari sebelum batas waktu
+// (default 1, 0 berarti hanya pada hari batas waktu). Eskalasi ke pemberi
+// disposisi dikirim setelah terlambat lebih dari DISPOSITION_ESCALATION_GRACE_DAYS
+// hari (default 2, 0 berarti langsung saat terlambat).
+func dispositionReminderDaysBefore() int {
+	return envDays("DISPOSITION_REMINDER_DAYS_BEFORE", 1)
+}
+
+func dispositionEscalationGraceDays() int {
+	return envDays("DISPOSITION_ESCALATION_GRACE_DAYS", 2)
+}
+
+// dispositionReminderKinds menentukan pengingat yang jatuh tempo untuk satu
+// disposisi pada tanggal today. Pengingat due_soon tidak dikirim lagi jika
+// batas waktunya sudah lewat.
+func dispositionReminderKinds(due, today time.Time, daysBefore, grace int) []string {
+	due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, today.Location())
+	if !today.After(due) {
+		if !due.After(today.AddDate(0, 0, daysBefore)) {
+			return []string{reminderDueSoon}
+		}
+		return nil
+	}
+	kinds := []string{reminderOverdue}
+	if today.After(due.AddDate(0, 0, grace)) {
+		kinds = append(kinds, reminderEscalated)
+	}
+	return kinds
+}
+
+// dispositionReminderMessage menyusun pesan pengingat untuk penerima atau,
+// untuk eskalasi, untuk pemberi disposisi.
+func dispositionReminderMessage(kind string, order models.SuperiorOrder) string {
+	due := order.DueDate.Format("02-01-2006")
+	switch kind {
+	case reminderDueSoon:
+		return fmt.Sprintf("PENGINGAT: Disposisi surat %s harus diselesaikan paling lambat %s", order.Document.Subject, due)
+	case reminderOverdue:
+		return fmt.Sprintf("PENGINGAT: Disposisi surat %s sudah melewati batas waktu %s", order.Document.Subject, due)
+	}
+	return fmt.Sprintf("ESKALASI: Disposisi surat %s kepada %s belum selesai, batas waktu %s", order.Document.Subject, order.User.Name, due)
+}
+
+// sendDispositionReminder mencatat pengingat lalu mengirim notifikasinya.
+// recipientID nil berarti dikirim ke semua admin. Catatan dibuat lebih dulu
+// dengan unique index sehingga dua proses yang berjalan bersamaan tidak
+// mengirim pengingat yang sama. Jika tidak ada notifikasi yang berhasil
+// dibuat, catatan dihapus agar dicoba lagi pada putaran berikutnya.
+func sendDispositionReminder(order models.SuperiorOrder, kind string, recipientID *string) (bool, error) {
+	reminder := models.DispositionReminder{
+		OrderID:     order.ID,
+		Kind:        kind,
+		DueDate:     *order.DueDate,
+		RecipientID: recipientID,
+	}
+	res := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&reminder)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+
+	var recipients []string
+	if recipientID != nil {
+		recipients = []string{*recipientID}
+	} else {
+		config.DB.Model(&models.User{}).Where("role = ?", "admin").Pluck("id", &recipients)
+	}
+
+	link := fmt.Sprintf("/dashboard/my-document/%s", order.DocumentID)
+	msg := dispositionReminderMessage(kind, order)
+	sent := 0
+	var lastErr error
+	for _, id := range recipients {
+		if err := CreateNotification(id, msg, link); err != nil {
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		config.DB.Delete(&reminder)
+		if lastErr == nil {
+			lastErr = fmt.Errorf("tidak ada penerima pengingat")
+		}
+		return false, lastErr
+	}
+	return true, nil
+}
+
+// SendDispositionReminders dijalankan scheduler: mengingatkan penerima
+// disposisi yang belum selesai menjelang dan setelah batas waktu, lalu
+// mengeskalasi ke pemberi disposisi (CreatedByID, atau semua admin jika
+// kosong) setelah masa tenggang.
+// Disposisi pada dokumen di recycle bin dilewati.
+func SendDispositionReminders() {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	daysBefore := dispositionReminderDaysBefore()
+	grace := dispositionEscalationGraceDays()
+
+	var orders []models.SuperiorOrder
+	err := config.DB.
+		Preload("Document", func(db *gorm.DB) *gorm.DB { return db.Omit("extracted_text") }).
+		Preload("User").
+		Where("status <> ? AND due_date IS NOT NULL AND due_date <= ?", "completed", today.AddDate(0, 0, daysBefore).Format("2006-01-02")).
+		Where("document_id IN (?)", config.DB.Model(&models.Document{}).Select("id")).
+		Find(&orders).Error
+	if err != nil {
+		log.Printf("Warning: gagal mengambil disposisi untuk pengingat: %v", err)
+		return
+	}
+
+	sent := 0
+	for _, order := range orders {
+		for _, kind := range dispositionReminderKinds(*order.DueDate, today, daysBefore, grace) {
+			recipientID := &order.UserID
+			if kind == reminderEscalated {
+				// Disposisi lama belum mencatat pemberinya, eskalasi ke semua admin
+				recipientID = order.CreatedByID
+				if recipientID != nil && *recipientID == order.UserID {
+					continue
+				}
+			}
+			ok, err := sendDispositionReminder(order, kind, recipientID)
+			if err != nil {
+				log.Printf("Warning: gagal mengirim pengingat %s disposisi %s: %v", kind, order.ID, err)
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+	if sent > 0 {
+		log.Printf("🔔 %d pengingat disposisi dikirim", sent)
+	}
+}
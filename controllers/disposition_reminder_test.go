@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDispositionReminderKinds(t *testing.T) {
+	today := date(2026, 10, 18)
+	tests := []struct {
+		name       string
+		due        time.Time
+		daysBefore int
+		grace      int
+		want       []string
+	}{
+		{"masih jauh", date(2026, 10, 21), 1, 2, nil},
+		{"belum masuk H-1", date(2026, 10, 20), 1, 2, nil},
+		{"H-1", date(2026, 10, 19), 1, 2, []string{reminderDueSoon}},
+		{"hari batas waktu", date(2026, 10, 18), 1, 2, []string{reminderDueSoon}},
+		{"jam pada batas waktu diabaikan", time.Date(2026, 10, 18, 23, 59, 0, 0, time.Local), 1, 2, []string{reminderDueSoon}},
+		{"terlambat 1 hari", date(2026, 10, 17), 1, 2, []string{reminderOverdue}},
+		{"terlambat tepat masa tenggang", date(2026, 10, 16), 1, 2, []string{reminderOverdue}},
+		{"lewat masa tenggang", date(2026, 10, 15), 1, 2, []string{reminderOverdue, reminderEscalated}},
+		{"tanpa H-n, besok", date(2026, 10, 19), 0, 0, nil},
+		{"tanpa H-n, hari ini", date(2026, 10, 18), 0, 0, []string{reminderDueSoon}},
+		{"tanpa masa tenggang", date(2026, 10, 17), 0, 0, []string{reminderOverdue, reminderEscalated}},
+		{"H-7", date(2026, 10, 25), 7, 2, []string{reminderDueSoon}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dispositionReminderKinds(tt.due, today, tt.daysBefore, tt.grace)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dispositionReminderKinds = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas jumlah lampiran per surat
+const maxAttachmentsPerDocument = 20
+
+// storedAttachment adalah lampiran yang filenya sudah tersimpan di storage
+// tetapi recordnya belum dibuat.
+type storedAttachment struct {
+	Name   string
+	Object storage.Object
+}
+
+// incomingAttachmentsFromForm mengambil lampiran dari field multipart
+// "attachments" (boleh lebih dari satu) dan "attachment_upload_ids"
+// (uuid dari upload bertahap, dipisah koma).
+func incomingAttachmentsFromForm(c *gin.Context, user models.User) ([]*incomingFile, error) {
+	var files []*incomingFile
+
+	if form, err := c.MultipartForm(); err == nil {
+		for _, fh := range form.File["attachments"] {
+			fileHeader := fh
+			files = append(files, &incomingFile{
+				Name:     fileHeader.Filename,
+				Size:     fileHeader.Size,
+				Uploader: user,
+				open:     func() (io.ReadCloser, error) { return fileHeader.Open() },
+			})
+		}
+	}
+
+	for _, uploadID := range strings.Split(c.PostForm("attachment_upload_ids"), ",") {
+		uploadID = strings.TrimSpace(uploadID)
+		if uploadID == "" {
+			continue
+		}
+		file, err := claimUpload(uploadID, user)
+		if err != nil {
+			releaseIncoming(files)
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	if len(files) > maxAttachmentsPerDocument {
+		releaseIncoming(files)
+		return nil, &requestError{
+			Status:  http.StatusBadRequest,
+			Code:    "too_many_attachments",
+			Message: fmt.Sprintf("Maksimal %d lampiran per surat", maxAttachmentsPerDocument),
+		}
+	}
+	return files, nil
+}
+
+// releaseIncoming mengembalikan sesi upload yang belum sempat disimpan.
+func releaseIncoming(files []*incomingFile) {
+	for _, f := range files {
+		f.release(false)
+	}
+}
+
+// storeAttachments menyimpan semua lampiran lewat storeFile (validasi dan
+// antivirus yang sama dengan file utama). Jika satu gagal, file yang sudah
+// tersimpan dihapus lagi.
+func storeAttachments(ctx context.Context, files []*incomingFile) ([]storedAttachment, error) {
+	var stored []storedAttachment
+	for i, file := range files {
+		obj, err := storeFile(ctx, file)
+		if err != nil {
+			deleteStoredAttachments(stored)
+			releaseIncoming(files[i+1:])
+			return nil, err
+		}
+		stored = append(stored, storedAttachment{Name: file.Name, Object: obj})
+	}
+	return stored, nil
+}
+
+func deleteStoredAttachments(stored []storedAttachment) {
+	for _, a := range stored {
+		deleteStoredFile(a.Object.Backend, a.Object.Key)
+	}
+}
+
+// createAttachmentRecords membuat record lampiran di urutan paling akhir.
+func createAttachmentRecords(tx *gorm.DB, documentID, uploaderID string, stored []storedAttachment) ([]models.DocumentAttachment, error) {
+	if len(stored) == 0 {
+		return []models.DocumentAttachment{}, nil
+	}
+
+	var count int64
+	if err := tx.Model(&models.DocumentAttachment{}).
+		Where("document_id = ?", documentID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if int(count)+len(stored) > maxAttachmentsPerDocument {
+		return nil, &requestError{
+			Status:  http.StatusBadRequest,
+			Code:    "too_many_attachments",
+			Message: fmt.Sprintf("Maksimal %d lampiran per surat", maxAttachmentsPerDocument),
+		}
+	}
+
+	var last int
+	if err := tx.Model(&models.DocumentAttachment{}).
+		Where("document_id = ?", documentID).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&last).Error; err != nil {
+		return nil, err
+	}
+
+	var attachments []models.DocumentAttachment
+	for i, a := range stored {
+		attachment := models.DocumentAttachment{
+			DocumentID:     documentID,
+			Position:       last + i + 1,
+			FileName:       a.Name,
+			FileURL:        a.Object.URL,
+			StorageKey:     a.Object.Key,
+			StorageBackend: a.Object.Backend,
+			Size:           a.Object.Size,
+			ContentType:    a.Object.ContentType,
+		}
+		if uploaderID != "" {
+			attachment.UploadedByID = &uploaderID
+		}
+		attachments = append(attachments, attachment)
+	}
+	err := tx.Create(&attachments).Error
+	return attachments, err
+}
+
+// deleteDocumentAttachments menghapus semua lampiran dokumen beserta filenya.
+func deleteDocumentAttachments(documentID string) {
+	var attachments []models.DocumentAttachment
+	config.DB.Where("document_id = ?", documentID).Find(&attachments)
+	for _, a := range attachments {
+		deleteStoredFile(a.StorageBackend, a.StorageKey)
+	}
+	config.DB.Where("document_id = ?", documentID).Delete(&models.DocumentAttachment{})
+}
+
+func attachmentDownloadable(a models.DocumentAttachment) downloadable {
+	return downloadable{
+		Backend:   a.StorageBackend,
+		Key:       a.StorageKey,
+		FileName:  a.FileName,
+		LegacyURL: a.FileURL,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
+
+// attachmentResponse menambahkan link download bertanda tangan untuk user.
+func attachmentResponse(attachments []models.DocumentAttachment, userID string) []gin.H {
+	response := []gin.H{}
+	for _, a := range attachments {
+		link, _ := signedDownloadURL("attachment", a.ID, userID)
+		response = append(response, gin.H{
+			"id":             a.ID,
+			"position":       a.Position,
+			"file_name":      a.FileName,
+			"size":           a.Size,
+			"content_type":   a.ContentType,
+			"uploaded_by_id": a.UploadedByID,
+			"created_at":     a.CreatedAt,
+			"download_url":   link,
+		})
+	}
+	return response
+}
+
+func listDocumentAttachments(documentID string) []models.DocumentAttachment {
+	var attachments []models.DocumentAttachment
+	config.DB.Where("document_id = ?", documentID).
+		Order("position ASC").Find(&attachments)
+	return attachments
+}
+
+// ======================================================
+// LIST ATTACHMENTS
+// ======================================================
+func GetDocumentAttachments(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"document_id": doc.ID,
+		"source":      documentSource(doc),
+		"attachments": attachmentResponse(listDocumentAttachments(doc.ID), user.ID),
+	})
+}
+
+// ======================================================
+// ADD ATTACHMENTS
+// ======================================================
+func AddDocumentAttachments(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+	if err := ensureDocumentEditable(doc); err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	files, err := incomingAttachmentsFromForm(c, user)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File lampiran tidak ditemukan"})
+		return
+	}
+
+	stored, err := storeAttachments(c.Request.Context(), files)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	var created []models.DocumentAttachment
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, err = createAttachmentRecords(tx, doc.ID, user.ID, stored)
+		return err
+	})
+	if err != nil {
+		deleteStoredAttachments(stored)
+		respondFileError(c, err)
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "ADD_ATTACHMENT",
+		fmt.Sprintf("Menambahkan %d lampiran pada dokumen: %s", len(created), doc.Subject))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Lampiran berhasil ditambahkan",
+		"attachments": attachmentResponse(created, user.ID),
+	})
+}
+
+// ======================================================
+// REORDER ATTACHMENTS
+// ======================================================
+// Body berisi seluruh id lampiran dalam urutan baru.
+func ReorderDocumentAttachments(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+	if err := ensureDocumentEditable(doc); err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	var input struct {
+		AttachmentIDs []string `json:"attachment_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		if err := tx.Model(&models.DocumentAttachment{}).
+			Where("document_id = ?", doc.ID).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+
+		// Urutan baru harus memuat semua lampiran tepat satu kali
+		existing := map[string]bool{}
+		for _, id := range ids {
+			existing[id] = true
+		}
+		seen := map[string]bool{}
+		for _, id := range input.AttachmentIDs {
+			if !existing[id] || seen[id] {
+				return &requestError{Status: http.StatusBadRequest, Code: "invalid_order", Message: "Daftar lampiran tidak sesuai: " + id}
+			}
+			seen[id] = true
+		}
+		if len(seen) != len(existing) {
+			return &requestError{Status: http.StatusBadRequest, Code: "invalid_order", Message: "Semua lampiran harus disertakan dalam urutan baru"}
+		}
+
+		for i, id := range input.AttachmentIDs {
+			if err := tx.Model(&models.DocumentAttachment{}).Where("id = ?", id).
+				Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Urutan lampiran berhasil disimpan",
+		"attachments": attachmentResponse(listDocumentAttachments(doc.ID), user.ID),
+	})
+}
+
+// ======================================================
+// DELETE ATTACHMENT
+// ======================================================
+func DeleteDocumentAttachment(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+	if err := ensureDocumentEditable(doc); err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	var attachment models.DocumentAttachment
+	if err := config.DB.First(&attachment, "id = ? AND document_id = ?",
+		c.Param("attachment_id"), doc.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lampiran tidak ditemukan"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&attachment).Error; err != nil {
+			return err
+		}
+		// Rapatkan urutan lampiran setelahnya
+		return tx.Model(&models.DocumentAttachment{}).
+			Where("document_id = ? AND position > ?", doc.ID, attachment.Position).
+			UpdateColumn("position", gorm.Expr("position - 1")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus lampiran: " + err.Error()})
+		return
+	}
+	deleteStoredFile(attachment.StorageBackend, attachment.StorageKey)
+
+	CreateActivityLog(user.ID, user.Name, "DELETE_ATTACHMENT",
+		fmt.Sprintf("Menghapus lampiran %s dari dokumen: %s", attachment.FileName, doc.Subject))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lampiran berhasil dihapus"})
+}
+
+// ======================================================
+// DOWNLOAD ATTACHMENT
+// ======================================================
+func DownloadDocumentAttachment(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	var attachment models.DocumentAttachment
+	if err := config.DB.First(&attachment, "id = ? AND document_id = ?",
+		c.Param("attachment_id"), doc.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lampiran tidak ditemukan"})
+		return
+	}
+
+	serveDownloadable(c, attachmentDownloadable(attachment))
+}
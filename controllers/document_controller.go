@@ -1,17 +1,17 @@
 package controllers
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"path/filepath"
 	"strings"
 
 	"dinsos_kuburaya/config"
 	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/storage"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // =======================
@@ -23,6 +23,8 @@ func CreateDocument(c *gin.Context) {
 	subject := c.PostForm("subject")
 	letterType := c.PostForm("letter_type")
 	targetUserIDsStr := c.PostForm("target_user_ids")
+	// Nomor register: pakai nomor hasil reservasi atau ambil nomor berikutnya
+	reservationID := c.PostForm("reservation_id")
 
 	// Cek User (Admin)
 	userInterface, exists := c.Get("user")
@@ -36,63 +38,108 @@ func CreateDocument(c *gin.Context) {
 		return
 	}
 
-	//  Handle File Upload
-	fileHeader, err := c.FormFile("file")
+	// Data agenda surat divalidasi sebelum file disimpan
+	metadata, _, err := parseLetterMetadata(c, models.LetterMetadata{})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File tidak ditemukan"})
+		respondFileError(c, err)
 		return
 	}
-
-	src, err := fileHeader.Open()
+	// Dokumen admin terbuka untuk semua staff kecuali diminta private
+	visibility, err := parseDocumentVisibility(c, "public")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuka file"})
+		respondFileError(c, err)
 		return
 	}
-	defer src.Close()
 
-	fileBytes, err := io.ReadAll(src)
+	//  Handle File Upload (multipart "file" atau "upload_id" dari upload bertahap)
+	incoming, err := incomingFileFromForm(c, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membaca file buffer"})
+		respondFileError(c, err)
+		return
+	}
+	if incoming == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File tidak ditemukan"})
 		return
 	}
 
-	//  Tentukan Folder Cloudinary
-	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	resourceType := "raw"
-	folder := "dinsos_kuburaya/arsip"
-
-	if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif" || ext == ".webp" {
-		resourceType = "image"
-		folder = "dinsos_kuburaya/gambar"
+	stored, err := storeFile(c.Request.Context(), incoming)
+	if err != nil {
+		respondFileError(c, err)
+		return
 	}
 
-	reader := bytes.NewReader(fileBytes)
-	uploadResult, err := config.UploadToCloudinary(reader, fileHeader.Filename, folder, resourceType)
+	// Lampiran (opsional) melalui validasi dan penyimpanan yang sama dengan file utama
+	attachmentFiles, err := incomingAttachmentsFromForm(c, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cloudinary upload gagal: " + err.Error()})
+		deleteStoredFile(stored.Backend, stored.Key)
+		respondFileError(c, err)
+		return
+	}
+	storedAttachments, err := storeAttachments(c.Request.Context(), attachmentFiles)
+	if err != nil {
+		deleteStoredFile(stored.Backend, stored.Key)
+		respondFileError(c, err)
 		return
 	}
 
 	// Simpan ke Database
 	userID := user.ID
 	document := models.Document{
-		Sender:       sender,
-		FileName:     fileHeader.Filename,
-		FileURL:      uploadResult.SecureURL,
-		Subject:      subject,
-		LetterType:   letterType,
-		UserID:       &userID,
-		PublicID:     uploadResult.PublicID,
-		ResourceType: uploadResult.ResourceType,
-	}
-
-	if err := config.DB.Create(&document).Error; err != nil {
-		config.DeleteFromCloudinary(uploadResult.PublicID, resourceType)
+		Sender:         sender,
+		FileName:       incoming.Name,
+		FileURL:        stored.URL,
+		Subject:        subject,
+		LetterType:     letterType,
+		UserID:         &userID,
+		StorageKey:     stored.Key,
+		StorageBackend: stored.Backend,
+		Origin:         "admin",
+		Visibility:     visibility,
+		LetterMetadata: metadata,
+	}
+
+	var attachments []models.DocumentAttachment
+	var dispositions dispositionDiff
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&document).Error; err != nil {
+			return err
+		}
+		number, err := assignLetterNumber(tx, document.ID, letterType, metadata.ClassificationCode, reservationID, user)
+		if err != nil {
+			return err
+		}
+		if number != "" {
+			document.RegisterNumber = number
+			if err := tx.Model(&document).UpdateColumn("register_number", number).Error; err != nil {
+				return err
+			}
+		}
+		if _, err := recordDocumentVersion(tx, document.ID, incoming.Name, stored, user.ID, "Versi awal"); err != nil {
+			return err
+		}
+		attachments, err = createAttachmentRecords(tx, document.ID, user.ID, storedAttachments)
+		if err != nil {
+			return err
+		}
+		// Disposisi ke staff yang dipilih ikut dalam transaksi dokumen
+		dispositions, err = syncDispositions(tx, document, splitIDs(targetUserIDsStr),
+			models.SuperiorOrder{InstructionOptions: []string{}, Priority: "biasa"}, &userID, false)
+		return err
+	})
+	if err != nil {
+		deleteStoredFile(stored.Backend, stored.Key)
+		deleteStoredAttachments(storedAttachments)
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			respondFileError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan dokumen: " + err.Error()})
 		return
 	}
 
 	CreateActivityLog(user.ID, user.Name, "UPLOAD_DOCUMENT", "Mengunggah dokumen: "+document.FileName)
+	queueFileProcessing(document.ID)
 
 	// ============================================================
 	//  LOGIKA SUPERIOR ORDER & NOTIFIKASI
@@ -102,7 +149,7 @@ func CreateDocument(c *gin.Context) {
 	docSubject := document.Subject
 
 	// Jalankan Background Process
-	go func(dID, dSubject, tIDs string) {
+	go func(dID, dSubject string, added []models.SuperiorOrder) {
 		defer func() {
 			if r := recover(); r != nil {
 				fmt.Printf("Warning: Recovered from panic: %v\n", r)
@@ -110,24 +157,12 @@ func CreateDocument(c *gin.Context) {
 		}()
 
 		processedUsers := make(map[string]bool)
-		//  PROSES DISPOSISI (Jika ada staff dipilih)
-		if tIDs != "" {
-			targetUserIDs := strings.Split(tIDs, ",")
-			for _, targetID := range targetUserIDs {
-				targetID = strings.TrimSpace(targetID)
-				if targetID == "" {
-					continue
-				}
-
-				order := models.SuperiorOrder{DocumentID: dID, UserID: targetID}
-
-				if err := config.DB.Create(&order).Error; err == nil {
-					msg := fmt.Sprintf("PERINTAH: Anda menerima disposisi baru: %s", dSubject)
-					link := fmt.Sprintf("/dashboard/my-document/%s", dID)
-					_ = CreateNotification(targetID, msg, link)
-					processedUsers[targetID] = true
-				}
-			}
+		//  NOTIFIKASI DISPOSISI (Jika ada staff dipilih)
+		for _, order := range added {
+			msg := fmt.Sprintf("PERINTAH: Anda menerima disposisi baru: %s", dSubject)
+			link := fmt.Sprintf("/dashboard/my-document/%s", dID)
+			_ = CreateNotification(order.UserID, msg, link)
+			processedUsers[order.UserID] = true
 		}
 
 		//  PROSES BROADCAST (Ke semua staff lain)
@@ -144,12 +179,13 @@ func CreateDocument(c *gin.Context) {
 			}
 		}
 
-	}(docID, docSubject, targetUserIDsStr)
+	}(docID, docSubject, dispositions.Added)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "Dokumen berhasil diupload dan diproses",
-		"document": document,
-		"file_url": uploadResult.SecureURL,
+		"message":     "Dokumen berhasil diupload dan diproses",
+		"document":    document,
+		"file_url":    documentDownloadPath(document),
+		"attachments": attachmentResponse(attachments, user.ID),
 	})
 }
 
@@ -157,18 +193,38 @@ func CreateDocument(c *gin.Context) {
 // GET ALL DOCUMENTS
 // =======================
 func GetDocuments(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
 	var documents []models.Document
-	search := c.Query("search")
-	letterType := c.Query("letter_type")
-	query := config.DB.Preload("User")
+	search, err := parseDocumentSearch(c)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+	// Endpoint lama /documents hanya menampilkan dokumen admin kecuali ?origin diisi
+	if c.Query("origin") == "" {
+		search.Origin = "admin"
+	}
+	query := visibleDocuments(config.DB.Preload("User"), user)
 
-	if letterType != "" && letterType != "all" {
-		query = query.Where("letter_type = ?", letterType)
+	for _, cond := range search.conditions(documentSearchColumns) {
+		query = query.Where(cond.SQL, cond.Args...)
+	}
+	// Urutan: sort dari user, lalu relevansi hasil pencarian, lalu terbaru dulu
+	if order := search.orderBy(); order != "" {
+		query = query.Order(order)
 	}
-	if search != "" {
-		searchPattern := "%" + search + "%"
-		query = query.Where("sender LIKE ? OR subject LIKE ? OR file_name LIKE ?",
-			searchPattern, searchPattern, searchPattern)
+	if search.Boolean != "" {
+		scoreSQL, scoreArgs := search.scoreExpr(documentSearchColumns)
+		query = query.Select("documents.*, "+scoreSQL+" AS score", scoreArgs...).Order("score DESC")
+	} else if len(search.Terms) == 0 {
+		// Teks hasil OCR hanya perlu diambil untuk highlight pencarian
+		query = query.Omit("extracted_text")
 	}
 
 	if err := query.Order("created_at DESC").Find(&documents).Error; err != nil {
@@ -176,6 +232,12 @@ func GetDocuments(c *gin.Context) {
 		return
 	}
 
+	ids := make([]string, 0, len(documents))
+	for _, doc := range documents {
+		ids = append(ids, doc.ID)
+	}
+	tags := documentTags(ids)
+
 	var response []gin.H
 	for _, doc := range documents {
 		userName := "-"
@@ -183,17 +245,44 @@ func GetDocuments(c *gin.Context) {
 			userName = doc.User.Name
 		}
 		response = append(response, gin.H{
-			"id":          doc.ID,
-			"sender":      doc.Sender,
-			"file_name":   doc.FileName,
-			"file_url":    doc.FileURL,
-			"subject":     doc.Subject,
-			"letter_type": doc.LetterType,
-			"user_id":     doc.UserID,
-			"user_name":   userName,
-			"created_at":  doc.CreatedAt,
-			"updated_at":  doc.UpdatedAt,
-			"user":        doc.User,
+			"id":              doc.ID,
+			"sender":          doc.Sender,
+			"file_name":       doc.FileName,
+			"file_url":        documentDownloadPath(doc),
+			"subject":         doc.Subject,
+			"letter_type":     doc.LetterType,
+			"register_number": doc.RegisterNumber,
+			"origin":          doc.Origin,
+			"visibility":      doc.Visibility,
+			"workflow_status": doc.WorkflowStatus,
+			"folder_id":       doc.FolderID,
+			"tags":            tagsOf(tags, doc.ID),
+
+			"sender_letter_number": doc.SenderLetterNumber,
+			"letter_date":          doc.LetterDate,
+			"received_date":        doc.ReceivedDate,
+			"classification_code":  doc.ClassificationCode,
+			"urgency":              doc.Urgency,
+			"confidentiality":      doc.Confidentiality,
+			"page_count":           doc.PageCount,
+			"attachment_count":     doc.AttachmentCount,
+			"reply_required_by":    doc.ReplyRequiredBy,
+
+			"user_id":           doc.UserID,
+			"user_name":         userName,
+			"created_at":        doc.CreatedAt,
+			"updated_at":        doc.UpdatedAt,
+			"user":              doc.User,
+			"score":             doc.SearchScore,
+			"extraction_status": doc.ExtractionStatus,
+			"preview_status":    doc.PreviewStatus,
+			"thumbnail_url":     thumbnailURL(doc.ID, doc.PreviewStatus, user.ID),
+			"highlights": search.highlights(map[string]string{
+				"sender":         doc.Sender,
+				"subject":        doc.Subject,
+				"file_name":      doc.FileName,
+				"extracted_text": doc.ExtractedText,
+			}),
 		})
 	}
 
@@ -219,77 +308,67 @@ func GetDocumentByID(c *gin.Context) {
 	user := userRaw.(models.User)
 
 	var document models.Document
-	errDoc := config.DB.Preload("User").Where("id = ?", id).First(&document).Error
-
-	if errDoc == nil {
-		if user.Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke dokumen admin"})
-		}
-
-		userName := "-"
-		if document.User.Name != "" {
-			userName = document.User.Name
-		}
-
-		response := gin.H{
-			"id":          document.ID,
-			"sender":      document.Sender,
-			"file_name":   document.FileName,
-			"file_url":    document.FileURL,
-			"subject":     document.Subject,
-			"letter_type": document.LetterType,
-			"user_id":     document.UserID,
-			"user_name":   userName,
-			"created_at":  document.CreatedAt,
-			"updated_at":  document.UpdatedAt,
-			"user":        document.User,
-		}
-		c.JSON(http.StatusOK, gin.H{"document": response})
+	if err := config.DB.Preload("User").Where("id = ?", id).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
 		return
 	}
-
-	// Cek di tabel staff jika tidak ketemu di admin
-	var docStaff models.DocumentStaff
-	errStaff := config.DB.Preload("User").First(&docStaff, "id = ?", id).Error
-
-	if errStaff == nil {
-		if user.Role != "admin" && docStaff.UserID != user.ID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke dokumen staff lain"})
-			return
-		}
-
-		userName := "-"
-		if docStaff.User.Name != "" {
-			userName = docStaff.User.Name
-		}
-
-		response := gin.H{
-			"id":          docStaff.ID,
-			"sender":      docStaff.Sender,
-			"file_name":   docStaff.FileName,
-			"file_url":    docStaff.FileName,
-			"subject":     docStaff.Subject,
-			"letter_type": docStaff.LetterType,
-			"user_id":     docStaff.UserID,
-			"user_name":   userName,
-			"created_at":  docStaff.CreatedAt,
-			"updated_at":  docStaff.UpdatedAt,
-			"user":        docStaff.User,
-			"source":      "staff",
-		}
-		c.JSON(http.StatusOK, gin.H{"document": response})
+	if !canAccessDocument(user, document) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke dokumen ini"})
 		return
 	}
 
-	c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+	userName := "-"
+	if document.User.Name != "" {
+		userName = document.User.Name
+	}
+
+	response := gin.H{
+		"id":              document.ID,
+		"sender":          document.Sender,
+		"file_name":       document.FileName,
+		"file_url":        documentDownloadPath(document),
+		"subject":         document.Subject,
+		"letter_type":     document.LetterType,
+		"register_number": document.RegisterNumber,
+		"origin":          document.Origin,
+		"visibility":      document.Visibility,
+		"workflow_status": document.WorkflowStatus,
+		"folder_id":       document.FolderID,
+		"tags":            tagsOf(documentTags([]string{document.ID}), document.ID),
+
+		"sender_letter_number": document.SenderLetterNumber,
+		"letter_date":          document.LetterDate,
+		"received_date":        document.ReceivedDate,
+		"classification_code":  document.ClassificationCode,
+		"urgency":              document.Urgency,
+		"confidentiality":      document.Confidentiality,
+		"page_count":           document.PageCount,
+		"attachment_count":     document.AttachmentCount,
+		"reply_required_by":    document.ReplyRequiredBy,
+
+		"user_id":    document.UserID,
+		"user_name":  userName,
+		"created_at": document.CreatedAt,
+		"updated_at": document.UpdatedAt,
+		"user":       document.User,
+
+		"preview_status": document.PreviewStatus,
+		"thumbnail_url":  thumbnailURL(document.ID, document.PreviewStatus, user.ID),
+		"attachments":    attachmentResponse(listDocumentAttachments(document.ID), user.ID),
+	}
+	// Penanda lama untuk dokumen yang diupload staff
+	if document.Origin == "staff" {
+		response["source"] = "staff"
+	}
+	c.JSON(http.StatusOK, gin.H{"document": response})
 }
 
 // =======================
 // UPDATE DOCUMENT
 // =======================
+// Route /documents hanya untuk admin; /document_staff memakai handler yang sama
+// dengan pengecekan pemilik di updateDocLogic.
 func UpdateDocument(c *gin.Context) {
-	id := c.Param("id")
-
 	userRaw, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -297,72 +376,117 @@ func UpdateDocument(c *gin.Context) {
 	}
 	user := userRaw.(models.User)
 
-	var document models.Document
-	if err := config.DB.Where("id = ?", id).First(&document).Error; err == nil {
-		// Hanya admin yang boleh edit dokumen admin
-		if user.Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Hanya admin yang dapat mengedit dokumen ini"})
-			return
-		}
-		updateDocLogic(c, &document, user)
+	document, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
 		return
 	}
-
-	c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+	updateDocLogic(c, &document, user)
 }
 
-// Helper update logic
+// Helper update logic. Perubahan disimpan per kolom (bukan Save) agar hasil
+// ekstraksi teks dan preview yang sedang berjalan di background tidak tertimpa.
 func updateDocLogic(c *gin.Context, document *models.Document, user models.User) {
+	if !canEditDocument(user, *document) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengedit dokumen ini"})
+		return
+	}
+	if err := ensureDocumentEditable(*document); err != nil {
+		respondFileError(c, err)
+		return
+	}
+
 	sender := c.PostForm("sender")
 	subject := c.PostForm("subject")
 	letterType := c.PostForm("letter_type")
 
+	updates := map[string]interface{}{}
+
 	if sender != "" {
-		document.Sender = sender
+		updates["sender"] = sender
 	}
 	if subject != "" {
-		document.Subject = subject
+		updates["subject"] = subject
 	}
 	if letterType != "" {
-		document.LetterType = letterType
-	}
-
-	fileHeader, err := c.FormFile("file")
-	if err == nil {
-		src, _ := fileHeader.Open()
-		defer src.Close()
-		fileBytes, _ := io.ReadAll(src)
-		reader := bytes.NewReader(fileBytes)
-
-		ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-		resourceType := "raw"
-		folder := "dinsos_kuburaya/arsip"
-		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif" || ext == ".webp" {
-			resourceType = "image"
-			folder = "dinsos_kuburaya/gambar"
+		// Alur persetujuan hanya untuk surat keluar
+		if document.WorkflowStatus != "" && letterType != "keluar" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis surat dalam alur persetujuan harus keluar"})
+			return
 		}
-
-		if document.PublicID != "" {
-			config.DeleteFromCloudinary(document.PublicID, document.ResourceType)
+		updates["letter_type"] = letterType
+	}
+	if _, ok := c.GetPostForm("visibility"); ok {
+		visibility, err := parseDocumentVisibility(c, document.Visibility)
+		if err != nil {
+			respondFileError(c, err)
+			return
 		}
+		updates["visibility"] = visibility
+	}
 
-		uploadResult, err := config.UploadToCloudinary(reader, fileHeader.Filename, folder, resourceType)
+	_, metadataUpdates, err := parseLetterMetadata(c, document.LetterMetadata)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+	for k, v := range metadataUpdates {
+		updates[k] = v
+	}
+
+	var stored *storage.Object
+
+	incoming, err := incomingFileFromForm(c, user)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+	if incoming != nil {
+		obj, err := storeFile(c.Request.Context(), incoming)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal upload file: " + err.Error()})
+			respondFileError(c, err)
 			return
 		}
+		stored = &obj
+
+		updates["file_name"] = incoming.Name
+		updates["file_url"] = obj.URL
+		updates["storage_key"] = obj.Key
+		updates["storage_backend"] = obj.Backend
 
-		document.FileName = fileHeader.Filename
-		document.FileURL = uploadResult.SecureURL
-		document.PublicID = uploadResult.PublicID
-		document.ResourceType = uploadResult.ResourceType
+		// Teks dan preview file lama tidak berlaku lagi, diproses ulang untuk file baru
+		for k, v := range resetFileProcessingFields() {
+			updates[k] = v
+		}
 	}
 
-	if err := config.DB.Save(&document).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal simpan update: " + err.Error()})
-		return
+	// File lama tidak dihapus, melainkan tetap tersimpan sebagai versi sebelumnya
+	if len(updates) > 0 {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(document).Updates(updates).Error; err != nil {
+				return err
+			}
+			if stored != nil {
+				_, err := recordDocumentVersion(tx, document.ID, incoming.Name, *stored, user.ID, c.PostForm("change_note"))
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			if stored != nil {
+				deleteStoredFile(stored.Backend, stored.Key)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal simpan update: " + err.Error()})
+			return
+		}
+	}
+
+	if stored != nil {
+		queueFileProcessing(document.ID)
 	}
 
+	config.DB.Preload("User").First(document, "id = ?", document.ID)
+
 	CreateActivityLog(user.ID, user.Name, "UPDATE_DOCUMENT", "Memperbarui dokumen: "+document.Subject)
 	c.JSON(http.StatusOK, gin.H{"message": "Dokumen berhasil diperbarui", "document": document})
 }
@@ -371,7 +495,6 @@ func updateDocLogic(c *gin.Context, document *models.Document, user models.User)
 // DELETE DOCUMENT
 // =======================
 func DeleteDocument(c *gin.Context) {
-	id := c.Param("id")
 	userRaw, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -379,48 +502,36 @@ func DeleteDocument(c *gin.Context) {
 	}
 	user := userRaw.(models.User)
 
-	var document models.Document
-	if err := config.DB.Where("id = ?", id).First(&document).Error; err == nil {
-		if user.Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Hanya admin yang dapat menghapus dokumen ini"})
-			return
-		}
-
-		if document.PublicID != "" {
-			config.DeleteFromCloudinary(document.PublicID, document.ResourceType)
-		}
-		config.DB.Delete(&document)
-		CreateActivityLog(user.ID, user.Name, "DELETE_DOCUMENT", "Menghapus dokumen: "+document.FileName)
-		c.JSON(http.StatusOK, gin.H{"message": "Dokumen berhasil dihapus"})
+	document, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
 		return
 	}
-
-	// Cek jika user mencoba menghapus dokumen staff via endpoint ini
-	var docStaff models.DocumentStaff
-	if err := config.DB.First(&docStaff, "id = ?", id).Error; err == nil {
-		// Admin boleh hapus dokumen staff
-		if user.Role != "admin" && docStaff.UserID != user.ID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Tidak punya akses hapus"})
-			return
-		}
-
-		if docStaff.PublicID != "" {
-			config.DeleteFromCloudinary(docStaff.PublicID, docStaff.ResourceType)
-		}
-		config.DB.Delete(&docStaff)
-		CreateActivityLog(user.ID, user.Name, "DELETE_DOCUMENT", "Menghapus dokumen staff: "+docStaff.FileName)
-		c.JSON(http.StatusOK, gin.H{"message": "Dokumen berhasil dihapus"})
+	if !canEditDocument(user, document) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak punya akses hapus"})
+		return
+	}
+	if err := ensureDocumentEditable(document); err != nil {
+		respondFileError(c, err)
+		return
+	}
+	if err := ensureDocumentDeletable(document); err != nil {
+		respondFileError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+	if err := moveToRecycleBin(&document, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus dokumen: " + err.Error()})
+		return
+	}
+	CreateActivityLog(user.ID, user.Name, "DELETE_DOCUMENT", "Memindahkan dokumen ke recycle bin: "+document.Subject)
+	c.JSON(http.StatusOK, gin.H{"message": "Dokumen berhasil dihapus"})
 }
 
 // =======================
 // DOWNLOAD DOCUMENT
 // =======================
 func DownloadDocument(c *gin.Context) {
-	id := c.Param("id")
 	userRaw, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -428,21 +539,34 @@ func DownloadDocument(c *gin.Context) {
 	}
 	user := userRaw.(models.User)
 
-	var document models.Document
-	if err := config.DB.First(&document, "id = ?", id).Error; err == nil {
-		c.Redirect(http.StatusTemporaryRedirect, document.FileURL)
+	document, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File tidak tersedia"})
 		return
 	}
-
-	var docStaff models.DocumentStaff
-	if err := config.DB.First(&docStaff, "id = ?", id).Error; err == nil {
-		if user.Role != "admin" && docStaff.UserID != user.ID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
-			return
-		}
-		c.Redirect(http.StatusTemporaryRedirect, docStaff.FileName)
+	if !canAccessDocument(user, document) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
 		return
 	}
+	serveDownloadable(c, documentDownloadable(document))
+}
 
-	c.JSON(http.StatusNotFound, gin.H{"error": "File tidak tersedia"})
+// Nilai visibility yang diterima dari form
+var documentVisibilities = map[string]bool{"public": true, "private": true}
+
+// parseDocumentVisibility membaca field visibility, def dipakai jika kosong.
+func parseDocumentVisibility(c *gin.Context, def string) (string, error) {
+	v := strings.ToLower(strings.TrimSpace(c.PostForm("visibility")))
+	if v == "" {
+		return def, nil
+	}
+	if !documentVisibilities[v] {
+		return "", &requestError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_visibility",
+			Message: "visibility harus public atau private",
+			Details: map[string]interface{}{"field": "visibility"},
+		}
+	}
+	return v, nil
 }
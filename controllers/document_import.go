@@ -0,0 +1,726 @@
+package controllers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/spreadsheet"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// importField adalah kolom spreadsheet yang bisa dipetakan ke field dokumen.
+// Aliases dipakai untuk memetakan kolom otomatis dari judul kolom.
+type importField struct {
+	Key      string
+	Required bool
+	Aliases  []string
+}
+
+var importFields = []importField{
+	{Key: "subject", Required: true, Aliases: []string{"perihal", "hal", "subject", "judul"}},
+	{Key: "file_name", Required: true, Aliases: []string{"file", "nama file", "file scan", "scan", "file_name"}},
+	{Key: "sender", Aliases: []string{"pengirim", "asal surat", "dari", "sender", "tujuan"}},
+	{Key: "letter_type", Aliases: []string{"jenis surat", "jenis", "letter_type"}},
+	{Key: "register_number", Aliases: []string{"nomor register", "no register", "nomor agenda", "no agenda", "register_number"}},
+	{Key: "sender_letter_number", Aliases: []string{"nomor surat", "no surat", "sender_letter_number"}},
+	{Key: "letter_date", Aliases: []string{"tanggal surat", "tgl surat", "letter_date"}},
+	{Key: "received_date", Aliases: []string{"tanggal diterima", "tanggal terima", "tgl terima", "received_date"}},
+	{Key: "classification_code", Aliases: []string{"kode klasifikasi", "klasifikasi", "classification_code"}},
+	{Key: "urgency", Aliases: []string{"sifat", "urgency"}},
+	{Key: "confidentiality", Aliases: []string{"kerahasiaan", "confidentiality"}},
+	{Key: "page_count", Aliases: []string{"jumlah halaman", "halaman", "page_count"}},
+	{Key: "attachment_count", Aliases: []string{"jumlah lampiran", "lampiran", "attachment_count"}},
+	{Key: "reply_required_by", Aliases: []string{"batas balasan", "batas waktu balasan", "reply_required_by"}},
+}
+
+// Baris yang diproses worker dalam satu putaran sebelum status impor dicek ulang
+const importBatchSize = 20
+
+// Impor running yang worker-nya tidak memperbarui heartbeat selama ini dianggap
+// terputus (server restart atau CLI berhenti) dan dilanjutkan job terjadwal
+const staleImportAfter = 10 * time.Minute
+
+// Baris tidak valid yang ikut dikirim di laporan dry run
+const importReportLimit = 100
+
+var (
+	nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+	// importsInFlight mencegah satu impor dijalankan dua worker sekaligus
+	importsInFlight sync.Map
+)
+
+func importStagingDir() string {
+	dir := os.Getenv("IMPORT_TMP_DIR")
+	if dir == "" {
+		dir = "imports_tmp"
+	}
+	return dir
+}
+
+// normalizeHeader: "Tgl. Surat" -> "tgl surat"
+func normalizeHeader(v string) string {
+	return strings.TrimSpace(nonAlphanumeric.ReplaceAllString(strings.ToLower(v), " "))
+}
+
+// resolveImportMapping menentukan indeks kolom untuk setiap field. custom berisi
+// field -> judul kolom dari user; field lain dipetakan otomatis lewat alias.
+func resolveImportMapping(headers []string, custom map[string]string) (map[string]int, error) {
+	byHeader := map[string]int{}
+	for i, h := range headers {
+		if n := normalizeHeader(h); n != "" {
+			if _, dup := byHeader[n]; !dup {
+				byHeader[n] = i
+			}
+		}
+	}
+
+	known := map[string]bool{}
+	for _, f := range importFields {
+		known[f.Key] = true
+	}
+	for key := range custom {
+		if !known[key] {
+			return nil, fmt.Errorf("field %s tidak dikenal", key)
+		}
+	}
+
+	mapping := map[string]int{}
+	for _, f := range importFields {
+		if header, ok := custom[f.Key]; ok {
+			if strings.TrimSpace(header) == "" {
+				continue // sengaja tidak dipetakan
+			}
+			i, found := byHeader[normalizeHeader(header)]
+			if !found {
+				return nil, fmt.Errorf("kolom %q untuk field %s tidak ada di spreadsheet", header, f.Key)
+			}
+			mapping[f.Key] = i
+			continue
+		}
+		for _, alias := range f.Aliases {
+			if i, found := byHeader[normalizeHeader(alias)]; found {
+				mapping[f.Key] = i
+				break
+			}
+		}
+	}
+
+	var missing []string
+	for _, f := range importFields {
+		if _, ok := mapping[f.Key]; f.Required && !ok {
+			missing = append(missing, f.Key)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("kolom wajib belum dipetakan: %s", strings.Join(missing, ", "))
+	}
+	return mapping, nil
+}
+
+// importRowData adalah isi satu baris yang sudah divalidasi, disimpan sebagai
+// JSON di document_import_rows agar impor bisa dilanjutkan tanpa membaca ulang spreadsheet.
+type importRowData struct {
+	Subject        string                `json:"subject"`
+	Sender         string                `json:"sender"`
+	LetterType     string                `json:"letter_type"`
+	RegisterNumber string                `json:"register_number"`
+	FileName       string                `json:"file_name"`
+	Metadata       models.LetterMetadata `json:"metadata"`
+}
+
+// parseImportDate menerima YYYY-MM-DD, DD/MM/YYYY, DD-MM-YYYY dan angka seri
+// tanggal Excel (sel bertipe tanggal di XLSX).
+func parseImportDate(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if serial, err := strconv.ParseFloat(v, 64); err == nil {
+		if serial < 1 || serial > 100000 {
+			return nil, fmt.Errorf("tanggal %q tidak valid", v)
+		}
+		t := time.Date(1899, 12, 30, 0, 0, 0, 0, time.Local).AddDate(0, 0, int(serial))
+		return &t, nil
+	}
+	for _, layout := range []string{"2006-01-02", "2/1/2006", "2-1-2006", "2.1.2006", "2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00"} {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+			return &d, nil
+		}
+	}
+	return nil, fmt.Errorf("tanggal %q tidak valid (gunakan YYYY-MM-DD atau DD/MM/YYYY)", v)
+}
+
+// archiveIndex memetakan nama file (huruf kecil, tanpa folder) ke isi arsip ZIP.
+// Nama yang muncul lebih dari sekali di folder berbeda ditandai ambigu.
+type archiveIndex struct {
+	byName    map[string][]*zip.File
+	byStem    map[string][]*zip.File
+	usedNames map[string]int // path di arsip -> nomor baris yang memakainya
+}
+
+func newArchiveIndex(files []*zip.File) *archiveIndex {
+	idx := &archiveIndex{byName: map[string][]*zip.File{}, byStem: map[string][]*zip.File{}, usedNames: map[string]int{}}
+	for _, f := range files {
+		base := path.Base(f.Name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+		name := strings.ToLower(base)
+		idx.byName[name] = append(idx.byName[name], f)
+		stem := strings.TrimSuffix(name, path.Ext(name))
+		idx.byStem[stem] = append(idx.byStem[stem], f)
+	}
+	return idx
+}
+
+// find mencocokkan nilai kolom file dengan isi arsip. Jika kolom tidak memakai
+// ekstensi, file dicari berdasarkan nama tanpa ekstensi.
+func (idx *archiveIndex) find(value string) (*zip.File, error) {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(strings.TrimSpace(value), "\\", "/")))
+	matches := idx.byName[name]
+	if len(matches) == 0 && path.Ext(name) == "" {
+		matches = idx.byStem[name]
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("file %s tidak ditemukan di arsip", value)
+	case 1:
+		return matches[0], nil
+	}
+	// Nama kembar: coba cocokkan path lengkap
+	full := strings.ToLower(strings.Trim(strings.ReplaceAll(value, "\\", "/"), "/"))
+	for _, f := range matches {
+		if strings.ToLower(f.Name) == full {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("ada %d file bernama %s di arsip, tulis path lengkapnya", len(matches), path.Base(name))
+}
+
+// validateImportRow memetakan satu baris spreadsheet dan mengumpulkan semua
+// kesalahannya sekaligus agar laporan dry run lengkap.
+func validateImportRow(rowNumber int, values []string, mapping map[string]int, defaultType string, idx *archiveIndex) (importRowData, []string) {
+	get := func(key string) string {
+		i, ok := mapping[key]
+		if !ok || i >= len(values) {
+			return ""
+		}
+		return strings.TrimSpace(values[i])
+	}
+
+	var errs []string
+	data := importRowData{
+		Subject:        get("subject"),
+		Sender:         get("sender"),
+		RegisterNumber: get("register_number"),
+		Metadata:       models.LetterMetadata{Urgency: "biasa", Confidentiality: "biasa"},
+	}
+	if data.Subject == "" {
+		errs = append(errs, "Perihal wajib diisi")
+	} else if len([]rune(data.Subject)) > 255 {
+		errs = append(errs, "Perihal maksimal 255 karakter")
+	}
+	if len([]rune(data.Sender)) > 255 {
+		errs = append(errs, "Pengirim maksimal 255 karakter")
+	}
+	if len(data.RegisterNumber) > 100 {
+		errs = append(errs, "Nomor register maksimal 100 karakter")
+	}
+
+	switch v := strings.ToLower(get("letter_type")); {
+	case v == "":
+		data.LetterType = defaultType
+		if defaultType == "" {
+			errs = append(errs, "Jenis surat wajib diisi (kolom jenis surat atau letter_type default)")
+		}
+	case strings.Contains(v, "masuk"):
+		data.LetterType = "masuk"
+	case strings.Contains(v, "keluar"):
+		data.LetterType = "keluar"
+	default:
+		errs = append(errs, "Jenis surat harus masuk atau keluar")
+	}
+
+	meta := &data.Metadata
+	meta.SenderLetterNumber = get("sender_letter_number")
+	if len(meta.SenderLetterNumber) > 100 {
+		errs = append(errs, "Nomor surat maksimal 100 karakter")
+	}
+	for _, field := range []string{"letter_date", "received_date", "reply_required_by"} {
+		date, err := parseImportDate(get(field))
+		if err != nil {
+			errs = append(errs, field+": "+err.Error())
+			continue
+		}
+		switch field {
+		case "letter_date":
+			meta.LetterDate = date
+		case "received_date":
+			meta.ReceivedDate = date
+		default:
+			meta.ReplyRequiredBy = date
+		}
+	}
+	if meta.ReceivedDate != nil && meta.ReceivedDate.After(time.Now()) {
+		errs = append(errs, "Tanggal diterima tidak boleh di masa depan")
+	}
+	if meta.LetterDate != nil && meta.ReceivedDate != nil && meta.ReceivedDate.Before(*meta.LetterDate) {
+		errs = append(errs, "Tanggal diterima tidak boleh sebelum tanggal surat")
+	}
+
+	meta.ClassificationCode = get("classification_code")
+	if meta.ClassificationCode != "" && !classificationCodePattern.MatchString(meta.ClassificationCode) {
+		errs = append(errs, "Kode klasifikasi tidak valid (contoh: 460 atau 400.7.1)")
+	}
+	if v := normalizeLetterOption(get("urgency")); v != "" {
+		if !letterUrgencies[v] {
+			errs = append(errs, "Sifat surat harus biasa, segera atau sangat_segera")
+		}
+		meta.Urgency = v
+	}
+	if v := normalizeLetterOption(get("confidentiality")); v != "" {
+		if !letterConfidentialities[v] {
+			errs = append(errs, "Tingkat kerahasiaan harus biasa, terbatas, rahasia atau sangat_rahasia")
+		}
+		meta.Confidentiality = v
+	}
+	for _, field := range []string{"page_count", "attachment_count"} {
+		v := get(field)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxLetterPageCount {
+			errs = append(errs, field+" harus berupa angka 0 sampai "+strconv.Itoa(maxLetterPageCount))
+			continue
+		}
+		if field == "page_count" {
+			meta.PageCount = n
+		} else {
+			meta.AttachmentCount = n
+		}
+	}
+
+	if v := get("file_name"); v == "" {
+		errs = append(errs, "Nama file wajib diisi")
+	} else if f, err := idx.find(v); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		data.FileName = f.Name
+		if other, used := idx.usedNames[f.Name]; used {
+			errs = append(errs, fmt.Sprintf("File %s sudah dipakai baris %d", f.Name, other))
+		} else {
+			idx.usedNames[f.Name] = rowNumber
+		}
+		if _, err := checkUploadMeta(f.Name, int64(f.UncompressedSize64)); err != nil {
+			errs = append(errs, path.Base(f.Name)+": "+err.Error())
+		}
+	}
+	return data, errs
+}
+
+// DocumentImportInput adalah spreadsheet dan arsip yang sudah ada di disk server.
+type DocumentImportInput struct {
+	SpreadsheetPath string
+	SpreadsheetName string
+	ArchivePath     string
+	ArchiveName     string
+	Mapping         map[string]string // field dokumen -> judul kolom, sisanya otomatis
+	LetterType      string            // dipakai jika kolom jenis surat kosong
+	Visibility      string
+}
+
+// DocumentImportReport adalah hasil dry run yang dikirim ke user.
+type DocumentImportReport struct {
+	Import          models.DocumentImport `json:"import"`
+	Mapping         map[string]string     `json:"mapping"`
+	UnmappedColumns []string              `json:"unmapped_columns"`
+	UnusedFiles     []string              `json:"unused_files"`
+	InvalidRows     []gin.H               `json:"invalid_rows"`
+}
+
+// PrepareDocumentImport membaca dan memvalidasi seluruh baris (dry run) lalu
+// menyimpan hasilnya. Tidak ada dokumen yang dibuat sampai impor dimulai.
+func PrepareDocumentImport(user models.User, in DocumentImportInput) (DocumentImportReport, error) {
+	var report DocumentImportReport
+
+	rows, err := spreadsheet.Read(in.SpreadsheetPath)
+	if err != nil {
+		return report, badImportInput("invalid_spreadsheet", err.Error())
+	}
+	if len(rows) < 2 {
+		return report, badImportInput("invalid_spreadsheet", "Spreadsheet harus berisi baris judul kolom dan minimal satu baris data")
+	}
+	headers := rows[0]
+	mapping, err := resolveImportMapping(headers, in.Mapping)
+	if err != nil {
+		return report, badImportInput("invalid_mapping", "Pemetaan kolom tidak valid: "+err.Error())
+	}
+
+	zr, err := zip.OpenReader(in.ArchivePath)
+	if err != nil {
+		return report, badImportInput("invalid_archive", "Arsip ZIP tidak valid: "+err.Error())
+	}
+	defer zr.Close()
+	idx := newArchiveIndex(zr.File)
+
+	mappingJSON, _ := json.Marshal(in.Mapping)
+	imp := models.DocumentImport{
+		UserID:          user.ID,
+		Status:          "validated",
+		SpreadsheetName: in.SpreadsheetName,
+		ArchiveName:     in.ArchiveName,
+		SpreadsheetPath: in.SpreadsheetPath,
+		ArchivePath:     in.ArchivePath,
+		Mapping:         string(mappingJSON),
+		DefaultType:     in.LetterType,
+		Visibility:      in.Visibility,
+	}
+
+	importRows := make([]models.DocumentImportRow, 0, len(rows)-1)
+	report.InvalidRows = []gin.H{}
+	for i, values := range rows[1:] {
+		if spreadsheetRowBlank(values) {
+			continue
+		}
+		rowNumber := i + 2 // nomor baris seperti di Excel, baris 1 adalah judul
+		data, errs := validateImportRow(rowNumber, values, mapping, in.LetterType, idx)
+		dataJSON, _ := json.Marshal(data)
+		row := models.DocumentImportRow{
+			RowNumber: rowNumber,
+			Status:    "valid",
+			Subject:   truncateRunes(data.Subject, 255),
+			FileName:  truncateRunes(data.FileName, 500),
+			Data:      string(dataJSON),
+		}
+		if len(errs) > 0 {
+			row.Status = "invalid"
+			errsJSON, _ := json.Marshal(errs)
+			row.Errors = string(errsJSON)
+			imp.InvalidRows++
+			if len(report.InvalidRows) < importReportLimit {
+				report.InvalidRows = append(report.InvalidRows, gin.H{"row_number": rowNumber, "subject": data.Subject, "errors": errs})
+			}
+		} else {
+			imp.ValidRows++
+		}
+		importRows = append(importRows, row)
+	}
+	imp.TotalRows = len(importRows)
+	if imp.TotalRows == 0 {
+		return report, badImportInput("invalid_spreadsheet", "Spreadsheet tidak memiliki baris data")
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&imp).Error; err != nil {
+			return err
+		}
+		for i := range importRows {
+			importRows[i].ImportID = imp.ID
+		}
+		return tx.CreateInBatches(&importRows, 500).Error
+	})
+	if err != nil {
+		return report, err
+	}
+
+	report.Import = imp
+	report.Mapping = map[string]string{}
+	used := map[int]bool{}
+	for key, i := range mapping {
+		report.Mapping[key] = headers[i]
+		used[i] = true
+	}
+	report.UnmappedColumns = []string{}
+	for i, h := range headers {
+		if !used[i] && h != "" {
+			report.UnmappedColumns = append(report.UnmappedColumns, h)
+		}
+	}
+	report.UnusedFiles = []string{}
+	for _, files := range idx.byName {
+		for _, f := range files {
+			if _, ok := idx.usedNames[f.Name]; !ok {
+				report.UnusedFiles = append(report.UnusedFiles, f.Name)
+			}
+		}
+	}
+	sort.Strings(report.UnusedFiles)
+	return report, nil
+}
+
+func badImportInput(code, message string) error {
+	return &requestError{Status: http.StatusUnprocessableEntity, Code: code, Message: message}
+}
+
+func spreadsheetRowBlank(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func truncateRunes(v string, max int) string {
+	if r := []rune(v); len(r) > max {
+		return string(r[:max])
+	}
+	return v
+}
+
+// MarkDocumentImportRunning menandai impor siap diproses worker.
+// retryFailed mengembalikan baris yang gagal ke antrean.
+func MarkDocumentImportRunning(imp *models.DocumentImport, retryFailed bool) error {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if retryFailed {
+			if err := tx.Model(&models.DocumentImportRow{}).
+				Where("import_id = ? AND status = ?", imp.ID, "failed").
+				Updates(map[string]interface{}{"status": "valid", "errors": ""}).Error; err != nil {
+				return err
+			}
+		}
+		updates := map[string]interface{}{"status": "running", "error": "", "finished_at": nil, "heartbeat_at": time.Now()}
+		if imp.StartedAt == nil {
+			updates["started_at"] = time.Now()
+		}
+		return tx.Model(imp).Updates(updates).Error
+	})
+	if err != nil {
+		return err
+	}
+	imp.Status = "running"
+	return nil
+}
+
+// startImportWorker menjalankan RunDocumentImport di background, sekali per impor.
+func startImportWorker(id string) {
+	if _, running := importsInFlight.LoadOrStore(id, true); running {
+		return
+	}
+	go func() {
+		defer importsInFlight.Delete(id)
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Warning: Recovered from panic saat impor %s: %v\n", id, r)
+			}
+		}()
+		if err := RunDocumentImport(id); err != nil {
+			fmt.Printf("Warning: impor %s gagal: %v\n", id, err)
+		}
+	}()
+}
+
+// ResumeDocumentImports melanjutkan impor berstatus running yang heartbeat-nya
+// sudah lama tidak diperbarui, mis. setelah server restart. Impor diklaim
+// dengan memperbarui heartbeat agar tidak diproses dua worker (server dan CLI).
+func ResumeDocumentImports() {
+	stale := time.Now().Add(-staleImportAfter)
+	var ids []string
+	config.DB.Model(&models.DocumentImport{}).
+		Where("status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)", "running", stale).
+		Pluck("id", &ids)
+	for _, id := range ids {
+		res := config.DB.Model(&models.DocumentImport{}).
+			Where("id = ? AND status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)", id, "running", stale).
+			Update("heartbeat_at", time.Now())
+		if res.Error == nil && res.RowsAffected == 1 {
+			startImportWorker(id)
+		}
+	}
+}
+
+// RunDocumentImport memproses baris valid satu per satu sampai habis atau
+// impor dihentikan (status bukan running lagi). Setiap baris disimpan dalam
+// transaksinya sendiri bersama status barisnya, sehingga impor yang terputus
+// bisa dilanjutkan tanpa menggandakan dokumen.
+func RunDocumentImport(id string) error {
+	var imp models.DocumentImport
+	if err := config.DB.First(&imp, "id = ?", id).Error; err != nil {
+		return err
+	}
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", imp.UserID).Error; err != nil {
+		return failImport(&imp, "User pengimpor tidak ditemukan")
+	}
+
+	zr, err := zip.OpenReader(imp.ArchivePath)
+	if err != nil {
+		return failImport(&imp, "Arsip ZIP tidak bisa dibuka: "+err.Error())
+	}
+	defer zr.Close()
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	for {
+		var status string
+		config.DB.Model(&models.DocumentImport{}).Where("id = ?", imp.ID).Pluck("status", &status)
+		if status != "running" {
+			return nil
+		}
+
+		var rows []models.DocumentImportRow
+		if err := config.DB.Where("import_id = ? AND status = ?", imp.ID, "valid").
+			Order("row_number ASC").Limit(importBatchSize).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			config.DB.Model(&models.DocumentImport{}).Where("id = ?", imp.ID).Update("heartbeat_at", time.Now())
+			if err := importRow(imp, user, files, row); err != nil {
+				errsJSON, _ := json.Marshal([]string{err.Error()})
+				config.DB.Model(&row).Updates(map[string]interface{}{"status": "failed", "errors": string(errsJSON)})
+			}
+		}
+		refreshImportCounts(&imp)
+	}
+
+	refreshImportCounts(&imp)
+	now := time.Now()
+	config.DB.Model(&imp).Where("status = ?", "running").
+		Updates(map[string]interface{}{"status": "completed", "finished_at": now})
+
+	msg := fmt.Sprintf("Impor register %s selesai: %d berhasil, %d gagal", imp.SpreadsheetName, imp.ImportedRows, imp.FailedRows)
+	CreateActivityLog(user.ID, user.Name, "IMPORT_DOCUMENTS", msg)
+	go func() {
+		_ = CreateNotification(user.ID, msg, "/dashboard/imports/"+imp.ID)
+	}()
+	return nil
+}
+
+// importRow menyimpan file dari arsip lalu membuat dokumennya.
+func importRow(imp models.DocumentImport, user models.User, files map[string]*zip.File, row models.DocumentImportRow) error {
+	var data importRowData
+	if err := json.Unmarshal([]byte(row.Data), &data); err != nil {
+		return fmt.Errorf("data baris rusak: %v", err)
+	}
+	zf, ok := files[data.FileName]
+	if !ok {
+		return fmt.Errorf("file %s tidak ditemukan di arsip", data.FileName)
+	}
+
+	incoming := &incomingFile{
+		Name:     path.Base(zf.Name),
+		Size:     int64(zf.UncompressedSize64),
+		Uploader: user,
+		open:     func() (io.ReadCloser, error) { return zf.Open() },
+	}
+	stored, err := storeFile(context.Background(), incoming)
+	if err != nil {
+		return err
+	}
+
+	// Dokumen hasil impor tidak mengirim notifikasi disposisi/broadcast;
+	// ekstraksi teks dan preview diambil job terjadwal dari status pending.
+	userID := user.ID
+	document := models.Document{
+		Sender:         data.Sender,
+		FileName:       incoming.Name,
+		FileURL:        stored.URL,
+		Subject:        data.Subject,
+		LetterType:     data.LetterType,
+		UserID:         &userID,
+		StorageKey:     stored.Key,
+		StorageBackend: stored.Backend,
+		Origin:         "admin",
+		Visibility:     imp.Visibility,
+		RegisterNumber: data.RegisterNumber,
+		LetterMetadata: data.Metadata,
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&document).Error; err != nil {
+			return err
+		}
+		if _, err := recordDocumentVersion(tx, document.ID, incoming.Name, stored, user.ID, "Impor register lama"); err != nil {
+			return err
+		}
+		return tx.Model(&row).Updates(map[string]interface{}{"status": "imported", "document_id": document.ID, "errors": ""}).Error
+	})
+	if err != nil {
+		deleteStoredFile(stored.Backend, stored.Key)
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			return reqErr
+		}
+		return fmt.Errorf("gagal menyimpan dokumen: %v", err)
+	}
+	return nil
+}
+
+// refreshImportCounts menghitung ulang jumlah baris per status.
+func refreshImportCounts(imp *models.DocumentImport) {
+	var counts []struct {
+		Status string
+		Total  int
+	}
+	config.DB.Model(&models.DocumentImportRow{}).Select("status, COUNT(*) AS total").
+		Where("import_id = ?", imp.ID).Group("status").Scan(&counts)
+
+	imp.ValidRows, imp.InvalidRows, imp.ImportedRows, imp.FailedRows = 0, 0, 0, 0
+	for _, c := range counts {
+		switch c.Status {
+		case "valid":
+			imp.ValidRows = c.Total
+		case "invalid":
+			imp.InvalidRows = c.Total
+		case "imported":
+			imp.ImportedRows = c.Total
+		case "failed":
+			imp.FailedRows = c.Total
+		}
+	}
+	config.DB.Model(imp).Updates(map[string]interface{}{
+		"valid_rows":    imp.ValidRows,
+		"invalid_rows":  imp.InvalidRows,
+		"imported_rows": imp.ImportedRows,
+		"failed_rows":   imp.FailedRows,
+	})
+}
+
+func failImport(imp *models.DocumentImport, message string) error {
+	config.DB.Model(imp).Updates(map[string]interface{}{"status": "failed", "error": message, "finished_at": time.Now()})
+	return errors.New(message)
+}
+
+// removeImportFiles menghapus spreadsheet dan arsip yang diunggah lewat API.
+// File milik CLI (di luar folder staging) tidak disentuh.
+func removeImportFiles(imp models.DocumentImport) {
+	staging, err := filepath.Abs(importStagingDir())
+	if err != nil {
+		return
+	}
+	for _, p := range []string{imp.SpreadsheetPath, imp.ArchivePath} {
+		abs, err := filepath.Abs(p)
+		if err != nil || !strings.HasPrefix(abs, staging+string(filepath.Separator)) {
+			continue
+		}
+		dir := filepath.Dir(abs)
+		if dir != staging {
+			os.RemoveAll(dir)
+		} else {
+			os.Remove(abs)
+		}
+	}
+}
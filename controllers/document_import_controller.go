@@ -0,0 +1,375 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportArchiveSize membaca IMPORT_MAX_SIZE_MB (default 2048 MB).
+func maxImportArchiveSize() int64 {
+	if mb, err := strconv.ParseInt(os.Getenv("IMPORT_MAX_SIZE_MB"), 10, 64); err == nil && mb > 0 {
+		return mb << 20
+	}
+	return 2048 << 20
+}
+
+func findDocumentImport(c *gin.Context) (models.DocumentImport, bool) {
+	var imp models.DocumentImport
+	if err := config.DB.First(&imp, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Impor tidak ditemukan"})
+		return imp, false
+	}
+	return imp, true
+}
+
+// importRowResponse mengubah kolom JSON baris impor menjadi objek di response.
+func importRowResponse(row models.DocumentImportRow) gin.H {
+	errs := []string{}
+	if row.Errors != "" {
+		_ = json.Unmarshal([]byte(row.Errors), &errs)
+	}
+	var data importRowData
+	_ = json.Unmarshal([]byte(row.Data), &data)
+	return gin.H{
+		"id":          row.ID,
+		"row_number":  row.RowNumber,
+		"status":      row.Status,
+		"subject":     row.Subject,
+		"file_name":   row.FileName,
+		"document_id": row.DocumentID,
+		"errors":      errs,
+		"data":        data,
+		"updated_at":  row.UpdatedAt,
+	}
+}
+
+// ======================================================
+// CREATE IMPORT (DRY RUN)
+// ======================================================
+// multipart/form-data: spreadsheet (.csv/.xlsx), archive (.zip), mapping
+// (JSON field -> judul kolom, opsional), letter_type (default jenis surat),
+// visibility. Semua baris divalidasi dan hasilnya disimpan; dokumen baru
+// dibuat setelah POST /imports/:id/start.
+func CreateDocumentImport(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	sheetHeader, err := c.FormFile("spreadsheet")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File spreadsheet (.csv atau .xlsx) wajib diupload"})
+		return
+	}
+	sheetExt := strings.ToLower(filepath.Ext(sheetHeader.Filename))
+	if sheetExt != ".csv" && sheetExt != ".xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Spreadsheet harus berformat .csv atau .xlsx"})
+		return
+	}
+	archiveHeader, err := c.FormFile("archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Arsip ZIP berisi file scan wajib diupload"})
+		return
+	}
+	if strings.ToLower(filepath.Ext(archiveHeader.Filename)) != ".zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Arsip harus berformat .zip"})
+		return
+	}
+	if archiveHeader.Size > maxImportArchiveSize() {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Ukuran arsip melebihi batas maksimal, gunakan perintah CLI untuk arsip besar"})
+		return
+	}
+
+	var mapping map[string]string
+	if v := strings.TrimSpace(c.PostForm("mapping")); v != "" {
+		if err := json.Unmarshal([]byte(v), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping harus berupa JSON {\"field\": \"judul kolom\"}"})
+			return
+		}
+	}
+	letterType := strings.ToLower(strings.TrimSpace(c.PostForm("letter_type")))
+	if letterType != "" && letterType != "masuk" && letterType != "keluar" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "letter_type harus masuk atau keluar"})
+		return
+	}
+	visibility, err := parseDocumentVisibility(c, "public")
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	if err := os.MkdirAll(importStagingDir(), 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyiapkan folder impor"})
+		return
+	}
+	dir, err := os.MkdirTemp(importStagingDir(), "import-*")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyiapkan folder impor"})
+		return
+	}
+	sheetPath := filepath.Join(dir, "spreadsheet"+sheetExt)
+	archivePath := filepath.Join(dir, "archive.zip")
+	if err := c.SaveUploadedFile(sheetHeader, sheetPath); err != nil {
+		os.RemoveAll(dir)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan spreadsheet: " + err.Error()})
+		return
+	}
+	if err := c.SaveUploadedFile(archiveHeader, archivePath); err != nil {
+		os.RemoveAll(dir)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan arsip: " + err.Error()})
+		return
+	}
+
+	report, err := PrepareDocumentImport(user, DocumentImportInput{
+		SpreadsheetPath: sheetPath,
+		SpreadsheetName: filepath.Base(sheetHeader.Filename),
+		ArchivePath:     archivePath,
+		ArchiveName:     filepath.Base(archiveHeader.Filename),
+		Mapping:         mapping,
+		LetterType:      letterType,
+		Visibility:      visibility,
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			respondFileError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memvalidasi impor: " + err.Error()})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "VALIDATE_IMPORT", "Memvalidasi impor register: "+report.Import.SpreadsheetName)
+	c.JSON(http.StatusCreated, gin.H{"message": "Validasi impor selesai", "report": report})
+}
+
+// ======================================================
+// GET IMPORTS
+// ======================================================
+func GetDocumentImports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 20
+	}
+
+	query := config.DB.Model(&models.DocumentImport{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var imports []models.DocumentImport
+	if err := query.Order("created_at DESC").Limit(perPage).Offset((page - 1) * perPage).Find(&imports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil daftar impor: " + err.Error()})
+		return
+	}
+
+	lastPage := int((total + int64(perPage) - 1) / int64(perPage))
+	c.JSON(http.StatusOK, gin.H{
+		"data":         imports,
+		"total":        total,
+		"current_page": page,
+		"last_page":    lastPage,
+		"per_page":     perPage,
+	})
+}
+
+// ======================================================
+// GET IMPORT
+// ======================================================
+func GetDocumentImport(c *gin.Context) {
+	imp, ok := findDocumentImport(c)
+	if !ok {
+		return
+	}
+	var mapping map[string]string
+	_ = json.Unmarshal([]byte(imp.Mapping), &mapping)
+	_, active := importsInFlight.Load(imp.ID)
+	c.JSON(http.StatusOK, gin.H{"import": imp, "mapping": mapping, "worker_active": active})
+}
+
+// ======================================================
+// GET IMPORT ROWS
+// ======================================================
+// Hasil per baris. Query: status (valid, invalid, imported, failed), page, per_page.
+func GetDocumentImportRows(c *gin.Context) {
+	imp, ok := findDocumentImport(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 50
+	}
+
+	query := config.DB.Model(&models.DocumentImportRow{}).Where("import_id = ?", imp.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var rows []models.DocumentImportRow
+	if err := query.Order("row_number ASC").Limit(perPage).Offset((page - 1) * perPage).Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil baris impor: " + err.Error()})
+		return
+	}
+	data := make([]gin.H, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, importRowResponse(row))
+	}
+
+	lastPage := int((total + int64(perPage) - 1) / int64(perPage))
+	c.JSON(http.StatusOK, gin.H{
+		"data":         data,
+		"total":        total,
+		"current_page": page,
+		"last_page":    lastPage,
+		"per_page":     perPage,
+	})
+}
+
+// ======================================================
+// START / RESUME IMPORT
+// ======================================================
+// Body (opsional): skip_invalid (wajib true jika ada baris tidak valid),
+// retry_failed (ulangi baris yang gagal). Dipakai juga untuk melanjutkan
+// impor yang dihentikan sementara atau gagal.
+func StartDocumentImport(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		SkipInvalid bool `json:"skip_invalid"`
+		RetryFailed bool `json:"retry_failed"`
+	}
+	_ = c.ShouldBindJSON(&input)
+
+	imp, ok := findDocumentImport(c)
+	if !ok {
+		return
+	}
+	switch imp.Status {
+	case "running":
+		c.JSON(http.StatusConflict, gin.H{"error": "Impor sedang berjalan", "code": "import_running"})
+		return
+	case "completed":
+		if !input.RetryFailed || imp.FailedRows == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Impor sudah selesai", "code": "import_completed"})
+			return
+		}
+	}
+	if imp.InvalidRows > 0 && !input.SkipInvalid {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":        "Masih ada baris yang tidak valid. Perbaiki spreadsheet atau kirim skip_invalid: true untuk melewatinya",
+			"code":         "invalid_rows",
+			"invalid_rows": imp.InvalidRows,
+		})
+		return
+	}
+	if _, err := os.Stat(imp.ArchivePath); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Arsip impor sudah tidak tersedia di server", "code": "archive_missing"})
+		return
+	}
+
+	if err := MarkDocumentImportRunning(&imp, input.RetryFailed); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulai impor: " + err.Error()})
+		return
+	}
+	startImportWorker(imp.ID)
+
+	CreateActivityLog(user.ID, user.Name, "START_IMPORT", "Memulai impor register: "+imp.SpreadsheetName)
+	c.JSON(http.StatusAccepted, gin.H{"message": "Impor dijalankan di background", "import": imp})
+}
+
+// ======================================================
+// PAUSE IMPORT
+// ======================================================
+// Worker berhenti setelah baris yang sedang diproses selesai.
+func PauseDocumentImport(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	imp, ok := findDocumentImport(c)
+	if !ok {
+		return
+	}
+	res := config.DB.Model(&models.DocumentImport{}).
+		Where("id = ? AND status = ?", imp.ID, "running").
+		Update("status", "paused")
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghentikan impor: " + res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Impor tidak sedang berjalan"})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "PAUSE_IMPORT", "Menghentikan sementara impor register: "+imp.SpreadsheetName)
+	c.JSON(http.StatusOK, gin.H{"message": "Impor dihentikan sementara"})
+}
+
+// ======================================================
+// DELETE IMPORT
+// ======================================================
+// Menghapus catatan impor dan file yang diupload. Dokumen yang sudah
+// berhasil diimpor tetap ada.
+func DeleteDocumentImport(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	imp, ok := findDocumentImport(c)
+	if !ok {
+		return
+	}
+	if _, running := importsInFlight.Load(imp.ID); running || imp.Status == "running" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Hentikan impor terlebih dahulu", "code": "import_running"})
+		return
+	}
+	if err := config.DB.Delete(&imp).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus impor: " + err.Error()})
+		return
+	}
+	removeImportFiles(imp)
+
+	CreateActivityLog(user.ID, user.Name, "DELETE_IMPORT", "Menghapus catatan impor register: "+imp.SpreadsheetName)
+	c.JSON(http.StatusOK, gin.H{"message": "Catatan impor dihapus"})
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+)
+
+func testArchiveIndex(t *testing.T, names ...string) *archiveIndex {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range names {
+		if _, err := w.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newArchiveIndex(r.File)
+}
+
+func TestArchiveIndexFind(t *testing.T) {
+	idx := testArchiveIndex(t,
+		"scan/2019/Surat-001.PDF",
+		"scan/2019/undangan.pdf",
+		"scan/2019/undangan.jpg",
+		"scan/2019/nota.pdf",
+		"scan/2020/nota.pdf",
+		"scan/2020/",
+		"__MACOSX/scan/2019/._Surat-001.PDF",
+		"scan/.DS_Store",
+	)
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"Surat-001.PDF", "scan/2019/Surat-001.PDF", false},
+		{"surat-001.pdf", "scan/2019/Surat-001.PDF", false},              // huruf besar kecil diabaikan
+		{"  C:\\scan\\surat-001.pdf ", "scan/2019/Surat-001.PDF", false}, // path Windows
+		{"surat-001", "scan/2019/Surat-001.PDF", false},                  // tanpa ekstensi
+		{"undangan.jpg", "scan/2019/undangan.jpg", false},
+		{"undangan", "", true}, // dua file dengan nama yang sama tanpa ekstensi
+		{"nota.pdf", "", true}, // nama kembar di folder berbeda
+		{"scan/2020/nota.pdf", "scan/2020/nota.pdf", false},
+		{"/scan/2019/NOTA.pdf", "scan/2019/nota.pdf", false},
+		{"tidak-ada.pdf", "", true},
+		{".DS_Store", "", true},
+	}
+	for _, tt := range tests {
+		f, err := idx.find(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("find(%q) = %s, want error", tt.value, f.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("find(%q) error: %v", tt.value, err)
+			continue
+		}
+		if f.Name != tt.want {
+			t.Errorf("find(%q) = %s, want %s", tt.value, f.Name, tt.want)
+		}
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"2023-07-16", "2023-07-16", false},
+		{"16/07/2023", "2023-07-16", false},
+		{"6/7/2023", "2023-07-06", false},
+		{"16-07-2023", "2023-07-16", false},
+		{"16.07.2023", "2023-07-16", false},
+		{"2023-07-16 13:45:00", "2023-07-16", false},
+		{"45123", "2023-07-16", false}, // nomor seri tanggal Excel
+		{"45123.5", "2023-07-16", false},
+		{"0", "", true},
+		{"16 Juli 2023", "", true},
+		{"31/02/2023", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseImportDate(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseImportDate(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		gotStr := ""
+		if got != nil {
+			gotStr = got.Format("2006-01-02")
+			if got.Hour() != 0 || got.Minute() != 0 || got.Location() != time.Local {
+				t.Errorf("parseImportDate(%q) = %v, want tengah malam waktu lokal", tt.in, got)
+			}
+		}
+		if gotStr != tt.want {
+			t.Errorf("parseImportDate(%q) = %q, want %q", tt.in, gotStr, tt.want)
+		}
+	}
+}
+
+func TestResolveImportMapping(t *testing.T) {
+	headers := []string{"No. Agenda", "Tgl. Surat", "Perihal", "Asal Surat", "File Scan", "Keterangan"}
+
+	mapping, err := resolveImportMapping(headers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"register_number": 0, "letter_date": 1, "subject": 2, "sender": 3, "file_name": 4}
+	if len(mapping) != len(want) {
+		t.Errorf("mapping = %v, want %v", mapping, want)
+	}
+	for key, i := range want {
+		if got, ok := mapping[key]; !ok || got != i {
+			t.Errorf("mapping[%s] = %d, want %d", key, got, i)
+		}
+	}
+
+	// Pemetaan manual menimpa alias, judul kosong berarti tidak dipetakan
+	mapping, err = resolveImportMapping(headers, map[string]string{"sender": "", "classification_code": "keterangan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mapping["sender"]; ok {
+		t.Error("sender seharusnya tidak dipetakan")
+	}
+	if mapping["classification_code"] != 5 {
+		t.Errorf("classification_code = %d, want 5", mapping["classification_code"])
+	}
+
+	errCases := []struct {
+		name    string
+		headers []string
+		custom  map[string]string
+	}{
+		{"field tidak dikenal", headers, map[string]string{"tanggal": "Tgl. Surat"}},
+		{"kolom tidak ada", headers, map[string]string{"subject": "Judul"}},
+		{"kolom wajib kosong", []string{"Perihal", "Pengirim"}, nil},
+	}
+	for _, tt := range errCases {
+		if _, err := resolveImportMapping(tt.headers, tt.custom); err == nil {
+			t.Errorf("%s: want error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/preview"
+	"dinsos_kuburaya/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// signedPreviewURL membuat link gambar preview untuk tag <img>. Masa berlaku
+// dibulatkan ke akhir jam berikutnya supaya URL sama selama satu jam dan
+// gambar bisa di-cache browser.
+func signedPreviewURL(id string, page int, userID string) string {
+	expires := time.Now().Truncate(time.Hour).Add(2 * time.Hour).Unix()
+	pageStr := strconv.Itoa(page)
+
+	q := url.Values{}
+	q.Set("uid", userID)
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("signature", storage.Sign("preview:"+id+":"+pageStr+":"+userID, expires))
+
+	return "/api/previews/" + id + "/" + pageStr + "?" + q.Encode()
+}
+
+// thumbnailURL mengembalikan link thumbnail, kosong jika preview belum siap.
+func thumbnailURL(id, status, userID string) string {
+	if status != "completed" {
+		return ""
+	}
+	return signedPreviewURL(id, 0, userID)
+}
+
+// queuePreviewGeneration membuat thumbnail dan preview halaman di background.
+func queuePreviewGeneration(id string) {
+	runFileJob("preview", id, func() { runPreviewGeneration(id) })
+}
+
+func runPreviewGeneration(id string) {
+	var doc models.Document
+	if err := config.DB.First(&doc, "id = ?", id).Error; err != nil {
+		return
+	}
+	current := documentDownloadable(doc)
+
+	setStatus := func(status string) {
+		config.DB.Model(&models.Document{}).
+			Where("id = ? AND storage_key = ?", id, current.Key).
+			UpdateColumn("preview_status", status)
+	}
+
+	if current.Key == "" {
+		setStatus("skipped")
+		return
+	}
+	config.DB.Model(&models.Document{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"preview_status": "processing", "preview_started_at": time.Now()})
+
+	thumb, pages, err := renderStoredPreviews(current)
+	if errors.Is(err, preview.ErrUnsupported) {
+		setStatus("skipped")
+		return
+	}
+	if err != nil {
+		fmt.Printf("Warning: pembuatan preview %s gagal: %v\n", id, err)
+		setStatus("failed")
+		return
+	}
+
+	// Gambar diupload dulu, record lama baru diganti setelah semua berhasil
+	ctx := context.Background()
+	backend := storage.Default()
+	var created []models.DocumentPreview
+	cleanupCreated := func() {
+		for _, p := range created {
+			deleteStoredFile(p.StorageBackend, p.StorageKey)
+		}
+	}
+	for _, page := range append([]preview.Page{thumb}, pages...) {
+		key := "dinsos_kuburaya/preview/" + uuid.NewString() + ".jpg"
+		obj, err := backend.Put(ctx, key, bytes.NewReader(page.Image), int64(len(page.Image)), "image/jpeg")
+		if err != nil {
+			fmt.Printf("Warning: gagal menyimpan preview %s: %v\n", id, err)
+			cleanupCreated()
+			setStatus("failed")
+			return
+		}
+		created = append(created, models.DocumentPreview{
+			DocumentID:     id,
+			Page:           page.Number,
+			Width:          page.Width,
+			Height:         page.Height,
+			StorageKey:     obj.Key,
+			StorageBackend: obj.Backend,
+		})
+	}
+
+	var old []models.DocumentPreview
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// File dokumen diganti selama render: hasil ini sudah tidak berlaku
+		var count int64
+		tx.Model(&models.Document{}).Where("id = ? AND storage_key = ?", id, current.Key).Count(&count)
+		if count == 0 {
+			return errPreviewOutdated
+		}
+
+		if err := tx.Where("document_id = ?", id).Find(&old).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("document_id = ?", id).Delete(&models.DocumentPreview{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&created).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Document{}).Where("id = ?", id).
+			UpdateColumns(map[string]interface{}{"preview_status": "completed", "preview_pages": len(pages)}).Error
+	})
+	if err != nil {
+		cleanupCreated()
+		if !errors.Is(err, errPreviewOutdated) {
+			fmt.Printf("Warning: gagal menyimpan preview %s: %v\n", id, err)
+			setStatus("failed")
+		}
+		return
+	}
+
+	for _, p := range old {
+		deleteStoredFile(p.StorageBackend, p.StorageKey)
+	}
+}
+
+var errPreviewOutdated = errors.New("file dokumen sudah diganti")
+
+func renderStoredPreviews(file downloadable) (preview.Page, []preview.Page, error) {
+	path, ext, cleanup, err := downloadToTemp(file)
+	if err != nil {
+		return preview.Page{}, nil, err
+	}
+	defer cleanup()
+
+	return preview.Generate(context.Background(), path, ext)
+}
+
+// deleteDocumentPreviews menghapus semua gambar preview dokumen beserta recordnya.
+func deleteDocumentPreviews(documentID string) {
+	var previews []models.DocumentPreview
+	config.DB.Where("document_id = ?", documentID).Find(&previews)
+	for _, p := range previews {
+		deleteStoredFile(p.StorageBackend, p.StorageKey)
+	}
+	config.DB.Where("document_id = ?", documentID).Delete(&models.DocumentPreview{})
+}
+
+// ProcessPendingPreviews dijalankan scheduler untuk dokumen yang belum punya
+// preview, termasuk dokumen lama dan proses yang terhenti karena restart.
+func ProcessPendingPreviews() {
+	stale := time.Now().Add(-staleFileJobAfter)
+	var ids []string
+	config.DB.Model(&models.Document{}).
+		Where("preview_status = ? OR (preview_status = ? AND (preview_started_at IS NULL OR preview_started_at < ?))",
+			"pending", "processing", stale).
+		Order("created_at DESC").
+		Limit(fileJobBatchSize).
+		Pluck("id", &ids)
+
+	for _, id := range ids {
+		queuePreviewGeneration(id)
+	}
+}
+
+// ======================================================
+// GET DOCUMENT PREVIEWS
+// ======================================================
+func GetDocumentPreviews(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	status := doc.PreviewStatus
+	pages := []gin.H{}
+	if status == "completed" {
+		var previews []models.DocumentPreview
+		config.DB.Where("document_id = ? AND page > 0", doc.ID).
+			Order("page ASC").Find(&previews)
+		for _, p := range previews {
+			pages = append(pages, gin.H{
+				"page":   p.Page,
+				"width":  p.Width,
+				"height": p.Height,
+				"url":    signedPreviewURL(doc.ID, p.Page, user.ID),
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"document_id":   doc.ID,
+		"source":        documentSource(doc),
+		"status":        status,
+		"thumbnail_url": thumbnailURL(doc.ID, status, user.ID),
+		"pages":         pages,
+	})
+}
+
+// ======================================================
+// REGENERATE DOCUMENT PREVIEWS
+// ======================================================
+func RegenerateDocumentPreviews(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+
+	// Preview lama tetap ada sampai yang baru selesai dibuat
+	if err := config.DB.Model(&doc).
+		UpdateColumn("preview_status", "pending").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menjadwalkan preview: " + err.Error()})
+		return
+	}
+	queuePreviewGeneration(doc.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Pembuatan preview dijadwalkan ulang", "status": "pending"})
+}
+
+// ======================================================
+// SIGNED PREVIEW IMAGE (TANPA TOKEN)
+// ======================================================
+// Dipakai langsung di tag <img>, hak akses user pembuat link dicek ulang.
+func ServeSignedPreview(c *gin.Context) {
+	id := c.Param("id")
+	pageStr := c.Param("page")
+	userID := c.Query("uid")
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !storage.Verify("preview:"+id+":"+pageStr+":"+userID, expires, c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Link tidak valid atau sudah kedaluwarsa"})
+		return
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview tidak ditemukan"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "User tidak valid"})
+		return
+	}
+
+	doc, found := findDocument(id)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+	if doc.PreviewStatus != "completed" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview belum tersedia"})
+		return
+	}
+
+	var p models.DocumentPreview
+	if err := config.DB.First(&p, "document_id = ? AND page = ?", id, page).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview tidak ditemukan"})
+		return
+	}
+
+	backend, err := storage.Get(p.StorageBackend)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reader, _, err := backend.Open(c.Request.Context(), p.StorageKey)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview tidak ditemukan"})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "image/jpeg")
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, "preview.jpg", p.CreatedAt, reader)
+}
@@ -0,0 +1,426 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Jenis hubungan antar surat
+var documentRelationTypes = map[string]string{
+	"reply_to":     "membalas",
+	"follow_up_of": "menindaklanjuti",
+	"supersedes":   "menggantikan",
+}
+
+// Batas jumlah surat dalam satu rangkaian agar query thread tetap ringan
+const maxThreadDocuments = 200
+
+// Batas per_page daftar surat yang menunggu balasan
+const maxAwaitingRepliesPerPage = 100
+
+// Surat balasan dianggap sudah dikirim jika tidak memakai alur persetujuan
+// atau statusnya sent. Dipakai di subquery NOT EXISTS daftar menunggu balasan.
+const sentReplyExistsSQL = `EXISTS (SELECT 1 FROM document_relations r
+	JOIN documents d ON d.id = r.document_id AND d.deleted_at IS NULL
+	WHERE r.related_document_id = documents.id AND r.type = 'reply_to' AND d.workflow_status IN ('', 'sent'))`
+
+// relatedDocumentSummary adalah data ringkas surat di daftar hubungan dan thread.
+func relatedDocumentSummary(doc models.Document) gin.H {
+	return gin.H{
+		"id":                   doc.ID,
+		"subject":              doc.Subject,
+		"sender":               doc.Sender,
+		"letter_type":          doc.LetterType,
+		"origin":               doc.Origin,
+		"register_number":      doc.RegisterNumber,
+		"sender_letter_number": doc.SenderLetterNumber,
+		"letter_date":          doc.LetterDate,
+		"received_date":        doc.ReceivedDate,
+		"reply_required_by":    doc.ReplyRequiredBy,
+		"workflow_status":      doc.WorkflowStatus,
+		"created_at":           doc.CreatedAt,
+	}
+}
+
+// accessibleDocuments mengambil dokumen aktif berdasarkan id dan membuang
+// dokumen yang tidak boleh dilihat user.
+func accessibleDocuments(user models.User, ids []string) map[string]models.Document {
+	result := map[string]models.Document{}
+	if len(ids) == 0 {
+		return result
+	}
+	var docs []models.Document
+	config.DB.Omit("extracted_text").Where("id IN ?", ids).Find(&docs)
+	for _, d := range docs {
+		if canAccessDocument(user, d) {
+			result[d.ID] = d
+		}
+	}
+	return result
+}
+
+// letterSortDate: surat diurutkan dengan tanggal surat, atau tanggal upload jika kosong.
+func letterSortDate(doc models.Document) time.Time {
+	if doc.LetterDate != nil {
+		return *doc.LetterDate
+	}
+	return doc.CreatedAt
+}
+
+// ======================================================
+// GET DOCUMENT RELATIONS
+// ======================================================
+// outgoing: surat ini membalas/menindaklanjuti/menggantikan surat lain.
+// incoming: surat lain yang membalas/menindaklanjuti/menggantikan surat ini.
+func GetDocumentRelations(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	var relations []models.DocumentRelation
+	config.DB.Where("document_id = ? OR related_document_id = ?", doc.ID, doc.ID).
+		Order("created_at ASC").Find(&relations)
+
+	var ids []string
+	for _, r := range relations {
+		ids = append(ids, r.DocumentID, r.RelatedDocumentID)
+	}
+	docs := accessibleDocuments(user, ids)
+
+	outgoing, incoming := []gin.H{}, []gin.H{}
+	for _, r := range relations {
+		if r.DocumentID == doc.ID {
+			if other, ok := docs[r.RelatedDocumentID]; ok {
+				outgoing = append(outgoing, gin.H{"id": r.ID, "type": r.Type, "created_at": r.CreatedAt, "document": relatedDocumentSummary(other)})
+			}
+			continue
+		}
+		if other, ok := docs[r.DocumentID]; ok {
+			incoming = append(incoming, gin.H{"id": r.ID, "type": r.Type, "created_at": r.CreatedAt, "document": relatedDocumentSummary(other)})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"document_id": doc.ID, "outgoing": outgoing, "incoming": incoming})
+}
+
+// ======================================================
+// CREATE DOCUMENT RELATION
+// ======================================================
+// Body: related_document_id, type (reply_to, follow_up_of, supersedes).
+// reply_to hanya boleh dari surat keluar ke surat masuk.
+// Hubungan hanya data penghubung sehingga tetap bisa dibuat pada surat yang terkunci.
+func CreateDocumentRelation(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		RelatedDocumentID string `json:"related_document_id" binding:"required"`
+		Type              string `json:"type" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	label, ok := documentRelationTypes[input.Type]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type harus reply_to, follow_up_of atau supersedes"})
+		return
+	}
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+	if input.RelatedDocumentID == doc.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Surat tidak bisa dihubungkan dengan dirinya sendiri"})
+		return
+	}
+	related, found := findDocument(input.RelatedDocumentID)
+	if !found || !canAccessDocument(user, related) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Surat yang dihubungkan tidak ditemukan"})
+		return
+	}
+	// Balasan selalu surat keluar yang menjawab surat masuk
+	if input.Type == "reply_to" && (doc.LetterType != "keluar" || related.LetterType != "masuk") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reply_to hanya untuk surat keluar yang membalas surat masuk"})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.DocumentRelation{}).
+		Where("document_id = ? AND related_document_id = ? AND type = ?", doc.ID, related.ID, input.Type).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Hubungan surat sudah ada"})
+		return
+	}
+
+	relation := models.DocumentRelation{
+		DocumentID:        doc.ID,
+		RelatedDocumentID: related.ID,
+		Type:              input.Type,
+		CreatedByID:       &user.ID,
+	}
+	if err := config.DB.Create(&relation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan hubungan surat: " + err.Error()})
+		return
+	}
+
+	LogActivity(user.ID, user.Name, "LINK_DOCUMENT", "Menandai surat "+doc.Subject+" "+label+" surat "+related.Subject)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Hubungan surat disimpan",
+		"relation": relation,
+		"document": relatedDocumentSummary(related),
+	})
+}
+
+// ======================================================
+// DELETE DOCUMENT RELATION
+// ======================================================
+func DeleteDocumentRelation(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+
+	var relation models.DocumentRelation
+	if err := config.DB.First(&relation, "id = ? AND document_id = ?", c.Param("relation_id"), doc.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hubungan surat tidak ditemukan"})
+		return
+	}
+	if err := config.DB.Delete(&relation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus hubungan surat: " + err.Error()})
+		return
+	}
+
+	LogActivity(user.ID, user.Name, "UNLINK_DOCUMENT", "Menghapus hubungan surat: "+doc.Subject)
+	c.JSON(http.StatusOK, gin.H{"message": "Hubungan surat dihapus"})
+}
+
+// ======================================================
+// GET DOCUMENT THREAD
+// ======================================================
+// Seluruh rangkaian surat yang terhubung (ke dua arah) dengan surat ini,
+// diurutkan dari yang paling awal. Surat yang tidak boleh dilihat user
+// tidak ditampilkan beserta hubungannya.
+func GetDocumentThread(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	// Telusuri hubungan per tingkat sampai tidak ada surat baru
+	seen := map[string]bool{doc.ID: true}
+	relationSeen := map[string]bool{}
+	var relations []models.DocumentRelation
+	frontier := []string{doc.ID}
+	truncated := false
+	for len(frontier) > 0 {
+		var batch []models.DocumentRelation
+		config.DB.Where("document_id IN ? OR related_document_id IN ?", frontier, frontier).Find(&batch)
+		frontier = nil
+		for _, r := range batch {
+			if relationSeen[r.ID] {
+				continue
+			}
+			relationSeen[r.ID] = true
+			relations = append(relations, r)
+			for _, id := range []string{r.DocumentID, r.RelatedDocumentID} {
+				if seen[id] {
+					continue
+				}
+				if len(seen) >= maxThreadDocuments {
+					truncated = true
+					continue
+				}
+				seen[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	docs := accessibleDocuments(user, ids)
+
+	ordered := make([]models.Document, 0, len(docs))
+	for _, d := range docs {
+		ordered = append(ordered, d)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := letterSortDate(ordered[i]), letterSortDate(ordered[j])
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+	})
+	documents := make([]gin.H, 0, len(ordered))
+	for _, d := range ordered {
+		documents = append(documents, relatedDocumentSummary(d))
+	}
+
+	edges := []models.DocumentRelation{}
+	for _, r := range relations {
+		_, fromOK := docs[r.DocumentID]
+		_, toOK := docs[r.RelatedDocumentID]
+		if fromOK && toOK {
+			edges = append(edges, r)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"document_id": doc.ID,
+		"documents":   documents,
+		"relations":   edges,
+		"truncated":   truncated,
+	})
+}
+
+// ======================================================
+// GET LETTERS AWAITING REPLY
+// ======================================================
+// Surat masuk dengan reply_required_by yang belum punya balasan terkirim
+// (surat reply_to tanpa alur persetujuan atau berstatus sent). Query:
+// overdue=1 (sudah lewat batas), due_within (hari), page, per_page (maks. 100).
+func GetAwaitingReplies(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 50
+	}
+	perPage = min(perPage, maxAwaitingRepliesPerPage)
+
+	today := time.Now().Format("2006-01-02")
+	query := visibleDocuments(config.DB.Model(&models.Document{}), user).
+		Where("documents.letter_type = ? AND documents.reply_required_by IS NOT NULL", "masuk").
+		Where("NOT " + sentReplyExistsSQL)
+	if c.Query("overdue") == "1" {
+		query = query.Where("documents.reply_required_by < ?", today)
+	}
+	if days, err := strconv.Atoi(c.Query("due_within")); err == nil && days >= 0 {
+		query = query.Where("documents.reply_required_by <= ?", time.Now().AddDate(0, 0, days).Format("2006-01-02"))
+	}
+	if v := strings.TrimSpace(c.Query("uploader_id")); v != "" {
+		query = query.Where("documents.user_id = ?", v)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var docs []models.Document
+	if err := query.Omit("extracted_text").
+		Order("documents.reply_required_by ASC, documents.created_at ASC").
+		Limit(perPage).Offset((page - 1) * perPage).
+		Find(&docs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil surat yang menunggu balasan: " + err.Error()})
+		return
+	}
+
+	// Surat yang sudah punya draft balasan (belum terkirim)
+	ids := make([]string, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, d.ID)
+	}
+	inProgress := map[string]bool{}
+	if len(ids) > 0 {
+		var drafted []string
+		config.DB.Table("document_relations").
+			Joins("JOIN documents d ON d.id = document_relations.document_id AND d.deleted_at IS NULL").
+			Where("document_relations.type = ? AND document_relations.related_document_id IN ?", "reply_to", ids).
+			Distinct().Pluck("document_relations.related_document_id", &drafted)
+		for _, id := range drafted {
+			inProgress[id] = true
+		}
+	}
+
+	now := time.Now()
+	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	data := make([]gin.H, 0, len(docs))
+	for _, d := range docs {
+		item := relatedDocumentSummary(d)
+		due := time.Date(d.ReplyRequiredBy.Year(), d.ReplyRequiredBy.Month(), d.ReplyRequiredBy.Day(), 0, 0, 0, 0, time.Local)
+		daysLeft := int(due.Sub(startOfToday).Hours() / 24)
+		item["days_left"] = daysLeft
+		item["overdue"] = daysLeft < 0
+		item["reply_status"] = "awaiting"
+		if inProgress[d.ID] {
+			item["reply_status"] = "in_progress"
+		}
+		data = append(data, item)
+	}
+
+	lastPage := int((total + int64(perPage) - 1) / int64(perPage))
+	c.JSON(http.StatusOK, gin.H{
+		"data":         data,
+		"total":        total,
+		"current_page": page,
+		"last_page":    lastPage,
+		"per_page":     perPage,
+	})
+}
@@ -0,0 +1,451 @@
+package controllers
+
+import (
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Kolom yang masuk FULLTEXT index. Urutan kolom di MATCH() harus sama persis
+// dengan definisi index di model.
+const documentSearchColumns = "sender, subject, file_name, extracted_text"
+
+// InnoDB mengabaikan kata yang lebih pendek dari innodb_ft_min_token_size (default 3)
+const minFulltextTokenLen = 3
+
+// Panjang maksimum potongan teks yang dikembalikan sebagai highlight
+const snippetLength = 160
+
+// documentSearch berisi kata kunci dan filter pencarian dari query string.
+type documentSearch struct {
+	Query      string
+	Terms      []string // kata dan frasa untuk highlight
+	Boolean    string   // ekspresi MATCH ... AGAINST (IN BOOLEAN MODE)
+	short      []string // kata yang terlalu pendek untuk FULLTEXT, dicari dengan LIKE
+	excluded   string   // kata yang dikecualikan jika query hanya berisi pengecualian
+	LetterType string
+	Sender     string
+	UploaderID string
+	Origin     string // admin atau staff, kosong berarti semua
+	Visibility string
+	Workflow   string // status alur surat keluar
+	Retention  string // status retensi arsip
+	DateFrom   *time.Time
+	DateTo     *time.Time
+
+	// Filter tag (dokumen harus memiliki semua tag) dan folder
+	TagIDs    []string
+	FolderIDs []string // folder yang dipilih, ditambah sub foldernya jika diminta
+	Unfiled   bool     // hanya dokumen yang belum masuk folder
+
+	// Filter data agenda surat
+	SenderLetterNumber string
+	ClassificationCode string
+	Urgency            string
+	Confidentiality    string
+	LetterDateFrom     *time.Time
+	LetterDateTo       *time.Time
+	ReceivedFrom       *time.Time
+	ReceivedTo         *time.Time
+
+	// Urutan yang diminta user (sort + order), kosong berarti urutan bawaan
+	SortColumn string
+	SortDesc   bool
+}
+
+// Kolom yang boleh dipakai untuk sort. Sifat dan kerahasiaan diurutkan dengan
+// FIELD() agar urutannya sesuai tingkatan, bukan alfabetis.
+var documentSortColumns = map[string]string{
+	"created_at":           "created_at",
+	"updated_at":           "updated_at",
+	"subject":              "subject",
+	"sender":               "sender",
+	"register_number":      "register_number",
+	"sender_letter_number": "sender_letter_number",
+	"letter_date":          "letter_date",
+	"received_date":        "received_date",
+	"classification_code":  "classification_code",
+	"urgency":              "FIELD(urgency, 'biasa', 'segera', 'sangat_segera')",
+	"confidentiality":      "FIELD(confidentiality, 'biasa', 'terbatas', 'rahasia', 'sangat_rahasia')",
+	"page_count":           "page_count",
+	"attachment_count":     "attachment_count",
+	"retention_expires_at": "retention_expires_at",
+	"reply_required_by":    "reply_required_by",
+}
+
+// sqlCondition adalah potongan WHERE beserta argumennya.
+type sqlCondition struct {
+	SQL  string
+	Args []interface{}
+}
+
+var searchTokenPattern = regexp.MustCompile(`-?"[^"]*"|\S+`)
+
+// Karakter operator boolean MySQL dibuang dari kata yang diketik user
+var booleanOperatorPattern = regexp.MustCompile(`[+\-<>()~*"@]+`)
+
+// parseDocumentSearch membaca parameter search, letter_type, sender, uploader_id,
+// origin, visibility, workflow_status, retention_status, tag_ids, folder_id, date_from dan date_to (format YYYY-MM-DD), filter agenda surat serta sort/order.
+func parseDocumentSearch(c *gin.Context) (documentSearch, error) {
+	s := documentSearch{
+		Query:      strings.TrimSpace(c.Query("search")),
+		Sender:     strings.TrimSpace(c.Query("sender")),
+		UploaderID: strings.TrimSpace(c.Query("uploader_id")),
+	}
+	if lt := c.Query("letter_type"); lt != "" && lt != "all" {
+		s.LetterType = lt
+	}
+	if v := c.Query("origin"); v != "" && v != "all" {
+		if v != "admin" && v != "staff" {
+			return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_filter", Message: "origin harus admin atau staff"}
+		}
+		s.Origin = v
+	}
+	if v := c.Query("visibility"); v != "" && v != "all" {
+		if !documentVisibilities[v] {
+			return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_filter", Message: "visibility harus public atau private"}
+		}
+		s.Visibility = v
+	}
+	if v := c.Query("workflow_status"); v != "" && v != "all" {
+		if _, ok := letterWorkflowStatuses[v]; !ok {
+			return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_filter", Message: "workflow_status tidak dikenal: " + v}
+		}
+		s.Workflow = v
+	}
+	if v := c.Query("retention_status"); v != "" && v != "all" {
+		if !retentionStatuses[v] && v != "none" {
+			return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_filter", Message: "retention_status tidak dikenal: " + v}
+		}
+		s.Retention = v
+	}
+	s.TagIDs = splitIDs(c.Query("tag_ids"))
+	if v := strings.TrimSpace(c.Query("folder_id")); v == "none" {
+		s.Unfiled = true
+	} else if v != "" {
+		s.FolderIDs = []string{v}
+		// Default hanya isi folder itu sendiri, subfolders=1 untuk ikut sub folder
+		if c.Query("subfolders") == "1" {
+			s.FolderIDs = folderSubtree(v)
+		}
+	}
+
+	if v := c.Query("date_from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_date", Message: "date_from harus berformat YYYY-MM-DD"}
+		}
+		s.DateFrom = &t
+	}
+	if v := c.Query("date_to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_date", Message: "date_to harus berformat YYYY-MM-DD"}
+		}
+		// Sampai akhir hari tersebut
+		t = t.AddDate(0, 0, 1)
+		s.DateTo = &t
+	}
+
+	s.SenderLetterNumber = strings.TrimSpace(c.Query("sender_letter_number"))
+	s.ClassificationCode = strings.TrimSpace(c.Query("classification_code"))
+	if v := c.Query("urgency"); v != "" && v != "all" {
+		s.Urgency = normalizeLetterOption(v)
+		if !letterUrgencies[s.Urgency] {
+			return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_filter", Message: "urgency harus biasa, segera atau sangat_segera"}
+		}
+	}
+	if v := c.Query("confidentiality"); v != "" && v != "all" {
+		s.Confidentiality = normalizeLetterOption(v)
+		if !letterConfidentialities[s.Confidentiality] {
+			return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_filter", Message: "confidentiality harus biasa, terbatas, rahasia atau sangat_rahasia"}
+		}
+	}
+
+	dateRanges := []struct {
+		from, to string
+		fromDst  **time.Time
+		toDst    **time.Time
+	}{
+		{"letter_date_from", "letter_date_to", &s.LetterDateFrom, &s.LetterDateTo},
+		{"received_from", "received_to", &s.ReceivedFrom, &s.ReceivedTo},
+	}
+	for _, r := range dateRanges {
+		for _, param := range []string{r.from, r.to} {
+			v := c.Query(param)
+			if v == "" {
+				continue
+			}
+			t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+			if err != nil {
+				return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_date", Message: param + " harus berformat YYYY-MM-DD"}
+			}
+			// Kolom bertipe DATE sehingga batas akhir cukup dibandingkan dengan <=
+			if param == r.from {
+				*r.fromDst = &t
+			} else {
+				*r.toDst = &t
+			}
+		}
+	}
+
+	if v := c.Query("sort"); v != "" {
+		column, ok := documentSortColumns[v]
+		if !ok {
+			return s, &requestError{Status: http.StatusBadRequest, Code: "invalid_sort", Message: "Kolom sort tidak dikenal: " + v}
+		}
+		s.SortColumn = column
+		s.SortDesc = !strings.EqualFold(c.Query("order"), "asc")
+	}
+
+	s.parseQuery()
+	return s, nil
+}
+
+// orderBy mengembalikan ORDER BY sesuai parameter sort, kosong jika tidak diminta.
+// Kolom sudah divalidasi dengan whitelist documentSortColumns.
+func (s documentSearch) orderBy() string {
+	if s.SortColumn == "" {
+		return ""
+	}
+	if s.SortDesc {
+		return s.SortColumn + " DESC"
+	}
+	return s.SortColumn + " ASC"
+}
+
+// parseQuery mengubah input user menjadi ekspresi boolean MySQL:
+//   - kata biasa wajib ada dan cocok sebagai awalan (bantuan -> bantuannya)
+//   - "frasa dalam kutip" harus muncul berurutan
+//   - -kata untuk mengecualikan
+func (s *documentSearch) parseQuery() {
+	var parts, excluded []string
+	for _, token := range searchTokenPattern.FindAllString(s.Query, -1) {
+		exclude := strings.HasPrefix(token, "-")
+		token = strings.TrimPrefix(token, "-")
+
+		if strings.HasPrefix(token, `"`) {
+			phrase := strings.Join(strings.Fields(booleanOperatorPattern.ReplaceAllString(token, " ")), " ")
+			if phrase == "" {
+				continue
+			}
+			if exclude {
+				excluded = append(excluded, `"`+phrase+`"`)
+				continue
+			}
+			parts = append(parts, `+"`+phrase+`"`)
+			s.Terms = append(s.Terms, phrase)
+			continue
+		}
+
+		for _, word := range strings.Fields(booleanOperatorPattern.ReplaceAllString(token, " ")) {
+			if utf8.RuneCountInString(word) < minFulltextTokenLen {
+				if !exclude {
+					s.short = append(s.short, word)
+				}
+				continue
+			}
+			if exclude {
+				excluded = append(excluded, word)
+				continue
+			}
+			parts = append(parts, "+"+word+"*")
+			s.Terms = append(s.Terms, word)
+		}
+	}
+	// MATCH yang hanya berisi operator - selalu kosong, sehingga query yang
+	// hanya berisi pengecualian dicari dengan NOT MATCH (semua kecuali kata itu)
+	if len(parts) > 0 {
+		for _, e := range excluded {
+			parts = append(parts, "-"+e)
+		}
+		s.Boolean = strings.Join(parts, " ")
+	} else {
+		s.excluded = strings.Join(excluded, " ")
+	}
+
+	// Kata pendek hanya dipakai jika tidak ada kata lain yang bisa dicari lewat
+	// FULLTEXT (mis. singkatan "KB"), sama seperti MySQL mengabaikannya
+	if s.Boolean != "" {
+		s.short = nil
+	}
+	s.Terms = append(s.Terms, s.short...)
+}
+
+// conditions menyusun filter pencarian. columns adalah kolom FULLTEXT yang dicari.
+func (s documentSearch) conditions(columns string) []sqlCondition {
+	var conds []sqlCondition
+
+	if s.Boolean != "" {
+		conds = append(conds, sqlCondition{"MATCH(" + columns + ") AGAINST (? IN BOOLEAN MODE)", []interface{}{s.Boolean}})
+	}
+	if s.excluded != "" {
+		conds = append(conds, sqlCondition{"NOT MATCH(" + columns + ") AGAINST (? IN BOOLEAN MODE)", []interface{}{s.excluded}})
+	}
+	// Kata pendek tidak ada di index FULLTEXT sehingga dicari dengan LIKE
+	for _, word := range s.short {
+		pattern := "%" + word + "%"
+		var likes []string
+		var args []interface{}
+		for _, col := range strings.Split(columns, ",") {
+			likes = append(likes, strings.TrimSpace(col)+" LIKE ?")
+			args = append(args, pattern)
+		}
+		conds = append(conds, sqlCondition{"(" + strings.Join(likes, " OR ") + ")", args})
+	}
+
+	if s.LetterType != "" {
+		conds = append(conds, sqlCondition{"letter_type = ?", []interface{}{s.LetterType}})
+	}
+	if s.Sender != "" {
+		conds = append(conds, sqlCondition{"sender LIKE ?", []interface{}{"%" + s.Sender + "%"}})
+	}
+	if s.UploaderID != "" {
+		conds = append(conds, sqlCondition{"user_id = ?", []interface{}{s.UploaderID}})
+	}
+	if s.Origin != "" {
+		conds = append(conds, sqlCondition{"origin = ?", []interface{}{s.Origin}})
+	}
+	if s.Visibility != "" {
+		conds = append(conds, sqlCondition{"visibility = ?", []interface{}{s.Visibility}})
+	}
+	if s.Workflow != "" {
+		conds = append(conds, sqlCondition{"workflow_status = ?", []interface{}{s.Workflow}})
+	}
+	if len(s.TagIDs) > 0 {
+		conds = append(conds, sqlCondition{
+			"documents.id IN (SELECT document_id FROM document_tags WHERE tag_id IN ? GROUP BY document_id HAVING COUNT(*) = ?)",
+			[]interface{}{s.TagIDs, len(s.TagIDs)},
+		})
+	}
+	if s.Unfiled {
+		conds = append(conds, sqlCondition{"folder_id IS NULL", nil})
+	} else if len(s.FolderIDs) > 0 {
+		conds = append(conds, sqlCondition{"folder_id IN ?", []interface{}{s.FolderIDs}})
+	}
+	if s.Retention == "none" {
+		conds = append(conds, sqlCondition{"retention_status = ?", []interface{}{""}})
+	} else if s.Retention != "" {
+		conds = append(conds, sqlCondition{"retention_status = ?", []interface{}{s.Retention}})
+	}
+	if s.DateFrom != nil {
+		conds = append(conds, sqlCondition{"created_at >= ?", []interface{}{*s.DateFrom}})
+	}
+	if s.DateTo != nil {
+		conds = append(conds, sqlCondition{"created_at < ?", []interface{}{*s.DateTo}})
+	}
+
+	if s.SenderLetterNumber != "" {
+		conds = append(conds, sqlCondition{"sender_letter_number LIKE ?", []interface{}{"%" + s.SenderLetterNumber + "%"}})
+	}
+	// Kode induk ikut mencakup sub kodenya (400 -> 400.7.1)
+	if s.ClassificationCode != "" {
+		conds = append(conds, sqlCondition{"(classification_code = ? OR classification_code LIKE ?)", []interface{}{s.ClassificationCode, s.ClassificationCode + ".%"}})
+	}
+	if s.Urgency != "" {
+		conds = append(conds, sqlCondition{"urgency = ?", []interface{}{s.Urgency}})
+	}
+	if s.Confidentiality != "" {
+		conds = append(conds, sqlCondition{"confidentiality = ?", []interface{}{s.Confidentiality}})
+	}
+	if s.LetterDateFrom != nil {
+		conds = append(conds, sqlCondition{"letter_date >= ?", []interface{}{s.LetterDateFrom.Format("2006-01-02")}})
+	}
+	if s.LetterDateTo != nil {
+		conds = append(conds, sqlCondition{"letter_date <= ?", []interface{}{s.LetterDateTo.Format("2006-01-02")}})
+	}
+	if s.ReceivedFrom != nil {
+		conds = append(conds, sqlCondition{"received_date >= ?", []interface{}{s.ReceivedFrom.Format("2006-01-02")}})
+	}
+	if s.ReceivedTo != nil {
+		conds = append(conds, sqlCondition{"received_date <= ?", []interface{}{s.ReceivedTo.Format("2006-01-02")}})
+	}
+	return conds
+}
+
+// scoreExpr adalah ekspresi skor relevansi untuk kolom SELECT.
+func (s documentSearch) scoreExpr(columns string) (string, []interface{}) {
+	if s.Boolean == "" {
+		return "0", nil
+	}
+	return "MATCH(" + columns + ") AGAINST (? IN BOOLEAN MODE)", []interface{}{s.Boolean}
+}
+
+// highlights mengembalikan potongan teks dengan kata yang cocok dibungkus <mark>.
+// Hanya field yang mengandung kata kunci yang dimasukkan.
+func (s documentSearch) highlights(fields map[string]string) map[string]string {
+	if len(s.Terms) == 0 {
+		return nil
+	}
+	result := map[string]string{}
+	for name, text := range fields {
+		if snippet, ok := highlightSnippet(text, s.Terms); ok {
+			result[name] = snippet
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// highlightSnippet memotong teks di sekitar kecocokan pertama lalu menandai
+// semua kecocokan. Teks di-escape sehingga aman ditampilkan sebagai HTML.
+func highlightSnippet(text string, terms []string) (string, bool) {
+	var quoted []string
+	for _, t := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(t))
+	}
+	pattern, err := regexp.Compile(`(?i)` + strings.Join(quoted, "|"))
+	if err != nil {
+		return "", false
+	}
+
+	loc := pattern.FindStringIndex(text)
+	if loc == nil {
+		return "", false
+	}
+
+	// Jendela teks di sekitar kecocokan pertama, dipotong di batas rune
+	start, end := 0, len(text)
+	if len(text) > snippetLength {
+		start = loc[0] - snippetLength/3
+		if start < 0 {
+			start = 0
+		}
+		end = start + snippetLength
+		if end > len(text) {
+			end = len(text)
+		}
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+	}
+	window := text[start:end]
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	last := 0
+	for _, m := range pattern.FindAllStringIndex(window, -1) {
+		b.WriteString(html.EscapeString(window[last:m[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(window[m[0]:m[1]]))
+		b.WriteString("</mark>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(window[last:]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
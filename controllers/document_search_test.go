@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		wantBoolean  string
+		wantExcluded string
+		wantShort    []string
+		wantTerms    []string
+	}{
+		{"kosong", "", "", "", nil, nil},
+		{"kata biasa", "bantuan sosial", "+bantuan* +sosial*", "", nil, []string{"bantuan", "sosial"}},
+		{"frasa", `"bantuan sosial" kuburaya`, `+"bantuan sosial" +kuburaya*`, "", nil, []string{"bantuan sosial", "kuburaya"}},
+		{"spasi dalam frasa dirapikan", `"  bantuan   sosial "`, `+"bantuan sosial"`, "", nil, []string{"bantuan sosial"}},
+		{"frasa kosong diabaikan", `"" bantuan`, "+bantuan*", "", nil, []string{"bantuan"}},
+		{"kutip tanpa penutup", `"bantuan`, `+"bantuan"`, "", nil, []string{"bantuan"}},
+		{"operator boolean dibuang", "bantu(an) +sosial~", "+bantu* +sosial*", "", nil, []string{"bantu", "sosial"}},
+		{"kata dengan pengecualian", "bantuan -pangan", "+bantuan* -pangan", "", nil, []string{"bantuan"}},
+		{"frasa dikecualikan", `bantuan -"beras miskin"`, `+bantuan* -"beras miskin"`, "", nil, []string{"bantuan"}},
+		{"hanya pengecualian", "-pangan", "", "pangan", nil, nil},
+		{"hanya pengecualian frasa dan kata", `-"beras miskin" -pangan`, "", `"beras miskin" pangan`, nil, nil},
+		{"pengecualian kata pendek diabaikan", "-kb", "", "", nil, nil},
+		{"kata pendek", "KB", "", "", []string{"KB"}, []string{"KB"}},
+		{"kata pendek dengan kata lain", "KB bantuan", "+bantuan*", "", nil, []string{"bantuan"}},
+		{"kata pendek dengan pengecualian", "KB -pangan", "", "pangan", []string{"KB"}, []string{"KB"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := documentSearch{Query: tt.query}
+			s.parseQuery()
+			if s.Boolean != tt.wantBoolean {
+				t.Errorf("Boolean = %q, want %q", s.Boolean, tt.wantBoolean)
+			}
+			if s.excluded != tt.wantExcluded {
+				t.Errorf("excluded = %q, want %q", s.excluded, tt.wantExcluded)
+			}
+			if !reflect.DeepEqual(s.short, tt.wantShort) {
+				t.Errorf("short = %v, want %v", s.short, tt.wantShort)
+			}
+			if !reflect.DeepEqual(s.Terms, tt.wantTerms) {
+				t.Errorf("Terms = %v, want %v", s.Terms, tt.wantTerms)
+			}
+		})
+	}
+}
+
+func TestDocumentSearchConditions(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantSQL  []string
+		wantArgs []interface{}
+	}{
+		{"bantuan -pangan", []string{"MATCH(sender, subject) AGAINST (? IN BOOLEAN MODE)"}, []interface{}{"+bantuan* -pangan"}},
+		{"-pangan", []string{"NOT MATCH(sender, subject) AGAINST (? IN BOOLEAN MODE)"}, []interface{}{"pangan"}},
+		{"KB -pangan", []string{"NOT MATCH(sender, subject) AGAINST (? IN BOOLEAN MODE)", "(sender LIKE ? OR subject LIKE ?)"}, []interface{}{"pangan", "%KB%", "%KB%"}},
+	}
+	for _, tt := range tests {
+		s := documentSearch{Query: tt.query}
+		s.parseQuery()
+		var sqls []string
+		var args []interface{}
+		for _, cond := range s.conditions("sender, subject") {
+			sqls = append(sqls, cond.SQL)
+			args = append(args, cond.Args...)
+		}
+		if !reflect.DeepEqual(sqls, tt.wantSQL) {
+			t.Errorf("%q: SQL = %v, want %v", tt.query, sqls, tt.wantSQL)
+		}
+		if !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("%q: args = %v, want %v", tt.query, args, tt.wantArgs)
+		}
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	filler := strings.Repeat("lorem ipsum ", 30)
+	tests := []struct {
+		name        string
+		text        string
+		terms       []string
+		want        string // dicek utuh jika tidak kosong
+		wantMatch   bool
+		wantLeading bool // diawali …
+		wantTrail   bool // diakhiri …
+	}{
+		{"tidak cocok", "Surat undangan rapat", []string{"bantuan"}, "", false, false, false},
+		{"teks pendek", "Bantuan sosial tunai", []string{"sosial"}, "Bantuan <mark>sosial</mark> tunai", true, false, false},
+		{"huruf besar kecil diabaikan", "BANTUAN sosial", []string{"bantuan"}, "<mark>BANTUAN</mark> sosial", true, false, false},
+		{"semua kecocokan ditandai", "beras dan beras", []string{"beras"}, "<mark>beras</mark> dan <mark>beras</mark>", true, false, false},
+		{"beberapa kata", "bantuan beras miskin", []string{"beras miskin", "bantuan"}, "<mark>bantuan</mark> <mark>beras miskin</mark>", true, false, false},
+		{"HTML di-escape", `<b>bantuan</b> & "sosial"`, []string{"bantuan"}, `&lt;b&gt;<mark>bantuan</mark>&lt;/b&gt; &amp; &#34;sosial&#34;`, true, false, false},
+		{"karakter regex pada kata kunci", "nomor 400.7 (a)", []string{"400.7 (a)"}, "nomor <mark>400.7 (a)</mark>", true, false, false},
+		{"panjang tepat batas", strings.Repeat("a", snippetLength-7) + "bantuan", []string{"bantuan"}, strings.Repeat("a", snippetLength-7) + "<mark>bantuan</mark>", true, false, false},
+		{"cocok di awal teks panjang", "bantuan " + filler, []string{"bantuan"}, "", true, false, true},
+		{"cocok di akhir teks panjang", filler + "bantuan", []string{"bantuan"}, "", true, true, false},
+		{"cocok di tengah teks panjang", filler + "bantuan " + filler, []string{"bantuan"}, "", true, true, true},
+		{"batas potongan di tengah rune", strings.Repeat("é", 200) + "bantuan" + strings.Repeat("ü", 200), []string{"bantuan"}, "", true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := highlightSnippet(tt.text, tt.terms)
+			if ok != tt.wantMatch {
+				t.Fatalf("cocok = %v, want %v", ok, tt.wantMatch)
+			}
+			if !ok {
+				return
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("snippet = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("snippet bukan UTF-8 yang valid: %q", got)
+			}
+			if !strings.Contains(got, "<mark>") {
+				t.Errorf("snippet tanpa <mark>: %q", got)
+			}
+			if strings.HasPrefix(got, "…") != tt.wantLeading {
+				t.Errorf("awalan … = %v, want %v: %q", !tt.wantLeading, tt.wantLeading, got)
+			}
+			if strings.HasSuffix(got, "…") != tt.wantTrail {
+				t.Errorf("akhiran … = %v, want %v: %q", !tt.wantTrail, tt.wantTrail, got)
+			}
+			plain := strings.NewReplacer("<mark>", "", "</mark>", "", "…", "").Replace(got)
+			if len(plain) > snippetLength+utf8.UTFMax {
+				t.Errorf("panjang snippet %d byte, maksimum %d", len(plain), snippetLength)
+			}
+		})
+	}
+}
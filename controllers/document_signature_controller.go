@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/esign"
+	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// signedFileName: surat.pdf menjadi surat-signed.pdf
+func signedFileName(name string) string {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	if base == "" {
+		base = "surat"
+	}
+	return base + "-signed.pdf"
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// signatureError memeriksa apakah user boleh memberi tanda tangan elektronik
+// pada surat. Hanya surat keluar berformat PDF yang sudah bernomor yang
+// bisa ditandatangani, oleh admin atau pemeriksa surat.
+func signatureError(user models.User, doc models.Document) error {
+	if !canApproveLetters(user) {
+		return &requestError{Status: http.StatusForbidden, Code: "forbidden", Message: "Hanya admin atau pemeriksa surat yang dapat menandatangani surat"}
+	}
+	if user.Role != "admin" && isDocumentOwner(user, doc) {
+		return &requestError{Status: http.StatusForbidden, Code: "forbidden", Message: "Surat tidak dapat ditandatangani oleh pembuatnya sendiri"}
+	}
+	if doc.WorkflowStatus != workflowNumbered && doc.WorkflowStatus != workflowSent {
+		return &requestError{
+			Status:  http.StatusConflict,
+			Code:    "invalid_transition",
+			Message: "Surat hanya bisa ditandatangani setelah disetujui dan diberi nomor",
+			Details: map[string]interface{}{"workflow_status": doc.WorkflowStatus},
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(doc.StorageKey))
+	if ext == "" {
+		ext = strings.ToLower(filepath.Ext(doc.FileName))
+	}
+	if doc.StorageKey == "" || ext != ".pdf" {
+		return &requestError{Status: http.StatusUnprocessableEntity, Code: "unsupported_file", Message: "Hanya file PDF yang bisa ditandatangani"}
+	}
+	return nil
+}
+
+// signatureResponse menambahkan link verifikasi dan info sertifikat ke catatan tanda tangan.
+func signatureResponse(sig models.DocumentSignature) gin.H {
+	return gin.H{
+		"signature":        sig,
+		"verification_url": esign.VerificationURL(sig.VerificationCode),
+		"download_url":     "/api/documents/" + sig.DocumentID + "/signature/pdf",
+		"signature_url":    "/api/documents/" + sig.DocumentID + "/signature/p7s",
+	}
+}
+
+// signLetter menempel cap, menandatangani dan menyimpan hasilnya ke storage.
+// Record belum dibuat; file yang sudah tersimpan dihapus jika terjadi error.
+func signLetter(doc models.Document, user models.User) (models.DocumentSignature, error) {
+	sig := models.DocumentSignature{
+		DocumentID:   doc.ID,
+		LetterNumber: doc.RegisterNumber,
+		Subject:      doc.Subject,
+		SignerID:     &user.ID,
+		SignerName:   user.Name,
+		FileName:     signedFileName(doc.FileName),
+		SignedAt:     time.Now(),
+	}
+	code, err := esign.NewCode()
+	if err != nil {
+		return sig, err
+	}
+	sig.VerificationCode = code
+
+	path, _, cleanup, err := downloadToTemp(documentDownloadable(doc))
+	if err != nil {
+		return sig, fmt.Errorf("gagal mengambil file surat: %w", err)
+	}
+	defer cleanup()
+	if sig.OriginalSHA256, err = fileSHA256(path); err != nil {
+		return sig, err
+	}
+
+	out, err := os.CreateTemp("", "signed-*.pdf")
+	if err != nil {
+		return sig, err
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	stamp := esign.Stamp{
+		LetterNumber: sig.LetterNumber,
+		SignerName:   sig.SignerName,
+		SignedAt:     sig.SignedAt,
+		Code:         sig.VerificationCode,
+	}
+	if err := esign.StampPDF(context.Background(), path, out.Name(), stamp); err != nil {
+		return sig, err
+	}
+
+	signed, err := os.ReadFile(out.Name())
+	if err != nil {
+		return sig, err
+	}
+	sum := sha256.Sum256(signed)
+	sig.SHA256 = hex.EncodeToString(sum[:])
+
+	p7s, err := esign.Sign(signed)
+	if err != nil {
+		return sig, fmt.Errorf("gagal menandatangani file: %w", err)
+	}
+	cert := esign.CertificateInfo()
+	sig.CertificateSubject = cert.Subject
+	sig.CertificateFingerprint = cert.Fingerprint
+
+	ctx := context.Background()
+	backend := storage.Default()
+	key := "dinsos_kuburaya/signed/" + uuid.NewString()
+	pdfObj, err := backend.Put(ctx, key+".pdf", bytes.NewReader(signed), int64(len(signed)), "application/pdf")
+	if err != nil {
+		return sig, fmt.Errorf("gagal menyimpan file bertanda tangan: %w", err)
+	}
+	p7sObj, err := backend.Put(ctx, key+".pdf.p7s", bytes.NewReader(p7s), int64(len(p7s)), "application/pkcs7-signature")
+	if err != nil {
+		deleteStoredFile(pdfObj.Backend, pdfObj.Key)
+		return sig, fmt.Errorf("gagal menyimpan file tanda tangan: %w", err)
+	}
+	sig.StorageKey, sig.StorageBackend = pdfObj.Key, pdfObj.Backend
+	sig.SignatureStorageKey, sig.SignatureStorageBackend = p7sObj.Key, p7sObj.Backend
+	return sig, nil
+}
+
+// ======================================================
+// SIGN LETTER
+// ======================================================
+// File asli tetap menjadi file dokumen, hasil bercap disimpan terpisah
+func SignDocument(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+	if err := signatureError(user, doc); err != nil {
+		respondFileError(c, err)
+		return
+	}
+	if !esign.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Tanda tangan elektronik tidak aktif di server ini"})
+		return
+	}
+
+	var existing models.DocumentSignature
+	if err := config.DB.First(&existing, "document_id = ?", doc.ID).Error; err == nil {
+		respondFileError(c, &requestError{
+			Status:  http.StatusConflict,
+			Code:    "already_signed",
+			Message: "Surat sudah ditandatangani",
+			Details: map[string]interface{}{"verification_code": existing.VerificationCode},
+		})
+		return
+	}
+
+	sig, err := signLetter(doc, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menandatangani surat: " + err.Error()})
+		return
+	}
+
+	// Unique index document_id mencegah dua tanda tangan jika ditandatangani bersamaan
+	if err := config.DB.Create(&sig).Error; err != nil {
+		deleteStoredFile(sig.StorageBackend, sig.StorageKey)
+		deleteStoredFile(sig.SignatureStorageBackend, sig.SignatureStorageKey)
+		c.JSON(http.StatusConflict, gin.H{"error": "Gagal menyimpan tanda tangan, surat mungkin sudah ditandatangani: " + err.Error()})
+		return
+	}
+	sig.Signer = user
+
+	message := fmt.Sprintf("%s menandatangani surat: %s (%s)", user.Name, doc.Subject, doc.RegisterNumber)
+	CreateActivityLog(user.ID, user.Name, "LETTER_SIGN", message)
+
+	recipients := letterParticipants(doc, user.ID, false)
+	go func() {
+		for _, id := range recipients {
+			_ = CreateNotification(id, message, letterLink(doc))
+		}
+	}()
+
+	response := signatureResponse(sig)
+	response["message"] = "Surat berhasil ditandatangani"
+	c.JSON(http.StatusCreated, response)
+}
+
+// deleteDocumentSignatureFiles menghapus PDF bercap dan .p7s milik dokumen
+// yang dihapus permanen. Catatan tanda tangannya tetap disimpan untuk
+// verifikasi QR code, hanya rujukan filenya dikosongkan dan purged_at diisi.
+func deleteDocumentSignatureFiles(documentID string) {
+	var sig models.DocumentSignature
+	if err := config.DB.First(&sig, "document_id = ?", documentID).Error; err != nil {
+		return
+	}
+	deleteStoredFile(sig.StorageBackend, sig.StorageKey)
+	deleteStoredFile(sig.SignatureStorageBackend, sig.SignatureStorageKey)
+	config.DB.Model(&sig).Updates(map[string]interface{}{
+		"storage_key":               "",
+		"storage_backend":           "",
+		"signature_storage_key":     "",
+		"signature_storage_backend": "",
+		"purged_at":                 time.Now(),
+	})
+}
+
+// ======================================================
+// GET SIGNATURE
+// ======================================================
+func GetDocumentSignature(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	var sig models.DocumentSignature
+	if err := config.DB.Preload("Signer").First(&sig, "document_id = ?", doc.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Surat belum ditandatangani"})
+		return
+	}
+	c.JSON(http.StatusOK, signatureResponse(sig))
+}
+
+// ======================================================
+// DOWNLOAD SIGNED FILE
+// ======================================================
+// :file = pdf (surat bercap) atau p7s (tanda tangan terpisah)
+func DownloadSignedDocument(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	var sig models.DocumentSignature
+	if err := config.DB.First(&sig, "document_id = ?", doc.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Surat belum ditandatangani"})
+		return
+	}
+
+	file := downloadable{Backend: sig.StorageBackend, Key: sig.StorageKey, FileName: sig.FileName, UpdatedAt: sig.SignedAt}
+	switch c.Param("file") {
+	case "pdf":
+	case "p7s":
+		file.Backend, file.Key, file.FileName = sig.SignatureStorageBackend, sig.SignatureStorageKey, sig.FileName+".p7s"
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "File tidak dikenal, gunakan pdf atau p7s"})
+		return
+	}
+	serveDownloadable(c, file)
+}
+
+// ======================================================
+// VERIFY SIGNATURE (TANPA TOKEN)
+// ======================================================
+// Dibuka penerima surat dari QR code. Hanya nomor surat, penanda tangan dan
+// hash yang ditampilkan; perihal dan isi surat tidak dibuka ke publik.
+// Query sha256 (opsional) dicocokkan dengan hash file bertanda tangan.
+// Tanda tangan surat yang sudah dihapus permanen tetap bisa diverifikasi
+// lewat hash; purged_at menandakan file di server sudah dimusnahkan.
+func VerifyDocumentSignature(c *gin.Context) {
+	var sig models.DocumentSignature
+	if err := config.DB.First(&sig, "verification_code = ?", strings.ToUpper(strings.TrimSpace(c.Param("code")))).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"valid": false, "error": "Kode verifikasi tidak terdaftar"})
+		return
+	}
+
+	response := gin.H{
+		"valid":                   true,
+		"verification_code":       sig.VerificationCode,
+		"letter_number":           sig.LetterNumber,
+		"signer_name":             sig.SignerName,
+		"signed_at":               sig.SignedAt,
+		"sha256":                  sig.SHA256,
+		"certificate_subject":     sig.CertificateSubject,
+		"certificate_fingerprint": sig.CertificateFingerprint,
+		"purged_at":               sig.PurgedAt,
+	}
+	if hash := strings.ToLower(strings.TrimSpace(c.Query("sha256"))); hash != "" {
+		response["sha256_match"] = hash == sig.SHA256
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/storage"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setCurrentFile mengarahkan record dokumen ke file milik versi tertentu.
+func setCurrentFile(tx *gorm.DB, doc models.Document, v models.DocumentVersion) error {
+	updates := resetFileProcessingFields()
+	updates["file_url"] = v.FileURL
+	updates["file_name"] = v.FileName
+	updates["storage_key"] = v.StorageKey
+	updates["storage_backend"] = v.StorageBackend
+	return tx.Model(&doc).Updates(updates).Error
+}
+
+// recordDocumentVersion menambahkan versi baru dengan nomor berikutnya.
+// Baris dokumen dikunci lebih dulu agar dua upload bersamaan tidak
+// mendapat nomor versi yang sama.
+func recordDocumentVersion(tx *gorm.DB, documentID, fileName string, obj storage.Object, uploaderID, note string) (models.DocumentVersion, error) {
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		First(&models.Document{}, "id = ?", documentID).Error; err != nil {
+		return models.DocumentVersion{}, err
+	}
+
+	var last int
+	if err := tx.Model(&models.DocumentVersion{}).
+		Where("document_id = ?", documentID).
+		Select("COALESCE(MAX(version_number), 0)").
+		Scan(&last).Error; err != nil {
+		return models.DocumentVersion{}, err
+	}
+
+	version := models.DocumentVersion{
+		DocumentID:     documentID,
+		VersionNumber:  last + 1,
+		FileName:       fileName,
+		FileURL:        obj.URL,
+		StorageKey:     obj.Key,
+		StorageBackend: obj.Backend,
+		Size:           obj.Size,
+		ContentType:    obj.ContentType,
+		ChangeNote:     note,
+	}
+	if uploaderID != "" {
+		version.UploadedByID = &uploaderID
+	}
+	err := tx.Create(&version).Error
+	return version, err
+}
+
+// deleteDocumentVersions menghapus semua versi beserta filenya. Versi hasil
+// restore memakai file yang sama, jadi setiap key hanya dihapus sekali.
+func deleteDocumentVersions(documentID string, current downloadable) {
+	var versions []models.DocumentVersion
+	config.DB.Where("document_id = ?", documentID).Find(&versions)
+
+	deleted := map[string]bool{}
+	deleteOnce := func(backend, key string) {
+		if key == "" || deleted[backend+"/"+key] {
+			return
+		}
+		deleted[backend+"/"+key] = true
+		deleteStoredFile(backend, key)
+	}
+
+	deleteOnce(current.Backend, current.Key)
+	for _, v := range versions {
+		deleteOnce(v.StorageBackend, v.StorageKey)
+	}
+
+	config.DB.Where("document_id = ?", documentID).Delete(&models.DocumentVersion{})
+}
+
+// ======================================================
+// LIST DOCUMENT VERSIONS
+// ======================================================
+func GetDocumentVersions(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	var versions []models.DocumentVersion
+	if err := config.DB.Preload("UploadedBy").
+		Where("document_id = ?", doc.ID).
+		Order("version_number DESC").
+		Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil riwayat versi: " + err.Error()})
+		return
+	}
+
+	var response []gin.H
+	for i, v := range versions {
+		uploaderName := "-"
+		if v.UploadedBy.Name != "" {
+			uploaderName = v.UploadedBy.Name
+		}
+		response = append(response, gin.H{
+			"id":             v.ID,
+			"version_number": v.VersionNumber,
+			"file_name":      v.FileName,
+			"size":           v.Size,
+			"content_type":   v.ContentType,
+			"change_note":    v.ChangeNote,
+			"uploaded_by_id": v.UploadedByID,
+			"uploader_name":  uploaderName,
+			"created_at":     v.CreatedAt,
+			"is_current":     i == 0,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"document_id": doc.ID,
+		"source":      documentSource(doc),
+		"versions":    response,
+	})
+}
+
+// ======================================================
+// DOWNLOAD DOCUMENT VERSION
+// ======================================================
+func DownloadDocumentVersion(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	var version models.DocumentVersion
+	if err := config.DB.First(&version, "id = ? AND document_id = ?",
+		c.Param("version_id"), doc.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Versi tidak ditemukan"})
+		return
+	}
+
+	serveDownloadable(c, downloadable{
+		Backend:   version.StorageBackend,
+		Key:       version.StorageKey,
+		FileName:  version.FileName,
+		LegacyURL: version.FileURL,
+		UpdatedAt: version.CreatedAt,
+	})
+}
+
+// ======================================================
+// RESTORE DOCUMENT VERSION
+// ======================================================
+// Restore tidak menghapus riwayat: versi lama disalin menjadi versi terbaru.
+func RestoreDocumentVersion(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+	if err := ensureDocumentEditable(doc); err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	var input struct {
+		ChangeNote string `json:"change_note"`
+	}
+	_ = c.ShouldBindJSON(&input)
+
+	var restored models.DocumentVersion
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var source models.DocumentVersion
+		if err := tx.First(&source, "id = ? AND document_id = ?",
+			c.Param("version_id"), doc.ID).Error; err != nil {
+			return err
+		}
+
+		note := input.ChangeNote
+		if note == "" {
+			note = fmt.Sprintf("Dipulihkan dari versi %d", source.VersionNumber)
+		}
+
+		var err error
+		restored, err = recordDocumentVersion(tx, doc.ID, source.FileName, storage.Object{
+			Key:         source.StorageKey,
+			Backend:     source.StorageBackend,
+			URL:         source.FileURL,
+			Size:        source.Size,
+			ContentType: source.ContentType,
+		}, user.ID, note)
+		if err != nil {
+			return err
+		}
+
+		return setCurrentFile(tx, doc, restored)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Versi tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulihkan versi: " + err.Error()})
+		return
+	}
+
+	queueFileProcessing(doc.ID)
+
+	CreateActivityLog(user.ID, user.Name, "RESTORE_VERSION",
+		fmt.Sprintf("Memulihkan dokumen %s ke file %s (versi %d)", doc.Subject, restored.FileName, restored.VersionNumber))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Versi berhasil dipulihkan", "version": restored})
+}
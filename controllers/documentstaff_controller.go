@@ -1,18 +1,17 @@
 package controllers
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"path/filepath"
 	"strconv"
-	"strings"
+	"time"
 
 	"dinsos_kuburaya/config"
 	"dinsos_kuburaya/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // ======================================================
@@ -30,6 +29,7 @@ func CreateDocumentStaff(c *gin.Context) {
 	// Sender dan LetterType opsional dari Frontend
 	sender := c.PostForm("sender")
 	letterType := c.PostForm("letter_type")
+	reservationID := c.PostForm("reservation_id")
 
 	// Validasi Subject Wajib
 	if subject == "" {
@@ -45,60 +45,114 @@ func CreateDocumentStaff(c *gin.Context) {
 		letterType = "keluar" // Default jenis surat
 	}
 
-	fileHeader, err := c.FormFile("file")
+	// Surat keluar dibuat sebagai draft dan baru diberi nomor setelah disetujui
+	workflowStatus := ""
+	if letterType == "keluar" {
+		workflowStatus = workflowDraft
+		if reservationID != "" {
+			respondFileError(c, &requestError{
+				Status:  http.StatusBadRequest,
+				Code:    "invalid_reservation",
+				Message: "Nomor surat keluar diberikan setelah surat disetujui (aksi number)",
+			})
+			return
+		}
+	}
+
+	metadata, _, err := parseLetterMetadata(c, models.LetterMetadata{})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File tidak ditemukan"})
+		respondFileError(c, err)
 		return
 	}
-
-	src, err := fileHeader.Open()
+	// Dokumen staff hanya terlihat oleh admin, pemilik dan penerima disposisi
+	// kecuali dibuat public
+	visibility, err := parseDocumentVisibility(c, "private")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Tidak dapat membuka file"})
+		respondFileError(c, err)
 		return
 	}
-	defer src.Close()
 
-	fileBytes, err := io.ReadAll(src)
+	incoming, err := incomingFileFromForm(c, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membaca file buffer"})
+		respondFileError(c, err)
 		return
 	}
-	reader := bytes.NewReader(fileBytes)
-
-	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	var resourceType string
-	var folder string
-
-	switch ext {
-	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
-		resourceType = "image"
-		folder = "dinsos_kuburaya/gambar"
-	case ".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx":
-		resourceType = "raw"
-		folder = "dinsos_kuburaya/arsip"
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Format file tidak didukung"})
+	if incoming == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File tidak ditemukan"})
 		return
 	}
 
-	uploadResult, err := config.UploadToCloudinary(reader, fileHeader.Filename, folder, resourceType)
+	stored, err := storeFile(c.Request.Context(), incoming)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Upload gagal: " + err.Error()})
+		respondFileError(c, err)
 		return
 	}
 
-	document := models.DocumentStaff{
-		UserID:       user.ID,
-		Sender:       sender,
-		Subject:      subject,
-		LetterType:   letterType,
-		FileName:     uploadResult.SecureURL,
-		PublicID:     uploadResult.PublicID,
-		ResourceType: resourceType,
+	// Lampiran (opsional) melalui validasi dan penyimpanan yang sama dengan file utama
+	attachmentFiles, err := incomingAttachmentsFromForm(c, user)
+	if err != nil {
+		deleteStoredFile(stored.Backend, stored.Key)
+		respondFileError(c, err)
+		return
+	}
+	storedAttachments, err := storeAttachments(c.Request.Context(), attachmentFiles)
+	if err != nil {
+		deleteStoredFile(stored.Backend, stored.Key)
+		respondFileError(c, err)
+		return
 	}
 
-	if err := config.DB.Create(&document).Error; err != nil {
-		config.DeleteFromCloudinary(uploadResult.PublicID, resourceType)
+	userID := user.ID
+	document := models.Document{
+		UserID:         &userID,
+		Sender:         sender,
+		Subject:        subject,
+		LetterType:     letterType,
+		FileName:       incoming.Name,
+		FileURL:        stored.URL,
+		StorageKey:     stored.Key,
+		StorageBackend: stored.Backend,
+		Origin:         "staff",
+		Visibility:     visibility,
+		WorkflowStatus: workflowStatus,
+		LetterMetadata: metadata,
+	}
+
+	var attachments []models.DocumentAttachment
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&document).Error; err != nil {
+			return err
+		}
+		if workflowStatus != "" {
+			if err := recordLetterTransition(tx, document, "create", "", workflowStatus, "", user.ID); err != nil {
+				return err
+			}
+		} else {
+			number, err := assignLetterNumber(tx, document.ID, letterType, metadata.ClassificationCode, reservationID, user)
+			if err != nil {
+				return err
+			}
+			if number != "" {
+				document.RegisterNumber = number
+				if err := tx.Model(&document).UpdateColumn("register_number", number).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if _, err := recordDocumentVersion(tx, document.ID, incoming.Name, stored, user.ID, "Versi awal"); err != nil {
+			return err
+		}
+		attachments, err = createAttachmentRecords(tx, document.ID, user.ID, storedAttachments)
+		return err
+	})
+	if err != nil {
+		deleteStoredFile(stored.Backend, stored.Key)
+		deleteStoredAttachments(storedAttachments)
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			respondFileError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB error: " + err.Error()})
 		return
 	}
@@ -106,9 +160,13 @@ func CreateDocumentStaff(c *gin.Context) {
 	// Log Activity
 	msg := fmt.Sprintf("Mengupload dokumen baru dengan subjek: %s", document.Subject)
 	LogActivity(user.ID, user.Name, "UPLOAD_DOKUMEN", msg)
+	queueFileProcessing(document.ID)
 
-	// Notifikasi ke Admin
+	// Notifikasi ke Admin (draft surat keluar baru dinotifikasi saat diajukan)
 	go func() {
+		if workflowStatus != "" {
+			return
+		}
 		var admins []models.User
 		if err := config.DB.Where("role = ?", "admin").Find(&admins).Error; err == nil {
 			for _, admin := range admins {
@@ -120,8 +178,9 @@ func CreateDocumentStaff(c *gin.Context) {
 	}()
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message":  "Dokumen berhasil diupload",
-		"document": document,
+		"message":     "Dokumen berhasil diupload",
+		"document":    document,
+		"attachments": attachmentResponse(attachments, user.ID),
 	})
 }
 
@@ -138,108 +197,118 @@ func GetDocumentStaffs(c *gin.Context) {
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "100"))
-	search := c.Query("search")
-	letterType := c.Query("letter_type")
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 100
+	}
+	search, err := parseDocumentSearch(c)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
 
-	// Struct khusus untuk menampung hasil gabungan tabel
+	// Bentuk response lama (hasil UNION dua tabel) dipertahankan untuk frontend
 	type CombinedDoc struct {
-		ID         string  `json:"id"`
-		Sender     string  `json:"sender"`
-		Subject    string  `json:"subject"`
-		LetterType string  `json:"letter_type"`
-		FileName   string  `json:"file_name"`
-		UserID     *string `json:"user_id"`
-		UserName   string  `json:"user_name"`
-		CreatedAt  string  `json:"created_at"`
-		UpdatedAt  string  `json:"updated_at"`
-		Source     string  `json:"source"` // Untuk membedakan asal dokumen di frontend
-	}
-
-	var combinedDocs []CombinedDoc
-
-	// Filter Pencarian
-	searchWhere := ""
-	searchArgs := []interface{}{}
-	if search != "" {
-		searchPattern := "%" + search + "%"
-		// Mencari di sender, subject, atau nama file
-		searchWhere = "AND (sender LIKE ? OR subject LIKE ? OR file_name LIKE ?)"
-		searchArgs = []interface{}{searchPattern, searchPattern, searchPattern}
-	}
-
-	// Filter Tipe Surat
-	letterTypeWhere := ""
-	letterTypeArgs := []interface{}{}
-	if letterType != "" && letterType != "all" {
-		letterTypeWhere = "AND letter_type = ?"
-		letterTypeArgs = []interface{}{letterType}
-	}
-
-	// LOGIKA FILTER USER:
-	// 1. Tabel `documents` (Admin Upload): TIDAK ADA filter user_id, karena staff boleh lihat semua dokumen admin.
-	// 2. Tabel `document_staffs` (Staff Upload):
-	//    - Jika Admin: Lihat semua.
-	//    - Jika Staff: Hanya lihat milik sendiri.
-
-	staffFilter := ""
-	if user.Role != "admin" {
-		staffFilter = fmt.Sprintf("AND user_id = '%s'", user.ID)
-	}
-
-	// QUERY GABUNGAN (UNION)
-	query := fmt.Sprintf(`
-		(
-			SELECT 
-				id, sender, subject, letter_type, file_url as file_name, 
-				user_id, 'Admin' as user_name, created_at, updated_at, 'document' as source
-			FROM documents
-			WHERE 1=1 %s %s
-		)
-		UNION ALL
-		(
-			SELECT 
-				id, sender, subject, letter_type, file_name, 
-				user_id, 'Staff' as user_name, created_at, updated_at, 'document_staff' as source
-			FROM document_staffs
-			WHERE 1=1 %s %s %s
-		)
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`, searchWhere, letterTypeWhere, searchWhere, letterTypeWhere, staffFilter)
-
-	// Susun Arguments
-	args := []interface{}{}
-	// Args untuk query pertama (Documents Admin)
-	args = append(args, searchArgs...)
-	args = append(args, letterTypeArgs...)
-	// Args untuk query kedua (Document Staffs)
-	args = append(args, searchArgs...)
-	args = append(args, letterTypeArgs...)
-	// Args untuk Limit/Offset
-	args = append(args, perPage, (page-1)*perPage)
-
-	if err := config.DB.Raw(query, args...).Scan(&combinedDocs).Error; err != nil {
+		ID             string `json:"id"`
+		Sender         string `json:"sender"`
+		Subject        string `json:"subject"`
+		LetterType     string `json:"letter_type"`
+		RegisterNumber string `json:"register_number"`
+		models.LetterMetadata
+		FileName         string            `json:"file_name"`
+		UserID           *string           `json:"user_id"`
+		UserName         string            `json:"user_name"`
+		CreatedAt        time.Time         `json:"created_at"`
+		UpdatedAt        time.Time         `json:"updated_at"`
+		Source           string            `json:"source"` // Untuk membedakan asal dokumen di frontend
+		Origin           string            `json:"origin"`
+		Visibility       string            `json:"visibility"`
+		WorkflowStatus   string            `json:"workflow_status"`
+		FolderID         *string           `json:"folder_id"`
+		Tags             []models.Tag      `json:"tags"`
+		Score            float64           `json:"score"`
+		ExtractionStatus string            `json:"extraction_status"`
+		PreviewStatus    string            `json:"preview_status"`
+		ThumbnailURL     string            `json:"thumbnail_url,omitempty"`
+		Highlights       map[string]string `json:"highlights,omitempty"`
+	}
+
+	// Admin melihat semua dokumen, staff hanya dokumen public, miliknya sendiri
+	// dan dokumen yang didisposisikan kepadanya
+	query := visibleDocuments(config.DB.Model(&models.Document{}), user)
+	for _, cond := range search.conditions(documentSearchColumns) {
+		query = query.Where(cond.SQL, cond.Args...)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil dokumen: " + err.Error()})
 		return
 	}
 
-	// Hitung Total (Untuk Pagination Frontend)
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) FROM (
-			SELECT id FROM documents WHERE 1=1 %s %s
-			UNION ALL
-			SELECT id FROM document_staffs WHERE 1=1 %s %s %s
-		) as total_docs
-	`, searchWhere, letterTypeWhere, searchWhere, letterTypeWhere, staffFilter)
+	// Urutan: sort dari user, lalu relevansi hasil pencarian, lalu terbaru dulu
+	if order := search.orderBy(); order != "" {
+		query = query.Order(order)
+	}
+	if search.Boolean != "" {
+		scoreSQL, scoreArgs := search.scoreExpr(documentSearchColumns)
+		query = query.Select("documents.*, "+scoreSQL+" AS score", scoreArgs...).Order("score DESC")
+	} else if len(search.Terms) == 0 {
+		// Teks hasil OCR hanya diambil jika dibutuhkan untuk highlight
+		query = query.Omit("extracted_text")
+	}
 
-	countArgs := []interface{}{}
-	countArgs = append(countArgs, searchArgs...)
-	countArgs = append(countArgs, letterTypeArgs...)
-	countArgs = append(countArgs, searchArgs...)
-	countArgs = append(countArgs, letterTypeArgs...)
+	var documents []models.Document
+	if err := query.Order("created_at DESC").
+		Limit(perPage).Offset((page - 1) * perPage).
+		Find(&documents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil dokumen: " + err.Error()})
+		return
+	}
 
-	var total int64
-	config.DB.Raw(countQuery, countArgs...).Count(&total)
+	ids := make([]string, 0, len(documents))
+	for _, doc := range documents {
+		ids = append(ids, doc.ID)
+	}
+	tags := documentTags(ids)
+
+	combinedDocs := []CombinedDoc{}
+	for _, doc := range documents {
+		userName := "Admin"
+		if doc.Origin == "staff" {
+			userName = "Staff"
+		}
+		combinedDocs = append(combinedDocs, CombinedDoc{
+			ID:               doc.ID,
+			Sender:           doc.Sender,
+			Subject:          doc.Subject,
+			LetterType:       doc.LetterType,
+			RegisterNumber:   doc.RegisterNumber,
+			LetterMetadata:   doc.LetterMetadata,
+			FileName:         doc.FileName,
+			UserID:           doc.UserID,
+			UserName:         userName,
+			CreatedAt:        doc.CreatedAt,
+			UpdatedAt:        doc.UpdatedAt,
+			Source:           documentSource(doc),
+			Origin:           doc.Origin,
+			Visibility:       doc.Visibility,
+			WorkflowStatus:   doc.WorkflowStatus,
+			FolderID:         doc.FolderID,
+			Tags:             tagsOf(tags, doc.ID),
+			Score:            doc.SearchScore,
+			ExtractionStatus: doc.ExtractionStatus,
+			PreviewStatus:    doc.PreviewStatus,
+			ThumbnailURL:     thumbnailURL(doc.ID, doc.PreviewStatus, user.ID),
+			Highlights: search.highlights(map[string]string{
+				"sender":         doc.Sender,
+				"subject":        doc.Subject,
+				"extracted_text": doc.ExtractedText,
+			}),
+		})
+	}
 
 	// Hitung Last Page
 	lastPage := int(total) / perPage
@@ -260,52 +329,10 @@ func GetDocumentStaffs(c *gin.Context) {
 }
 
 // ======================================================
-// GET STAFF DOCUMENT BY ID (Mencari di kedua tabel)
+// GET STAFF DOCUMENT BY ID
 // ======================================================
 func GetDocumentStaffByID(c *gin.Context) {
 	id := c.Param("id")
-
-	// Cek Tabel Staff Dulu
-	var docStaff models.DocumentStaff
-	errStaff := config.DB.Preload("User").First(&docStaff, "id = ?", id).Error
-
-	if errStaff == nil {
-		// Ketemu di tabel staff
-		c.JSON(http.StatusOK, gin.H{"document": docStaff})
-		return
-	}
-
-	// Jika tidak, Cek Tabel Admin
-	var doc models.Document
-	errDoc := config.DB.Preload("User").First(&doc, "id = ?", id).Error
-
-	if errDoc == nil {
-		// Ketemu di tabel admin -> Konversi format response agar frontend staff tidak error
-		response := gin.H{
-			"id":          doc.ID,
-			"sender":      doc.Sender,
-			"subject":     doc.Subject,
-			"letter_type": doc.LetterType,
-			"file_name":   doc.FileURL, // Mapping FileURL ke file_name
-			"user_id":     doc.UserID,
-			"created_at":  doc.CreatedAt,
-			"updated_at":  doc.UpdatedAt,
-			"user":        doc.User,
-			"source":      "document", // Penanda
-		}
-		c.JSON(http.StatusOK, gin.H{"document": response})
-		return
-	}
-
-	c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
-}
-
-// ======================================================
-// UPDATE STAFF DOCUMENT
-// ======================================================
-func UpdateDocumentStaff(c *gin.Context) {
-	id := c.Param("id")
-
 	userRaw, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -313,150 +340,76 @@ func UpdateDocumentStaff(c *gin.Context) {
 	}
 	user := userRaw.(models.User)
 
-	var document models.DocumentStaff
-
-	if err := config.DB.First(&document, "id = ?", id).Error; err != nil {
+	var doc models.Document
+	if err := config.DB.Preload("User").First(&doc, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
 		return
 	}
-
-	if user.Role != "admin" && document.UserID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengedit dokumen ini"})
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
 		return
 	}
 
-	sender := c.PostForm("sender")
-	subject := c.PostForm("subject")
-	letterType := c.PostForm("letter_type")
-
-	updates := map[string]interface{}{}
-
-	if sender != "" {
-		updates["sender"] = sender
-	}
-	if subject != "" {
-		updates["subject"] = subject
-	}
-	if letterType != "" {
-		updates["letter_type"] = letterType
-	}
-
-	fileHeader, err := c.FormFile("file")
-	if err == nil {
-		src, err := fileHeader.Open()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Tidak dapat membuka file"})
-			return
-		}
-		defer src.Close()
-
-		fileBytes, err := io.ReadAll(src)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membaca file buffer"})
-			return
-		}
-		reader := bytes.NewReader(fileBytes)
-
-		ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-		var resourceType string
-		var folder string
-
-		switch ext {
-		case ".jpg", ".jpeg", ".png", ".gif", ".webp":
-			resourceType = "image"
-			folder = "dinsos_kuburaya/gambar"
-		case ".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx":
-			resourceType = "raw"
-			folder = "dinsos_kuburaya/arsip"
-		default:
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Format file tidak didukung"})
-			return
-		}
-
-		uploadResult, err := config.UploadToCloudinary(reader, fileHeader.Filename, folder, resourceType)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Upload gagal: " + err.Error()})
-			return
-		}
-
-		if document.PublicID != "" {
-			config.DeleteFromCloudinary(document.PublicID, document.ResourceType)
-		}
-		updates["file_name"] = uploadResult.SecureURL
-		updates["public_id"] = uploadResult.PublicID
-		updates["resource_type"] = resourceType
-	}
-
-	if len(updates) > 0 {
-		if err := config.DB.Model(&document).Updates(updates).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan perubahan"})
-			return
-		}
+	attachments := attachmentResponse(listDocumentAttachments(doc.ID), user.ID)
+	response := gin.H{
+		"id":              doc.ID,
+		"sender":          doc.Sender,
+		"subject":         doc.Subject,
+		"letter_type":     doc.LetterType,
+		"register_number": doc.RegisterNumber,
+		"origin":          doc.Origin,
+		"visibility":      doc.Visibility,
+		"workflow_status": doc.WorkflowStatus,
+		"folder_id":       doc.FolderID,
+		"tags":            tagsOf(documentTags([]string{doc.ID}), doc.ID),
+
+		"sender_letter_number": doc.SenderLetterNumber,
+		"letter_date":          doc.LetterDate,
+		"received_date":        doc.ReceivedDate,
+		"classification_code":  doc.ClassificationCode,
+		"urgency":              doc.Urgency,
+		"confidentiality":      doc.Confidentiality,
+		"page_count":           doc.PageCount,
+		"attachment_count":     doc.AttachmentCount,
+		"reply_required_by":    doc.ReplyRequiredBy,
+
+		"file_name":  doc.FileName,
+		"file_url":   documentDownloadPath(doc),
+		"user_id":    doc.UserID,
+		"created_at": doc.CreatedAt,
+		"updated_at": doc.UpdatedAt,
+		"user":       doc.User,
+		"source":     documentSource(doc), // Penanda
+
+		"extraction_status": doc.ExtractionStatus,
+		"preview_status":    doc.PreviewStatus,
+		"thumbnail_url":     thumbnailURL(doc.ID, doc.PreviewStatus, user.ID),
+		"attachments":       attachments,
 	}
-
-	config.DB.Preload("User").Find(&document)
-
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "Dokumen berhasil diperbarui",
-		"document": document,
+		"document":    response,
+		"attachments": attachments,
 	})
 }
 
+// ======================================================
+// UPDATE STAFF DOCUMENT
+// ======================================================
+// Staff hanya boleh mengubah dokumen miliknya, admin semua dokumen.
+func UpdateDocumentStaff(c *gin.Context) {
+	UpdateDocument(c)
+}
+
 // ======================================================
 // DELETE DOCUMENT STAFF
 // ======================================================
 func DeleteDocumentStaff(c *gin.Context) {
-	id := c.Param("id")
-
-	var docStaff models.DocumentStaff
-	errStaff := config.DB.First(&docStaff, "id = ?", id).Error
-
-	if errStaff == nil {
-		if docStaff.PublicID != "" {
-			config.DeleteFromCloudinary(docStaff.PublicID, docStaff.ResourceType)
-		}
-		config.DB.Delete(&docStaff)
-		c.JSON(http.StatusOK, gin.H{"message": "Dokumen berhasil dihapus"})
-		return
-	}
-
-	// Jika admin menghapus dokumen via endpoint ini
-	var doc models.Document
-	errDoc := config.DB.First(&doc, "id = ?", id).Error
-
-	if errDoc == nil {
-		if doc.PublicID != "" {
-			config.DeleteFromCloudinary(doc.PublicID, doc.ResourceType)
-		}
-		config.DB.Delete(&doc)
-		c.JSON(http.StatusOK, gin.H{"message": "Dokumen berhasil dihapus"})
-		return
-	}
-
-	c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+	DeleteDocument(c)
 }
 
 // ======================================================
 // DOWNLOAD DOCUMENT STAFF
 // ======================================================
 func DownloadDocumentStaff(c *gin.Context) {
-	id := c.Param("id")
-
-	var docStaff models.DocumentStaff
-	errStaff := config.DB.First(&docStaff, "id = ?", id).Error
-
-	if errStaff == nil && docStaff.FileName != "" {
-		c.Redirect(http.StatusTemporaryRedirect, docStaff.FileName)
-		return
-	}
-
-	var doc models.Document
-	errDoc := config.DB.First(&doc, "id = ?", id).Error
-
-	if errDoc == nil && doc.FileURL != "" {
-		c.Redirect(http.StatusTemporaryRedirect, doc.FileURL)
-		return
-	}
-
-	c.JSON(http.StatusNotFound, gin.H{"error": "File tidak tersedia"})
+	DownloadDocument(c)
 }
@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Masa berlaku link download bertanda tangan
+const downloadLinkExpiry = 5 * time.Minute
+
+// downloadable adalah informasi file yang cukup untuk menyajikannya ke client.
+type downloadable struct {
+	Backend   string
+	Key       string
+	FileName  string
+	LegacyURL string
+	UpdatedAt time.Time
+}
+
+// canAccessDocument menentukan apakah user boleh membuka dokumen. Dokumen
+// public terbuka untuk semua user yang login, dokumen private hanya untuk
+// admin, pemiliknya dan user yang menerima disposisi dokumen tersebut.
+// Pemeriksa surat keluar boleh membuka surat yang sudah diajukan.
+func canAccessDocument(user models.User, doc models.Document) bool {
+	if user.Role == "admin" || isDocumentOwner(user, doc) {
+		return true
+	}
+	if user.CanApproveLetters && inLetterReview(doc) {
+		return true
+	}
+	if doc.Visibility != "private" {
+		return user.Role == "staff"
+	}
+	var count int64
+	config.DB.Model(&models.SuperiorOrder{}).Where("document_id = ? AND user_id = ?", doc.ID, user.ID).Count(&count)
+	return count > 0
+}
+
+// canEditDocument: dokumen hanya diubah admin atau pemiliknya.
+func canEditDocument(user models.User, doc models.Document) bool {
+	return user.Role == "admin" || isDocumentOwner(user, doc)
+}
+
+func isDocumentOwner(user models.User, doc models.Document) bool {
+	return doc.UserID != nil && *doc.UserID == user.ID
+}
+
+// visibleDocuments membatasi query documents dengan aturan yang sama seperti canAccessDocument.
+func visibleDocuments(query *gorm.DB, user models.User) *gorm.DB {
+	if user.Role == "admin" {
+		return query
+	}
+	dispositions := config.DB.Model(&models.SuperiorOrder{}).Select("document_id").Where("user_id = ?", user.ID)
+	if user.CanApproveLetters {
+		return query.Where("(documents.visibility = ? OR documents.user_id = ? OR documents.id IN (?) OR documents.workflow_status NOT IN ?)",
+			"public", user.ID, dispositions, []string{"", workflowDraft})
+	}
+	return query.Where("(documents.visibility = ? OR documents.user_id = ? OR documents.id IN (?))", "public", user.ID, dispositions)
+}
+
+// findDocument mencari dokumen aktif (bukan di recycle bin).
+func findDocument(id string) (models.Document, bool) {
+	var doc models.Document
+	if err := config.DB.First(&doc, "id = ?", id).Error; err != nil {
+		return doc, false
+	}
+	return doc, true
+}
+
+// documentSource adalah penanda asal dokumen di response API lama:
+// "document" untuk dokumen admin dan "document_staff" untuk dokumen staff.
+func documentSource(doc models.Document) string {
+	if doc.Origin == "staff" {
+		return "document_staff"
+	}
+	return "document"
+}
+
+func documentOwnerID(doc models.Document) string {
+	if doc.UserID == nil {
+		return ""
+	}
+	return *doc.UserID
+}
+
+// documentDownloadPath adalah endpoint API untuk mengunduh file dokumen.
+// URL storage tidak pernah dikirim ke client karena URL Cloudinary bersifat
+// publik dan permanen; file selalu diunduh lewat API dengan pemeriksaan akses.
+func documentDownloadPath(doc models.Document) string {
+	if doc.Origin == "staff" {
+		return "/api/document_staff/" + doc.ID + "/download"
+	}
+	return "/api/documents/" + doc.ID + "/download"
+}
+
+func documentDownloadable(doc models.Document) downloadable {
+	return downloadable{
+		Backend:   doc.StorageBackend,
+		Key:       doc.StorageKey,
+		FileName:  doc.FileName,
+		LegacyURL: doc.FileURL,
+		UpdatedAt: doc.UpdatedAt,
+	}
+}
+
+// serveDownloadable men-stream file lewat API dengan dukungan Range,
+// If-Modified-Since dan If-Range (ditangani http.ServeContent).
+func serveDownloadable(c *gin.Context, file downloadable) {
+	// Record lama sebelum storage layer belum punya key, hanya URL Cloudinary
+	if file.Key == "" {
+		if file.LegacyURL == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File tidak tersedia"})
+			return
+		}
+		serveLegacyURL(c, file)
+		return
+	}
+
+	backend, err := storage.Get(file.Backend)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	reader, obj, err := backend.Open(c.Request.Context(), file.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File tidak tersedia"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal membuka file: " + err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(strings.ToLower(filepath.Ext(file.FileName)))
+	}
+	if contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+
+	disposition := "attachment"
+	if c.Query("inline") == "1" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": file.FileName}))
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	modTime := obj.ModTime
+	if modTime.IsZero() {
+		modTime = file.UpdatedAt
+	}
+	http.ServeContent(c.Writer, c.Request, file.FileName, modTime, reader)
+}
+
+// serveLegacyURL men-stream file lama yang hanya punya URL Cloudinary lewat
+// API, sehingga URL publiknya tidak dikirim ke client.
+func serveLegacyURL(c *gin.Context, file downloadable) {
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, file.LegacyURL, nil)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File tidak tersedia"})
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal membuka file: " + err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File tidak tersedia"})
+		return
+	}
+
+	disposition := "attachment"
+	if c.Query("inline") == "1" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": file.FileName}))
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, nil)
+}
+
+// signedDownloadURL membuat link yang berlaku sebentar untuk user tertentu.
+// Link tetap memeriksa hak akses user saat dipakai.
+func signedDownloadURL(kind, id, userID string) (string, time.Time) {
+	expiresAt := time.Now().Add(downloadLinkExpiry)
+	expires := expiresAt.Unix()
+
+	q := url.Values{}
+	q.Set("uid", userID)
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("signature", storage.Sign(kind+":"+id+":"+userID, expires))
+
+	return "/api/downloads/" + kind + "/" + id + "?" + q.Encode(), expiresAt
+}
+
+// ======================================================
+// CREATE DOWNLOAD LINK
+// ======================================================
+// Dipakai oleh /documents/:id/download-link dan /document_staff/:id/download-link
+func CreateDownloadLink(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+	if !storage.SigningEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Link download belum dikonfigurasi (STORAGE_SIGNING_KEY)"})
+		return
+	}
+	link, expiresAt := signedDownloadURL("document", doc.ID, user.ID)
+	c.JSON(http.StatusOK, gin.H{"url": link, "expires_at": expiresAt})
+}
+
+// ======================================================
+// SIGNED DOWNLOAD (TANPA TOKEN)
+// ======================================================
+// Untuk dibuka langsung di browser (tag <a>/<iframe>) yang tidak bisa
+// mengirim header Authorization.
+func ServeSignedDownload(c *gin.Context) {
+	kind := c.Param("kind")
+	id := c.Param("id")
+	userID := c.Query("uid")
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !storage.Verify(kind+":"+id+":"+userID, expires, c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Link tidak valid atau sudah kedaluwarsa"})
+		return
+	}
+
+	// Hak akses dicek ulang: user bisa saja sudah dihapus sejak link dibuat
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "User tidak valid"})
+		return
+	}
+
+	switch kind {
+	// document_staff tetap diterima untuk link yang dibuat sebelum tabel digabung
+	case "document", "document_staff":
+		doc, found := findDocument(id)
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+			return
+		}
+		if !canAccessDocument(user, doc) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+			return
+		}
+		serveDownloadable(c, documentDownloadable(doc))
+	case "attachment":
+		var attachment models.DocumentAttachment
+		if err := config.DB.First(&attachment, "id = ?", id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lampiran tidak ditemukan"})
+			return
+		}
+		// Hak akses mengikuti dokumen induknya
+		doc, found := findDocument(attachment.DocumentID)
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+			return
+		}
+		if !canAccessDocument(user, doc) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+			return
+		}
+		serveDownloadable(c, attachmentDownloadable(attachment))
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+	}
+}
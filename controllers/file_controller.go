@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dinsos_kuburaya/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ======================================================
+// SERVE SIGNED FILE (LOCAL STORAGE)
+// ======================================================
+// Menyajikan file dari local storage lewat link bertanda tangan yang dibuat
+// oleh storage.Local.SignedURL.
+func ServeSignedFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !storage.Verify(key, expires, c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Link tidak valid atau sudah kedaluwarsa"})
+		return
+	}
+
+	backend, err := storage.Get("local")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, obj, err := backend.Open(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File tidak tersedia"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuka file: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	if obj.ContentType != "" {
+		c.Header("Content-Type", obj.ContentType)
+	}
+	http.ServeContent(c.Writer, c.Request, obj.Key, obj.ModTime, file)
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"dinsos_kuburaya/storage"
+)
+
+// Job yang berstatus processing lebih lama dari ini dianggap macet
+// (mis. server restart di tengah proses) dan diulang
+const staleFileJobAfter = time.Hour
+
+// Jumlah dokumen pending yang diambil setiap job terjadwal berjalan
+const fileJobBatchSize = 50
+
+var (
+	// fileJobSlots membatasi jumlah proses berat (OCR, render preview) yang
+	// berjalan bersamaan (OCR_WORKERS, default 2)
+	fileJobSlots     chan struct{}
+	fileJobSlotsOnce sync.Once
+	// fileJobsInFlight mencegah job yang sama untuk dokumen yang sama berjalan dua kali
+	fileJobsInFlight sync.Map
+)
+
+// runFileJob menjalankan job di background dengan batas jumlah proses bersamaan.
+func runFileJob(name, id string, job func()) {
+	key := name + ":" + id
+	if _, running := fileJobsInFlight.LoadOrStore(key, true); running {
+		return
+	}
+
+	fileJobSlotsOnce.Do(func() {
+		workers := 2
+		if v, err := strconv.Atoi(os.Getenv("OCR_WORKERS")); err == nil && v > 0 {
+			workers = v
+		}
+		fileJobSlots = make(chan struct{}, workers)
+	})
+
+	go func() {
+		defer fileJobsInFlight.Delete(key)
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Warning: Recovered from panic saat job %s: %v\n", name, r)
+			}
+		}()
+
+		fileJobSlots <- struct{}{}
+		defer func() { <-fileJobSlots }()
+		job()
+	}()
+}
+
+// queueFileProcessing dipanggil setiap kali file aktif dokumen berganti.
+func queueFileProcessing(id string) {
+	queueTextExtraction(id)
+	queuePreviewGeneration(id)
+}
+
+// resetFileProcessingFields mengosongkan hasil olahan file lama (teks dan preview).
+func resetFileProcessingFields() map[string]interface{} {
+	updates := resetExtractionFields()
+	updates["preview_status"] = "pending"
+	updates["preview_pages"] = 0
+	return updates
+}
+
+// downloadToTemp menyalin file dari storage ke file sementara karena program
+// eksternal (pdftotext, tesseract, pdftoppm) butuh path file.
+func downloadToTemp(file downloadable) (string, string, func(), error) {
+	ext := filepath.Ext(file.Key)
+	if ext == "" {
+		ext = filepath.Ext(file.FileName)
+	}
+
+	backend, err := storage.Get(file.Backend)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	src, _, err := backend.Open(context.Background(), file.Key)
+	if err != nil {
+		return "", "", nil, err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "document-*"+ext)
+	if err != nil {
+		return "", "", nil, err
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		cleanup()
+		return "", "", nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return "", "", nil, err
+	}
+	return tmp.Name(), ext, cleanup, nil
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dinsos_kuburaya/antivirus"
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func isImageExt(ext string) bool {
+	switch ext {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return true
+	}
+	return false
+}
+
+// storageKeyFor membuat key unik di storage dengan folder sesuai jenis file.
+func storageKeyFor(fileName string) string {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	folder := "dinsos_kuburaya/arsip"
+	if isImageExt(ext) {
+		folder = "dinsos_kuburaya/gambar"
+	}
+	return folder + "/" + uuid.NewString() + ext
+}
+
+// requestError adalah error yang sudah punya status HTTP untuk client.
+// Code dan Details ikut dikirim agar frontend bisa menampilkan pesan yang tepat.
+type requestError struct {
+	Status  int
+	Code    string
+	Message string
+	Details map[string]interface{}
+}
+
+func (e *requestError) Error() string { return e.Message }
+
+// respondFileError mengirim response error dari proses pengambilan/penyimpanan file.
+func respondFileError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		body := gin.H{"error": reqErr.Message}
+		if reqErr.Code != "" {
+			body["code"] = reqErr.Code
+		}
+		for k, v := range reqErr.Details {
+			body[k] = v
+		}
+		c.JSON(reqErr.Status, body)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Upload gagal: " + err.Error()})
+}
+
+// incomingFile adalah file yang akan disimpan, baik dari field multipart
+// maupun dari sesi upload bertahap yang sudah selesai (upload_id).
+type incomingFile struct {
+	Name     string
+	Size     int64
+	Uploader models.User
+	open     func() (io.ReadCloser, error)
+	upload   *models.Upload
+}
+
+// incomingFileFromForm mengambil file dari field "file" atau "upload_id".
+// Mengembalikan nil tanpa error jika request tidak membawa file.
+func incomingFileFromForm(c *gin.Context, user models.User) (*incomingFile, error) {
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		return &incomingFile{
+			Name:     fileHeader.Filename,
+			Size:     fileHeader.Size,
+			Uploader: user,
+			open:     func() (io.ReadCloser, error) { return fileHeader.Open() },
+		}, nil
+	}
+
+	uploadID := c.PostForm("upload_id")
+	if uploadID == "" {
+		return nil, nil
+	}
+	return claimUpload(uploadID, user)
+}
+
+// claimUpload mengambil sesi upload bertahap yang sudah selesai milik user.
+func claimUpload(uploadID string, user models.User) (*incomingFile, error) {
+	// Klaim sesi upload secara atomik supaya tidak bisa dipakai dua dokumen
+	res := config.DB.Model(&models.Upload{}).
+		Where("id = ? AND user_id = ? AND status = ?", uploadID, user.ID, "completed").
+		Update("status", "consumed")
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, &requestError{Status: http.StatusBadRequest, Message: "upload_id tidak ditemukan atau upload belum selesai"}
+	}
+
+	var upload models.Upload
+	if err := config.DB.First(&upload, "id = ?", uploadID).Error; err != nil {
+		return nil, err
+	}
+
+	return &incomingFile{
+		Name:     upload.FileName,
+		Size:     upload.Size,
+		Uploader: user,
+		open:     func() (io.ReadCloser, error) { return os.Open(uploadStagingPath(upload.ID)) },
+		upload:   &upload,
+	}, nil
+}
+
+// release membereskan sesi upload setelah file dipindahkan ke storage.
+// Jika penyimpanan gagal, sesi dikembalikan ke status completed agar bisa dicoba lagi.
+func (f *incomingFile) release(stored bool) {
+	if f.upload == nil {
+		return
+	}
+	if stored {
+		os.Remove(uploadStagingPath(f.upload.ID))
+		uploadLocks.Delete(f.upload.ID)
+		return
+	}
+	config.DB.Model(f.upload).Update("status", "completed")
+}
+
+// storeFile memvalidasi file dengan uploadPolicy, memindai virus jika
+// antivirus aktif, lalu men-stream-nya ke storage backend aktif.
+func storeFile(ctx context.Context, file *incomingFile) (storage.Object, error) {
+	src, err := file.open()
+	if err != nil {
+		file.release(false)
+		return storage.Object{}, fmt.Errorf("gagal membuka file: %v", err)
+	}
+
+	reader, contentType, err := validateUpload(file.Name, file.Size, src)
+	if err != nil {
+		src.Close()
+		file.release(false)
+		return storage.Object{}, err
+	}
+
+	// Pemindaian membaca seluruh isi file, jadi file dibuka ulang untuk disimpan
+	if scanner := antivirus.Active(); scanner != nil {
+		err := scanIncomingFile(ctx, scanner, file, reader)
+		src.Close()
+		if err != nil {
+			return storage.Object{}, err
+		}
+		if src, err = file.open(); err != nil {
+			file.release(false)
+			return storage.Object{}, fmt.Errorf("gagal membuka file: %v", err)
+		}
+		reader = src
+	}
+	defer src.Close()
+
+	obj, err := storage.Default().Put(ctx, storageKeyFor(file.Name), reader, file.Size, contentType)
+	file.release(err == nil)
+	return obj, err
+}
+
+// deleteStoredFile menghapus file dari backend tempat file itu disimpan.
+// Kegagalan hanya dicatat karena record database tetap harus diproses.
+func deleteStoredFile(backendName, key string) {
+	if key == "" {
+		return
+	}
+	backend, err := storage.Get(backendName)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	if err := backend.Delete(context.Background(), key); err != nil {
+		fmt.Printf("Warning: gagal menghapus file %s: %v\n", key, err)
+	}
+}
@@ -0,0 +1,384 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batas kedalaman folder agar struktur tetap mudah dinavigasi
+const maxFolderDepth = 10
+
+// folderNode adalah satu folder di response pohon folder.
+type folderNode struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	ParentID      *string       `json:"parent_id"`
+	DocumentCount int64         `json:"document_count"` // dokumen langsung di folder ini
+	TotalCount    int64         `json:"total_count"`    // termasuk semua sub folder
+	Children      []*folderNode `json:"children"`
+}
+
+// folderChildren memetakan parent_id ke id folder anaknya ("" untuk tingkat teratas).
+func folderChildren(folders []models.Folder) map[string][]string {
+	children := map[string][]string{}
+	for _, f := range folders {
+		parent := ""
+		if f.ParentID != nil {
+			parent = *f.ParentID
+		}
+		children[parent] = append(children[parent], f.ID)
+	}
+	return children
+}
+
+// folderSubtree mengembalikan id folder beserta semua turunannya.
+func folderSubtree(id string) []string {
+	var folders []models.Folder
+	config.DB.Select("id", "parent_id").Find(&folders)
+	children := folderChildren(folders)
+
+	ids := []string{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids
+}
+
+// folderHeight menghitung jumlah tingkat folder id beserta sub foldernya
+// (1 untuk folder tanpa sub folder).
+func folderHeight(children map[string][]string, id string) int {
+	height := 0
+	for level := []string{id}; len(level) > 0 && height <= maxFolderDepth; height++ {
+		var next []string
+		for _, f := range level {
+			next = append(next, children[f]...)
+		}
+		level = next
+	}
+	return height
+}
+
+// folderDepth menghitung kedalaman folder (1 untuk folder teratas).
+func folderDepth(parentID *string) int {
+	depth := 1
+	for parentID != nil && depth <= maxFolderDepth {
+		var parent models.Folder
+		if err := config.DB.Select("id", "parent_id").First(&parent, "id = ?", *parentID).Error; err != nil {
+			break
+		}
+		depth++
+		parentID = parent.ParentID
+	}
+	return depth
+}
+
+// folderNameTaken: nama folder harus unik di antara folder yang satu induk.
+func folderNameTaken(name string, parentID *string, exceptID string) bool {
+	query := config.DB.Model(&models.Folder{}).Where("name = ? AND id <> ?", name, exceptID)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	var count int64
+	query.Count(&count)
+	return count > 0
+}
+
+// parseFolderParent memvalidasi parent_id dari input; string kosong berarti tingkat teratas.
+func parseFolderParent(parentID *string) (*string, error) {
+	if parentID == nil || strings.TrimSpace(*parentID) == "" {
+		return nil, nil
+	}
+	id := strings.TrimSpace(*parentID)
+	var parent models.Folder
+	if err := config.DB.First(&parent, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("folder induk tidak ditemukan")
+	}
+	return &id, nil
+}
+
+// ======================================================
+// GET FOLDERS
+// ======================================================
+// Pohon folder beserta jumlah dokumen yang boleh dilihat user.
+func GetFolders(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var folders []models.Folder
+	if err := config.DB.Find(&folders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil folder: " + err.Error()})
+		return
+	}
+
+	query := visibleDocuments(config.DB.Model(&models.Document{}), user).Where("documents.folder_id IS NOT NULL")
+	if origin := c.Query("origin"); origin == "admin" || origin == "staff" {
+		query = query.Where("documents.origin = ?", origin)
+	}
+	var counts []struct {
+		FolderID string
+		Total    int64
+	}
+	if err := query.Select("documents.folder_id, COUNT(*) AS total").Group("documents.folder_id").Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghitung dokumen per folder: " + err.Error()})
+		return
+	}
+	direct := map[string]int64{}
+	for _, fc := range counts {
+		direct[fc.FolderID] = fc.Total
+	}
+
+	nodes := map[string]*folderNode{}
+	for _, f := range folders {
+		nodes[f.ID] = &folderNode{ID: f.ID, Name: f.Name, ParentID: f.ParentID, DocumentCount: direct[f.ID], Children: []*folderNode{}}
+	}
+	roots := []*folderNode{}
+	for _, f := range folders {
+		node := nodes[f.ID]
+		if f.ParentID != nil && nodes[*f.ParentID] != nil {
+			parent := nodes[*f.ParentID]
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	var finish func(list []*folderNode) int64
+	finish = func(list []*folderNode) int64 {
+		sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+		var sum int64
+		for _, n := range list {
+			n.TotalCount = n.DocumentCount + finish(n.Children)
+			sum += n.TotalCount
+		}
+		return sum
+	}
+	finish(roots)
+
+	// Dokumen yang belum dimasukkan ke folder, untuk item "Tanpa folder" di sidebar
+	unfiled := visibleDocuments(config.DB.Model(&models.Document{}), user).Where("documents.folder_id IS NULL")
+	if origin := c.Query("origin"); origin == "admin" || origin == "staff" {
+		unfiled = unfiled.Where("documents.origin = ?", origin)
+	}
+	var unfiledCount int64
+	unfiled.Count(&unfiledCount)
+
+	c.JSON(http.StatusOK, gin.H{"data": roots, "unfiled_count": unfiledCount})
+}
+
+// ======================================================
+// CREATE FOLDER
+// ======================================================
+// Semua user boleh membuat folder; ubah, pindah dan hapus hanya admin.
+func CreateFolder(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		Name     string  `json:"name" binding:"required"`
+		ParentID *string `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len([]rune(input.Name)) > 150 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nama folder wajib diisi, maksimal 150 karakter"})
+		return
+	}
+	parentID, err := parseFolderParent(input.ParentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if folderDepth(parentID) > maxFolderDepth {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Folder maksimal %d tingkat", maxFolderDepth)})
+		return
+	}
+	if folderNameTaken(input.Name, parentID, "") {
+		c.JSON(http.StatusConflict, gin.H{"error": "Folder " + input.Name + " sudah ada di lokasi ini"})
+		return
+	}
+
+	folder := models.Folder{Name: input.Name, ParentID: parentID, CreatedByID: &user.ID}
+	if err := config.DB.Create(&folder).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan folder: " + err.Error()})
+		return
+	}
+	CreateActivityLog(user.ID, user.Name, "CREATE_FOLDER", "Membuat folder: "+folder.Name)
+	c.JSON(http.StatusCreated, gin.H{"message": "Folder berhasil dibuat", "folder": folder})
+}
+
+// ======================================================
+// UPDATE FOLDER (ADMIN)
+// ======================================================
+// Body: name dan/atau parent_id (string kosong = pindah ke tingkat teratas).
+func UpdateFolder(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var folder models.Folder
+	if err := config.DB.First(&folder, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Folder tidak ditemukan"})
+		return
+	}
+
+	var input struct {
+		Name     *string `json:"name"`
+		ParentID *string `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	name := folder.Name
+	if input.Name != nil {
+		name = strings.TrimSpace(*input.Name)
+		if name == "" || len([]rune(name)) > 150 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nama folder wajib diisi, maksimal 150 karakter"})
+			return
+		}
+	}
+	parentID := folder.ParentID
+	if input.ParentID != nil {
+		var err error
+		if parentID, err = parseFolderParent(input.ParentID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Folder tidak boleh dipindah ke dalam dirinya sendiri atau turunannya
+		if parentID != nil {
+			for _, id := range folderSubtree(folder.ID) {
+				if id == *parentID {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Folder tidak bisa dipindah ke dalam sub foldernya sendiri"})
+					return
+				}
+			}
+		}
+		// Seluruh sub folder ikut pindah, jadi tingginya ikut dihitung
+		var folders []models.Folder
+		config.DB.Select("id", "parent_id").Find(&folders)
+		if folderDepth(parentID)+folderHeight(folderChildren(folders), folder.ID)-1 > maxFolderDepth {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Folder maksimal %d tingkat", maxFolderDepth)})
+			return
+		}
+	}
+	if folderNameTaken(name, parentID, folder.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Folder " + name + " sudah ada di lokasi ini"})
+		return
+	}
+
+	if err := config.DB.Model(&folder).Updates(map[string]interface{}{"name": name, "parent_id": parentID}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan folder: " + err.Error()})
+		return
+	}
+	folder.Name, folder.ParentID = name, parentID
+
+	CreateActivityLog(user.ID, user.Name, "UPDATE_FOLDER", "Mengubah folder: "+folder.Name)
+	c.JSON(http.StatusOK, gin.H{"message": "Folder berhasil diperbarui", "folder": folder})
+}
+
+// ======================================================
+// DELETE FOLDER (ADMIN)
+// ======================================================
+// Hanya folder kosong (tanpa sub folder dan dokumen, termasuk di recycle bin) yang bisa dihapus.
+func DeleteFolder(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var folder models.Folder
+	if err := config.DB.First(&folder, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Folder tidak ditemukan"})
+		return
+	}
+
+	var subfolders, documents int64
+	config.DB.Model(&models.Folder{}).Where("parent_id = ?", folder.ID).Count(&subfolders)
+	config.DB.Unscoped().Model(&models.Document{}).Where("folder_id = ?", folder.ID).Count(&documents)
+	if subfolders > 0 || documents > 0 {
+		respondFileError(c, &requestError{
+			Status:  http.StatusConflict,
+			Code:    "folder_not_empty",
+			Message: "Folder masih berisi sub folder atau dokumen",
+			Details: map[string]interface{}{"subfolders": subfolders, "documents": documents},
+		})
+		return
+	}
+
+	if err := config.DB.Delete(&folder).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus folder: " + err.Error()})
+		return
+	}
+	CreateActivityLog(user.ID, user.Name, "DELETE_FOLDER", "Menghapus folder: "+folder.Name)
+	c.JSON(http.StatusOK, gin.H{"message": "Folder berhasil dihapus"})
+}
+
+// ======================================================
+// MOVE DOCUMENT TO FOLDER
+// ======================================================
+// Body: folder_id (null atau string kosong = keluarkan dari folder)
+func SetDocumentFolder(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		FolderID *string `json:"folder_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+
+	folderID, err := parseFolderParent(input.FolderID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Folder tidak ditemukan"})
+		return
+	}
+	if err := config.DB.Model(&doc).UpdateColumn("folder_id", folderID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memindahkan dokumen: " + err.Error()})
+		return
+	}
+
+	LogActivity(user.ID, user.Name, "MOVE_DOCUMENT", "Memindahkan dokumen ke folder: "+doc.Subject)
+	c.JSON(http.StatusOK, gin.H{"message": "Dokumen dipindahkan", "document_id": doc.ID, "folder_id": folderID})
+}
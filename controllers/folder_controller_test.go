@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"dinsos_kuburaya/models"
+)
+
+func folder(id, parent string) models.Folder {
+	f := models.Folder{ID: id}
+	if parent != "" {
+		f.ParentID = &parent
+	}
+	return f
+}
+
+func TestFolderHeight(t *testing.T) {
+	children := folderChildren([]models.Folder{
+		folder("arsip", ""),
+		folder("2024", "arsip"),
+		folder("masuk", "2024"),
+		folder("keluar", "2024"),
+		folder("januari", "masuk"),
+		folder("2025", "arsip"),
+		folder("lain", ""),
+	})
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{"arsip", 4},
+		{"2024", 3},
+		{"masuk", 2},
+		{"keluar", 1},
+		{"lain", 1},
+		{"tidak-ada", 1},
+	}
+	for _, tt := range tests {
+		if got := folderHeight(children, tt.id); got != tt.want {
+			t.Errorf("folderHeight(%s) = %d, want %d", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestFolderHeightIsBounded(t *testing.T) {
+	// Rantai yang lebih dalam dari batas berhenti dihitung setelah maxFolderDepth+1
+	var folders []models.Folder
+	parent := ""
+	for i := 0; i < maxFolderDepth+5; i++ {
+		id := fmt.Sprintf("f%d", i)
+		folders = append(folders, folder(id, parent))
+		parent = id
+	}
+	if got := folderHeight(folderChildren(folders), "f0"); got != maxFolderDepth+1 {
+		t.Fatalf("folderHeight = %d, want %d", got, maxFolderDepth+1)
+	}
+}
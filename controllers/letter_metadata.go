@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var letterUrgencies = map[string]bool{"biasa": true, "segera": true, "sangat_segera": true}
+
+var letterConfidentialities = map[string]bool{"biasa": true, "terbatas": true, "rahasia": true, "sangat_rahasia": true}
+
+// Kode klasifikasi arsip: tiga digit, boleh diikuti sub kode (460, 400.7.1)
+var classificationCodePattern = regexp.MustCompile(`^[0-9]{3}(\.[0-9]+)*$`)
+
+// Batas wajar jumlah halaman/lampiran untuk menolak salah ketik
+const maxLetterPageCount = 10000
+
+// normalizeLetterOption menerima "Sangat Segera", "sangat-segera" dan "sangat_segera".
+func normalizeLetterOption(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(v)
+}
+
+// parseLetterMetadata membaca field agenda surat dari form dan menimpakannya ke
+// current. Hanya field yang dikirim yang diubah; field yang dikirim kosong
+// dikosongkan. updates berisi kolom yang berubah untuk dipakai di Updates().
+func parseLetterMetadata(c *gin.Context, current models.LetterMetadata) (models.LetterMetadata, map[string]interface{}, error) {
+	meta := current
+	updates := map[string]interface{}{}
+	invalid := func(field, message string) error {
+		return &requestError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_metadata",
+			Message: message,
+			Details: map[string]interface{}{"field": field},
+		}
+	}
+
+	if v, ok := c.GetPostForm("sender_letter_number"); ok {
+		v = strings.TrimSpace(v)
+		if len(v) > 100 {
+			return meta, nil, invalid("sender_letter_number", "Nomor surat maksimal 100 karakter")
+		}
+		meta.SenderLetterNumber = v
+		updates["sender_letter_number"] = v
+	}
+
+	for _, field := range []string{"letter_date", "received_date"} {
+		v, ok := c.GetPostForm(field)
+		if !ok {
+			continue
+		}
+		var date *time.Time
+		if v = strings.TrimSpace(v); v != "" {
+			t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+			if err != nil {
+				return meta, nil, invalid(field, field+" harus berformat YYYY-MM-DD")
+			}
+			date = &t
+		}
+		if field == "letter_date" {
+			meta.LetterDate = date
+		} else {
+			meta.ReceivedDate = date
+		}
+		updates[field] = date
+	}
+	if meta.ReceivedDate != nil && meta.ReceivedDate.After(time.Now()) {
+		return meta, nil, invalid("received_date", "Tanggal diterima tidak boleh di masa depan")
+	}
+	if meta.LetterDate != nil && meta.ReceivedDate != nil && meta.ReceivedDate.Before(*meta.LetterDate) {
+		return meta, nil, invalid("received_date", "Tanggal diterima tidak boleh sebelum tanggal surat")
+	}
+
+	if v, ok := c.GetPostForm("reply_required_by"); ok {
+		var date *time.Time
+		if v = strings.TrimSpace(v); v != "" {
+			t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+			if err != nil {
+				return meta, nil, invalid("reply_required_by", "Batas waktu balasan harus berformat YYYY-MM-DD")
+			}
+			date = &t
+		}
+		meta.ReplyRequiredBy = date
+		updates["reply_required_by"] = date
+	}
+
+	if v, ok := c.GetPostForm("classification_code"); ok {
+		v = strings.TrimSpace(v)
+		if v != "" && !classificationCodePattern.MatchString(v) {
+			return meta, nil, invalid("classification_code", "Kode klasifikasi tidak valid (contoh: 460 atau 400.7.1)")
+		}
+		meta.ClassificationCode = v
+		updates["classification_code"] = v
+	}
+
+	if v, ok := c.GetPostForm("urgency"); ok {
+		v = normalizeLetterOption(v)
+		if v == "" {
+			v = "biasa"
+		}
+		if !letterUrgencies[v] {
+			return meta, nil, invalid("urgency", "Sifat surat harus biasa, segera atau sangat_segera")
+		}
+		meta.Urgency = v
+		updates["urgency"] = v
+	}
+
+	if v, ok := c.GetPostForm("confidentiality"); ok {
+		v = normalizeLetterOption(v)
+		if v == "" {
+			v = "biasa"
+		}
+		if !letterConfidentialities[v] {
+			return meta, nil, invalid("confidentiality", "Tingkat kerahasiaan harus biasa, terbatas, rahasia atau sangat_rahasia")
+		}
+		meta.Confidentiality = v
+		updates["confidentiality"] = v
+	}
+
+	for _, field := range []string{"page_count", "attachment_count"} {
+		v, ok := c.GetPostForm(field)
+		if !ok {
+			continue
+		}
+		n := 0
+		if v = strings.TrimSpace(v); v != "" {
+			var err error
+			n, err = strconv.Atoi(v)
+			if err != nil || n < 0 || n > maxLetterPageCount {
+				return meta, nil, invalid(field, field+" harus berupa angka 0 sampai "+strconv.Itoa(maxLetterPageCount))
+			}
+		}
+		if field == "page_count" {
+			meta.PageCount = n
+		} else {
+			meta.AttachmentCount = n
+		}
+		updates[field] = n
+	}
+
+	return meta, updates, nil
+}
@@ -0,0 +1,477 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Template bawaan jika admin belum mengatur format untuk jenis surat tersebut
+var defaultLetterNumberTemplates = map[string]string{
+	"masuk":  "{seq:3}/AGENDA/DINSOS/{roman_month}/{year}",
+	"keluar": "{seq:3}/{klasifikasi}/DINSOS/{roman_month}/{year}",
+}
+
+const fallbackLetterNumberTemplate = "{seq:3}/{klasifikasi}/DINSOS/{roman_month}/{year}"
+
+// Kode klasifikasi 000 (Umum) dipakai jika dokumen tidak menyebutkan klasifikasi
+const defaultClassificationCode = "000"
+
+// Batas lebar {seq:N} agar nomor tetap wajar dan muat di kolom nomor register
+const maxSeqWidth = 10
+
+// {nama} atau {nama:lebar}, lebar hanya berlaku untuk {seq} (diisi nol di depan)
+var letterNumberPlaceholder = regexp.MustCompile(`\{([a-z_]+)(?::(\d+))?\}`)
+
+var letterNumberPlaceholders = map[string]bool{
+	"seq":         true,
+	"klasifikasi": true,
+	"month":       true,
+	"roman_month": true,
+	"year":        true,
+	"letter_type": true,
+}
+
+var romanMonths = []string{"I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X", "XI", "XII"}
+
+// validateLetterNumberTemplate memastikan template memuat {seq} dan hanya
+// memakai placeholder yang dikenal.
+func validateLetterNumberTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return errors.New("template tidak boleh kosong")
+	}
+	hasSeq := false
+	for _, m := range letterNumberPlaceholder.FindAllStringSubmatch(template, -1) {
+		if !letterNumberPlaceholders[m[1]] {
+			return fmt.Errorf("placeholder {%s} tidak dikenal", m[1])
+		}
+		if m[2] != "" && m[1] != "seq" {
+			return fmt.Errorf("lebar angka hanya bisa dipakai untuk {seq}")
+		}
+		if width, _ := strconv.Atoi(m[2]); m[2] != "" && (width < 1 || width > maxSeqWidth) {
+			return fmt.Errorf("lebar {seq} harus antara 1 dan %d", maxSeqWidth)
+		}
+		if m[1] == "seq" {
+			hasSeq = true
+		}
+	}
+	if !hasSeq {
+		return errors.New("template wajib memuat {seq}")
+	}
+	return nil
+}
+
+// renderLetterNumber mengisi placeholder template.
+func renderLetterNumber(template string, seq int, classification, letterType string, t time.Time) string {
+	if classification == "" {
+		classification = defaultClassificationCode
+	}
+	return letterNumberPlaceholder.ReplaceAllStringFunc(template, func(token string) string {
+		m := letterNumberPlaceholder.FindStringSubmatch(token)
+		switch m[1] {
+		case "seq":
+			width, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%0*d", min(width, maxSeqWidth), seq)
+		case "klasifikasi":
+			return classification
+		case "month":
+			return fmt.Sprintf("%02d", int(t.Month()))
+		case "roman_month":
+			return romanMonths[t.Month()-1]
+		case "year":
+			return strconv.Itoa(t.Year())
+		case "letter_type":
+			return strings.ToUpper(letterType)
+		}
+		return token
+	})
+}
+
+// parseLetterNumberType menormalkan jenis surat yang punya buku register sendiri.
+func parseLetterNumberType(v string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	_, ok := defaultLetterNumberTemplates[v]
+	return v, ok
+}
+
+// letterNumberTemplate mengambil template yang diatur admin atau template bawaan.
+func letterNumberTemplate(tx *gorm.DB, letterType string) (string, bool) {
+	var format models.LetterNumberFormat
+	if err := tx.First(&format, "letter_type = ?", letterType).Error; err == nil {
+		return format.Template, false
+	}
+	if tmpl, ok := defaultLetterNumberTemplates[letterType]; ok {
+		return tmpl, true
+	}
+	return fallbackLetterNumberTemplate, true
+}
+
+// nextLetterNumber mengambil nomor urut berikutnya dan mencatatnya di register.
+// Harus dipanggil di dalam transaksi: baris counter dikunci sampai transaksi
+// selesai, dan jika transaksi gagal nomor ikut batal sehingga tidak ada nomor bolong.
+func nextLetterNumber(tx *gorm.DB, letterType, classification, userID, status, note string) (models.LetterNumber, error) {
+	now := time.Now()
+	year := now.Year()
+
+	// Baris counter dibuat dulu jika belum ada (aman jika dua request bersamaan)
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.LetterNumberCounter{Year: year, LetterType: letterType}).Error; err != nil {
+		return models.LetterNumber{}, err
+	}
+
+	var counter models.LetterNumberCounter
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&counter, "year = ? AND letter_type = ?", year, letterType).Error; err != nil {
+		return models.LetterNumber{}, err
+	}
+
+	counter.LastSeq++
+	if err := tx.Model(&counter).Update("last_seq", counter.LastSeq).Error; err != nil {
+		return models.LetterNumber{}, err
+	}
+
+	template, _ := letterNumberTemplate(tx, letterType)
+	number := models.LetterNumber{
+		Number:             renderLetterNumber(template, counter.LastSeq, classification, letterType, now),
+		Year:               year,
+		LetterType:         letterType,
+		Seq:                counter.LastSeq,
+		ClassificationCode: classification,
+		Status:             status,
+		Note:               note,
+	}
+	if userID != "" {
+		number.ReservedByID = &userID
+	}
+	if status == "used" {
+		number.UsedAt = &now
+	}
+	err := tx.Create(&number).Error
+	return number, err
+}
+
+// assignLetterNumber memberi nomor register untuk dokumen baru. Jika
+// reservationID diisi, nomor hasil reservasi dipakai; jika tidak, nomor baru diambil.
+// Dokumen tanpa letter_type tidak diberi nomor.
+func assignLetterNumber(tx *gorm.DB, documentID, letterType, classification, reservationID string, user models.User) (string, error) {
+	now := time.Now()
+
+	if reservationID != "" {
+		var reserved models.LetterNumber
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&reserved, "id = ? AND status = ?", reservationID, "reserved").Error; err != nil {
+			return "", &requestError{Status: http.StatusUnprocessableEntity, Code: "invalid_reservation", Message: "Nomor reservasi tidak ditemukan atau sudah dipakai"}
+		}
+		if user.Role != "admin" && (reserved.ReservedByID == nil || *reserved.ReservedByID != user.ID) {
+			return "", &requestError{Status: http.StatusForbidden, Code: "invalid_reservation", Message: "Nomor reservasi milik user lain"}
+		}
+		if reserved.LetterType != letterType {
+			return "", &requestError{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "invalid_reservation",
+				Message: fmt.Sprintf("Nomor reservasi untuk surat %s, bukan %s", reserved.LetterType, letterType),
+			}
+		}
+
+		err := tx.Model(&reserved).Updates(map[string]interface{}{
+			"status":      "used",
+			"document_id": documentID,
+			"used_at":     now,
+		}).Error
+		return reserved.Number, err
+	}
+
+	if letterType == "" {
+		return "", nil
+	}
+
+	number, err := nextLetterNumber(tx, letterType, classification, user.ID, "used", "")
+	if err != nil {
+		return "", err
+	}
+	err = tx.Model(&number).Update("document_id", documentID).Error
+	return number.Number, err
+}
+
+// ======================================================
+// GET LETTER NUMBER FORMATS (ADMIN)
+// ======================================================
+func GetLetterNumberFormats(c *gin.Context) {
+	types := []string{"masuk", "keluar"}
+	var formats []models.LetterNumberFormat
+	config.DB.Find(&formats)
+	for _, f := range formats {
+		if _, ok := defaultLetterNumberTemplates[f.LetterType]; !ok {
+			types = append(types, f.LetterType)
+		}
+	}
+
+	now := time.Now()
+	var response []gin.H
+	for _, letterType := range types {
+		template, isDefault := letterNumberTemplate(config.DB, letterType)
+
+		var counter models.LetterNumberCounter
+		config.DB.Where("year = ? AND letter_type = ?", now.Year(), letterType).First(&counter)
+
+		response = append(response, gin.H{
+			"letter_type": letterType,
+			"template":    template,
+			"is_default":  isDefault,
+			"last_seq":    counter.LastSeq,
+			"next_number": renderLetterNumber(template, counter.LastSeq+1, "", letterType, now),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// ======================================================
+// UPDATE LETTER NUMBER FORMAT (ADMIN)
+// ======================================================
+func UpdateLetterNumberFormat(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+	letterType, ok := parseLetterNumberType(c.Param("letter_type"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "letter_type harus masuk atau keluar"})
+		return
+	}
+
+	var input struct {
+		Template string `json:"template" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if err := validateLetterNumberTemplate(input.Template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Template tidak valid: " + err.Error()})
+		return
+	}
+
+	format := models.LetterNumberFormat{
+		LetterType:  letterType,
+		Template:    strings.TrimSpace(input.Template),
+		UpdatedByID: &user.ID,
+	}
+	if err := config.DB.Save(&format).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan format: " + err.Error()})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "UPDATE_NUMBER_FORMAT",
+		fmt.Sprintf("Mengubah format nomor surat %s menjadi %s", letterType, format.Template))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Format nomor surat berhasil disimpan",
+		"format":  format,
+		"example": renderLetterNumber(format.Template, 1, "", letterType, time.Now()),
+	})
+}
+
+// ======================================================
+// RESERVE LETTER NUMBER
+// ======================================================
+// Reservasi dipakai untuk draft surat keluar yang butuh nomor sebelum dokumennya diupload.
+func ReserveLetterNumber(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		LetterType         string `json:"letter_type" binding:"required"`
+		ClassificationCode string `json:"classification_code"`
+		Note               string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	// Nomor yang dipesan hanya bisa dipakai dokumen surat masuk atau keluar
+	letterType, ok := parseLetterNumberType(input.LetterType)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "letter_type harus masuk atau keluar"})
+		return
+	}
+
+	var number models.LetterNumber
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		number, err = nextLetterNumber(tx, letterType, input.ClassificationCode, user.ID, "reserved", input.Note)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil nomor: " + err.Error()})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "RESERVE_NUMBER", "Memesan nomor surat "+number.Number)
+	c.JSON(http.StatusCreated, gin.H{"message": "Nomor surat berhasil dipesan", "reservation": number})
+}
+
+// ======================================================
+// CANCEL LETTER NUMBER RESERVATION
+// ======================================================
+// Nomor yang dibatalkan tidak dipakai ulang, tetapi tetap tercatat di register.
+func CancelLetterNumberReservation(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		Note string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&input)
+
+	var number models.LetterNumber
+	if err := config.DB.First(&number, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reservasi tidak ditemukan"})
+		return
+	}
+	if user.Role != "admin" && (number.ReservedByID == nil || *number.ReservedByID != user.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": "cancelled", "cancelled_at": now}
+	if input.Note != "" {
+		updates["note"] = input.Note
+	}
+	res := config.DB.Model(&models.LetterNumber{}).
+		Where("id = ? AND status = ?", number.ID, "reserved").
+		Updates(updates)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membatalkan reservasi: " + res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Nomor sudah dipakai atau sudah dibatalkan"})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "CANCEL_NUMBER", "Membatalkan nomor surat "+number.Number)
+	c.JSON(http.StatusOK, gin.H{"message": "Reservasi nomor dibatalkan"})
+}
+
+// ======================================================
+// GET LETTER NUMBER REGISTER
+// ======================================================
+// Admin melihat seluruh buku register, staff hanya nomor yang dia pesan/pakai.
+func GetLetterNumbers(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 50
+	}
+
+	query := config.DB.Model(&models.LetterNumber{})
+	if user.Role != "admin" {
+		query = query.Where("reserved_by_id = ?", user.ID)
+	}
+	if year := c.Query("year"); year != "" {
+		query = query.Where("year = ?", year)
+	}
+	if letterType := c.Query("letter_type"); letterType != "" && letterType != "all" {
+		query = query.Where("letter_type = ?", letterType)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var numbers []models.LetterNumber
+	if err := query.Preload("ReservedBy").
+		Order("year DESC, letter_type ASC, seq DESC").
+		Limit(perPage).Offset((page - 1) * perPage).
+		Find(&numbers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil register: " + err.Error()})
+		return
+	}
+
+	lastPage := int((total + int64(perPage) - 1) / int64(perPage))
+	c.JSON(http.StatusOK, gin.H{
+		"data":         numbers,
+		"total":        total,
+		"current_page": page,
+		"last_page":    lastPage,
+		"per_page":     perPage,
+	})
+}
+
+// ======================================================
+// LOOKUP BY LETTER NUMBER
+// ======================================================
+func LookupLetterNumber(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	number := strings.TrimSpace(c.Query("number"))
+	if number == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter number wajib diisi"})
+		return
+	}
+
+	var numbers []models.LetterNumber
+	config.DB.Preload("ReservedBy").Where("number = ?", number).Find(&numbers)
+	if len(numbers) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Nomor surat tidak ditemukan"})
+		return
+	}
+
+	var results []gin.H
+	for _, n := range numbers {
+		item := gin.H{"letter_number": n}
+		// Detail dokumen hanya disertakan jika user boleh melihatnya
+		if n.DocumentID != nil {
+			if doc, found := findDocument(*n.DocumentID); found && canAccessDocument(user, doc) {
+				item["document"] = gin.H{
+					"id":      doc.ID,
+					"source":  documentSource(doc),
+					"subject": doc.Subject,
+				}
+			}
+		}
+		results = append(results, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderLetterNumber(t *testing.T) {
+	date := time.Date(2025, time.March, 14, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		name           string
+		template       string
+		seq            int
+		classification string
+		letterType     string
+		want           string
+	}{
+		{"template keluar", defaultLetterNumberTemplates["keluar"], 7, "460", "keluar", "007/460/DINSOS/III/2025"},
+		{"template masuk", defaultLetterNumberTemplates["masuk"], 12, "", "masuk", "012/AGENDA/DINSOS/III/2025"},
+		{"klasifikasi kosong", "{seq}/{klasifikasi}", 1, "", "keluar", "1/000"},
+		{"seq lebih panjang dari lebar", "{seq:2}", 1234, "", "keluar", "1234"},
+		{"lebar tersimpan dibatasi", "{seq:99}", 7, "", "keluar", "0000000007"},
+		{"bulan angka dan jenis surat", "{letter_type}-{seq:4}/{month}.{year}", 5, "", "keluar", "KELUAR-0005/03.2025"},
+		{"placeholder tidak dikenal dibiarkan", "{seq}/{unknown}", 3, "", "masuk", "3/{unknown}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderLetterNumber(tt.template, tt.seq, tt.classification, tt.letterType, date)
+			if got != tt.want {
+				t.Errorf("renderLetterNumber = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderLetterNumberRomanMonths(t *testing.T) {
+	want := []string{"I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X", "XI", "XII"}
+	for i, roman := range want {
+		date := time.Date(2025, time.Month(i+1), 1, 0, 0, 0, 0, time.Local)
+		if got := renderLetterNumber("{roman_month}", 1, "", "", date); got != roman {
+			t.Errorf("bulan %d = %q, want %q", i+1, got, roman)
+		}
+	}
+}
+
+func TestValidateLetterNumberTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{"{seq:3}/{klasifikasi}/DINSOS/{roman_month}/{year}", false},
+		{"{letter_type}/{seq}/{month}", false},
+		{"", true},
+		{"   ", true},
+		{"{klasifikasi}/{year}", true}, // tanpa {seq}
+		{"{seq}/{tanggal}", true},      // placeholder tidak dikenal
+		{"{seq}/{year:4}", true},       // lebar hanya untuk {seq}
+		{"NOMOR-{seq:5}-DINSOS", false},
+		{"{seq:10}", false},
+		{"{seq:0}", true},
+		{"{seq:11}", true},
+		{"{seq:99999999999999999999}", true},
+	}
+	for _, tt := range tests {
+		err := validateLetterNumberTemplate(tt.template)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateLetterNumberTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseLetterNumberType(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"masuk", "masuk", true},
+		{" Keluar ", "keluar", true},
+		{"nota_dinas", "nota_dinas", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLetterNumberType(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseLetterNumberType(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Status alur surat keluar
+const (
+	workflowDraft             = "draft"
+	workflowSubmitted         = "submitted"
+	workflowRevisionRequested = "revision_requested"
+	workflowApproved          = "approved"
+	workflowNumbered          = "numbered"
+	workflowSent              = "sent"
+)
+
+var letterWorkflowStatuses = map[string]bool{
+	workflowDraft: true, workflowSubmitted: true, workflowRevisionRequested: true,
+	workflowApproved: true, workflowNumbered: true, workflowSent: true,
+}
+
+// letterTransition adalah satu aksi pada alur surat keluar.
+type letterTransition struct {
+	From         []string
+	To           string
+	Label        string // untuk log aktivitas dan notifikasi
+	Approver     bool   // hanya admin atau pemeriksa yang ditunjuk
+	NoteRequired bool
+}
+
+// Aksi yang tersedia, dipanggil lewat POST /:id/workflow/:action
+var letterTransitions = map[string]letterTransition{
+	"submit": {
+		From:  []string{workflowDraft, workflowRevisionRequested},
+		To:    workflowSubmitted,
+		Label: "mengajukan",
+	},
+	"request-revision": {
+		From:         []string{workflowSubmitted},
+		To:           workflowRevisionRequested,
+		Label:        "meminta revisi",
+		Approver:     true,
+		NoteRequired: true,
+	},
+	"approve": {
+		From:     []string{workflowSubmitted},
+		To:       workflowApproved,
+		Label:    "menyetujui",
+		Approver: true,
+	},
+	"number": {
+		From:     []string{workflowApproved},
+		To:       workflowNumbered,
+		Label:    "memberi nomor",
+		Approver: true,
+	},
+	"send": {
+		From:  []string{workflowNumbered},
+		To:    workflowSent,
+		Label: "mengirim",
+	},
+}
+
+// canApproveLetters: admin dan staff yang ditunjuk sebagai pemeriksa surat keluar.
+func canApproveLetters(user models.User) bool {
+	return user.Role == "admin" || user.CanApproveLetters
+}
+
+// inLetterReview: surat sudah diajukan sehingga pemeriksa boleh membukanya.
+func inLetterReview(doc models.Document) bool {
+	return doc.WorkflowStatus != "" && doc.WorkflowStatus != workflowDraft
+}
+
+// ensureDocumentEditable menolak perubahan isi surat keluar yang sedang
+// diperiksa atau sudah disetujui. Surat yang sudah disetujui tidak bisa diubah
+// atau dihapus oleh siapa pun, termasuk admin.
+func ensureDocumentEditable(doc models.Document) error {
+	switch doc.WorkflowStatus {
+	case workflowSubmitted:
+		return &requestError{
+			Status:  http.StatusConflict,
+			Code:    "document_locked",
+			Message: "Surat sedang diperiksa, tunggu hasil pemeriksaan sebelum mengubahnya",
+			Details: map[string]interface{}{"workflow_status": doc.WorkflowStatus},
+		}
+	case workflowApproved, workflowNumbered, workflowSent:
+		return &requestError{
+			Status:  http.StatusConflict,
+			Code:    "document_locked",
+			Message: "Surat sudah disetujui dan tidak dapat diubah",
+			Details: map[string]interface{}{"workflow_status": doc.WorkflowStatus},
+		}
+	}
+	return nil
+}
+
+// allowedLetterActions mengembalikan aksi yang boleh dilakukan user pada status sekarang.
+func allowedLetterActions(user models.User, doc models.Document) []string {
+	actions := []string{}
+	for _, name := range []string{"submit", "request-revision", "approve", "number", "send"} {
+		if letterActionError(user, doc, name, letterTransitions[name]) == nil {
+			actions = append(actions, name)
+		}
+	}
+	return actions
+}
+
+// letterActionError memeriksa status asal dan hak user untuk sebuah aksi.
+func letterActionError(user models.User, doc models.Document, action string, t letterTransition) error {
+	allowedFrom := false
+	for _, from := range t.From {
+		if doc.WorkflowStatus == from {
+			allowedFrom = true
+		}
+	}
+	if !allowedFrom {
+		return &requestError{
+			Status:  http.StatusConflict,
+			Code:    "invalid_transition",
+			Message: fmt.Sprintf("Aksi %s tidak bisa dilakukan pada surat berstatus %s", action, doc.WorkflowStatus),
+			Details: map[string]interface{}{"workflow_status": doc.WorkflowStatus, "allowed_from": t.From},
+		}
+	}
+
+	if t.Approver {
+		if !canApproveLetters(user) {
+			return &requestError{Status: http.StatusForbidden, Code: "forbidden", Message: "Hanya admin atau pemeriksa surat yang dapat melakukan aksi ini"}
+		}
+		// Pemeriksa bukan admin tidak memeriksa surat buatannya sendiri
+		if user.Role != "admin" && isDocumentOwner(user, doc) && action != "number" {
+			return &requestError{Status: http.StatusForbidden, Code: "forbidden", Message: "Surat tidak dapat diperiksa oleh pembuatnya sendiri"}
+		}
+		return nil
+	}
+	if !canEditDocument(user, doc) {
+		return &requestError{Status: http.StatusForbidden, Code: "forbidden", Message: "Hanya pembuat surat atau admin yang dapat melakukan aksi ini"}
+	}
+	return nil
+}
+
+// recordLetterTransition menyimpan log perpindahan status di dalam transaksi.
+func recordLetterTransition(tx *gorm.DB, doc models.Document, action, from, to, note string, actorID string) error {
+	entry := models.LetterWorkflowLog{
+		DocumentID: doc.ID,
+		Action:     action,
+		FromStatus: from,
+		ToStatus:   to,
+		Note:       note,
+	}
+	if actorID != "" {
+		entry.ActorID = &actorID
+	}
+	return tx.Create(&entry).Error
+}
+
+// letterParticipants adalah pembuat surat dan semua user yang pernah mengubah
+// status atau berkomentar, tanpa actor. Saat surat diajukan, semua pemeriksa ikut.
+func letterParticipants(doc models.Document, actorID string, includeApprovers bool) []string {
+	seen := map[string]bool{actorID: true}
+	var ids []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	add(documentOwnerID(doc))
+
+	var involved []string
+	config.DB.Model(&models.LetterWorkflowLog{}).
+		Where("document_id = ? AND actor_id IS NOT NULL", doc.ID).
+		Distinct().Pluck("actor_id", &involved)
+	var commenters []string
+	config.DB.Model(&models.LetterComment{}).
+		Where("document_id = ? AND user_id IS NOT NULL", doc.ID).
+		Distinct().Pluck("user_id", &commenters)
+	for _, id := range append(involved, commenters...) {
+		add(id)
+	}
+
+	if includeApprovers {
+		var approvers []string
+		config.DB.Model(&models.User{}).
+			Where("role = ? OR can_approve_letters = ?", "admin", true).
+			Pluck("id", &approvers)
+		for _, id := range approvers {
+			add(id)
+		}
+	}
+	return ids
+}
+
+func letterLink(doc models.Document) string {
+	return fmt.Sprintf("/dashboard/my-document/%s", doc.ID)
+}
+
+// ======================================================
+// GET LETTER WORKFLOW
+// ======================================================
+func GetLetterWorkflow(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+	if doc.WorkflowStatus == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen ini tidak memakai alur persetujuan"})
+		return
+	}
+
+	var logs []models.LetterWorkflowLog
+	config.DB.Preload("Actor").Where("document_id = ?", doc.ID).Order("created_at ASC").Find(&logs)
+
+	var comments []models.LetterComment
+	config.DB.Preload("User").Where("document_id = ?", doc.ID).Order("created_at ASC").Find(&comments)
+
+	c.JSON(http.StatusOK, gin.H{
+		"document_id":     doc.ID,
+		"workflow_status": doc.WorkflowStatus,
+		"register_number": doc.RegisterNumber,
+		"actions":         allowedLetterActions(user, doc),
+		"history":         logs,
+		"comments":        comments,
+	})
+}
+
+// ======================================================
+// TRANSITION LETTER WORKFLOW
+// ======================================================
+// Body (JSON, opsional): note, reservation_id (hanya untuk aksi number)
+func TransitionLetterWorkflow(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	action := c.Param("action")
+	transition, ok := letterTransitions[action]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Aksi tidak dikenal: " + action})
+		return
+	}
+
+	var input struct {
+		Note          string `json:"note"`
+		ReservationID string `json:"reservation_id"`
+	}
+	_ = c.ShouldBindJSON(&input)
+	input.Note = strings.TrimSpace(input.Note)
+	if transition.NoteRequired && input.Note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Catatan wajib diisi untuk aksi ini"})
+		return
+	}
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+	if doc.WorkflowStatus == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen ini tidak memakai alur persetujuan"})
+		return
+	}
+
+	from := ""
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Baris dokumen dikunci agar dua pemeriksa tidak memproses surat bersamaan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&doc, "id = ?", doc.ID).Error; err != nil {
+			return err
+		}
+		if err := letterActionError(user, doc, action, transition); err != nil {
+			return err
+		}
+		from = doc.WorkflowStatus
+
+		updates := map[string]interface{}{"workflow_status": transition.To}
+		if action == "number" {
+			number, err := assignLetterNumber(tx, doc.ID, doc.LetterType, doc.ClassificationCode, input.ReservationID, user)
+			if err != nil {
+				return err
+			}
+			updates["register_number"] = number
+			doc.RegisterNumber = number
+		}
+		if err := tx.Model(&doc).Updates(updates).Error; err != nil {
+			return err
+		}
+		doc.WorkflowStatus = transition.To
+
+		return recordLetterTransition(tx, doc, action, from, transition.To, input.Note, user.ID)
+	})
+	if err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			respondFileError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui status surat: " + err.Error()})
+		return
+	}
+
+	message := fmt.Sprintf("%s %s surat: %s", user.Name, transition.Label, doc.Subject)
+	if doc.RegisterNumber != "" && action == "number" {
+		message += " (" + doc.RegisterNumber + ")"
+	}
+	if input.Note != "" {
+		message += ". Catatan: " + input.Note
+	}
+	CreateActivityLog(user.ID, user.Name, "LETTER_"+strings.ToUpper(strings.ReplaceAll(action, "-", "_")), message)
+
+	recipients := letterParticipants(doc, user.ID, action == "submit")
+	go func() {
+		for _, id := range recipients {
+			_ = CreateNotification(id, message, letterLink(doc))
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Status surat diperbarui",
+		"document_id":     doc.ID,
+		"from_status":     from,
+		"workflow_status": doc.WorkflowStatus,
+		"register_number": doc.RegisterNumber,
+		"actions":         allowedLetterActions(user, doc),
+	})
+}
+
+// ======================================================
+// ADD LETTER COMMENT
+// ======================================================
+func AddLetterComment(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		Message string `json:"message" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Message) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Komentar wajib diisi"})
+		return
+	}
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+	if doc.WorkflowStatus == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen ini tidak memakai alur persetujuan"})
+		return
+	}
+
+	userID := user.ID
+	comment := models.LetterComment{DocumentID: doc.ID, UserID: &userID, Message: strings.TrimSpace(input.Message)}
+	if err := config.DB.Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan komentar: " + err.Error()})
+		return
+	}
+	comment.User = user
+
+	recipients := letterParticipants(doc, user.ID, false)
+	go func() {
+		msg := fmt.Sprintf("%s mengomentari surat: %s", user.Name, doc.Subject)
+		for _, id := range recipients {
+			_ = CreateNotification(id, msg, letterLink(doc))
+		}
+	}()
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Komentar ditambahkan", "comment": comment})
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"dinsos_kuburaya/antivirus"
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/google/uuid"
+)
+
+func quarantineDir() string {
+	dir := os.Getenv("QUARANTINE_DIR")
+	if dir == "" {
+		dir = "quarantine"
+	}
+	return dir
+}
+
+// scanIncomingFile memindai file sebelum disimpan. File terinfeksi dipindah
+// ke karantina, dicatat di activity log, dan admin diberi notifikasi.
+func scanIncomingFile(ctx context.Context, scanner antivirus.Scanner, file *incomingFile, reader io.Reader) error {
+	result, err := scanner.Scan(ctx, reader)
+	if err != nil {
+		if antivirus.FailOpen() {
+			fmt.Printf("Warning: pemindaian antivirus gagal, file tetap diterima: %v\n", err)
+			return nil
+		}
+		file.release(false)
+		return &requestError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "scan_unavailable",
+			Message: "Pemindaian antivirus tidak tersedia, silakan coba lagi nanti",
+		}
+	}
+
+	if !result.Infected {
+		return nil
+	}
+
+	quarantinePath, qErr := quarantineFile(file)
+	if qErr != nil {
+		fmt.Printf("Warning: gagal mengkarantina file %s: %v\n", file.Name, qErr)
+	}
+	// File di staging tidak boleh dipakai lagi
+	file.release(true)
+
+	uploader := file.Uploader
+	LogActivity(uploader.ID, uploader.Name, "QUARANTINE_FILE", fmt.Sprintf(
+		"File %s terdeteksi virus (%s) dan dikarantina di %s", file.Name, result.Signature, quarantinePath,
+	))
+
+	go func(fileName, signature, uploaderName string) {
+		var admins []models.User
+		if err := config.DB.Where("role = ?", "admin").Find(&admins).Error; err == nil {
+			for _, admin := range admins {
+				msg := fmt.Sprintf("PERINGATAN: Upload %s oleh %s terdeteksi virus (%s) dan dikarantina", fileName, uploaderName, signature)
+				_ = CreateNotification(admin.ID, msg, "/dashboard/activity-logs")
+			}
+		}
+	}(file.Name, result.Signature, uploader.Name)
+
+	return &requestError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "infected_file",
+		Message: "File terdeteksi mengandung virus dan ditolak",
+		Details: map[string]interface{}{"signature": result.Signature},
+	}
+}
+
+// quarantineFile menyalin file ke folder karantina dengan izin baca terbatas.
+func quarantineFile(file *incomingFile) (string, error) {
+	if err := os.MkdirAll(quarantineDir(), 0o700); err != nil {
+		return "", err
+	}
+
+	src, err := file.open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	// Nama asli tidak dipakai sebagai nama file agar tidak bisa dieksekusi/dibuka langsung
+	path := filepath.Join(quarantineDir(), uuid.NewString()+".quarantine")
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, dst.Close()
+}
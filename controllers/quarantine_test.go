@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dinsos_kuburaya/antivirus"
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testEICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// useDryRunDB memasang koneksi DryRun agar activity log dan notifikasi yang
+// dijalankan di background tidak membutuhkan database sungguhan. Koneksi tidak
+// dikembalikan setelah test karena goroutine tersebut bisa selesai belakangan.
+func useDryRunDB(t *testing.T) {
+	t.Helper()
+	if config.DB != nil {
+		return
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:1)/test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.DB = db
+}
+
+func useScanner(t *testing.T, s antivirus.Scanner) {
+	t.Helper()
+	prev := antivirus.Active()
+	antivirus.SetScanner(s)
+	t.Cleanup(func() { antivirus.SetScanner(prev) })
+}
+
+func memoryFile(name string, data []byte) *incomingFile {
+	return &incomingFile{
+		Name:     name,
+		Size:     int64(len(data)),
+		Uploader: models.User{ID: "user-1", Name: "Staff"},
+		open:     func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil },
+	}
+}
+
+type failingScanner struct{}
+
+func (failingScanner) Name() string { return "failing" }
+
+func (failingScanner) Scan(ctx context.Context, r io.Reader) (antivirus.Result, error) {
+	return antivirus.Result{}, errors.New("clamd tidak bisa dihubungi")
+}
+
+func TestStoreFileQuarantinesInfectedFile(t *testing.T) {
+	useDryRunDB(t)
+	useScanner(t, antivirus.Fake{})
+	dir := t.TempDir()
+	t.Setenv("QUARANTINE_DIR", dir)
+
+	data := []byte("%PDF-1.4\n" + testEICAR + "\n%%EOF\n")
+	_, err := storeFile(context.Background(), memoryFile("surat.pdf", data))
+
+	var reqErr *requestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("storeFile error = %v, want requestError", err)
+	}
+	if reqErr.Status != http.StatusUnprocessableEntity || reqErr.Code != "infected_file" {
+		t.Errorf("error = %d %s, want 422 infected_file", reqErr.Status, reqErr.Code)
+	}
+	if reqErr.Details["signature"] != "Eicar-Test-Signature" {
+		t.Errorf("signature = %v", reqErr.Details["signature"])
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.quarantine"))
+	if len(files) != 1 {
+		t.Fatalf("file karantina = %v, want 1 file", files)
+	}
+	got, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("isi file karantina berbeda dengan file upload")
+	}
+	if info, _ := os.Stat(files[0]); info.Mode().Perm() != 0o600 {
+		t.Errorf("izin file karantina = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestStoreFileScanUnavailable(t *testing.T) {
+	useScanner(t, failingScanner{})
+	t.Setenv("ANTIVIRUS_FAIL_OPEN", "")
+	dir := t.TempDir()
+	t.Setenv("QUARANTINE_DIR", dir)
+
+	_, err := storeFile(context.Background(), memoryFile("surat.pdf", []byte("%PDF-1.4\nsurat\n%%EOF\n")))
+
+	var reqErr *requestError
+	if !errors.As(err, &reqErr) || reqErr.Status != http.StatusServiceUnavailable || reqErr.Code != "scan_unavailable" {
+		t.Fatalf("storeFile error = %v, want 503 scan_unavailable", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("file tidak boleh dikarantina: %v", files)
+	}
+}
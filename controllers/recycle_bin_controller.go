@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RecycleBinRetention dibaca dari RECYCLE_BIN_RETENTION_DAYS (default 30 hari).
+func RecycleBinRetention() time.Duration {
+	days := 30
+	if v, err := strconv.Atoi(os.Getenv("RECYCLE_BIN_RETENTION_DAYS")); err == nil && v > 0 {
+		days = v
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// RecycleBinPurgeInterval dibaca dari RECYCLE_BIN_PURGE_INTERVAL_MINUTES (default 60 menit).
+func RecycleBinPurgeInterval() time.Duration {
+	minutes := 60
+	if v, err := strconv.Atoi(os.Getenv("RECYCLE_BIN_PURGE_INTERVAL_MINUTES")); err == nil && v > 0 {
+		minutes = v
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// moveToRecycleBin melakukan soft delete dan mencatat siapa yang menghapus.
+// File di storage dan disposisi tetap ada sampai dokumen di-purge.
+func moveToRecycleBin(model interface{}, userID string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(model).UpdateColumn("deleted_by_id", userID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(model).Error
+	})
+}
+
+// hardDeleteDocument menghapus permanen dokumen di dalam tx. Disposisi, tag dan
+// relasi ikut terhapus lewat ON DELETE CASCADE, versi, preview dan lampiran
+// dihapus bersama filenya setelah transaksi. Riwayat alur dan komentar surat
+// keluar tidak memakai foreign key sehingga dihapus di sini; nomor register dan
+// baris import tetap disimpan sebagai riwayat, hanya rujukannya dilepas.
+func hardDeleteDocument(tx *gorm.DB, doc *models.Document) error {
+	for _, model := range []interface{}{&models.LetterWorkflowLog{}, &models.LetterComment{}} {
+		if err := tx.Where("document_id = ?", doc.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	for _, model := range []interface{}{&models.LetterNumber{}, &models.DocumentImportRow{}} {
+		if err := tx.Model(model).Where("document_id = ?", doc.ID).Update("document_id", nil).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Unscoped().Delete(doc).Error
+}
+
+type recycleBinItem struct {
+	ID            string    `json:"id"`
+	Source        string    `json:"source"`
+	Sender        string    `json:"sender"`
+	Subject       string    `json:"subject"`
+	LetterType    string    `json:"letter_type"`
+	FileName      string    `json:"file_name"`
+	OwnerID       string    `json:"user_id"`
+	DeletedAt     time.Time `json:"deleted_at"`
+	DeletedByID   *string   `json:"deleted_by_id"`
+	DeletedByName string    `json:"deleted_by_name"`
+	PurgeAt       time.Time `json:"purge_at"`
+}
+
+// ======================================================
+// GET RECYCLE BIN (ADMIN)
+// ======================================================
+func GetRecycleBin(c *gin.Context) {
+	retention := RecycleBinRetention()
+	items := []recycleBinItem{}
+
+	query := config.DB.Unscoped().Where("deleted_at IS NOT NULL")
+	// ?source=document atau document_staff dipertahankan untuk klien lama
+	switch c.Query("source") {
+	case "document":
+		query = query.Where("origin = ?", "admin")
+	case "document_staff":
+		query = query.Where("origin = ?", "staff")
+	}
+
+	var docs []models.Document
+	if err := query.Order("deleted_at DESC").Find(&docs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil recycle bin: " + err.Error()})
+		return
+	}
+	for _, d := range docs {
+		items = append(items, recycleBinItem{
+			ID:          d.ID,
+			Source:      documentSource(d),
+			Sender:      d.Sender,
+			Subject:     d.Subject,
+			LetterType:  d.LetterType,
+			FileName:    d.FileName,
+			OwnerID:     documentOwnerID(d),
+			DeletedAt:   d.DeletedAt.Time,
+			DeletedByID: d.DeletedByID,
+			PurgeAt:     d.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	// Nama penghapus diambil sekali untuk semua item
+	userIDs := []string{}
+	for _, item := range items {
+		if item.DeletedByID != nil {
+			userIDs = append(userIDs, *item.DeletedByID)
+		}
+	}
+	names := map[string]string{}
+	if len(userIDs) > 0 {
+		var users []models.User
+		config.DB.Where("id IN ?", userIDs).Find(&users)
+		for _, u := range users {
+			names[u.ID] = u.Name
+		}
+	}
+	for i := range items {
+		items[i].DeletedByName = "-"
+		if items[i].DeletedByID != nil && names[*items[i].DeletedByID] != "" {
+			items[i].DeletedByName = names[*items[i].DeletedByID]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":           items,
+		"total":          len(items),
+		"retention_days": int(retention.Hours() / 24),
+	})
+}
+
+// ======================================================
+// RESTORE FROM RECYCLE BIN (ADMIN)
+// ======================================================
+func RestoreFromRecycleBin(c *gin.Context) {
+	id := c.Param("id")
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	restore := map[string]interface{}{"deleted_at": nil, "deleted_by_id": nil}
+
+	var doc models.Document
+	if err := config.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&doc, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan di recycle bin"})
+		return
+	}
+	if err := config.DB.Unscoped().Model(&doc).UpdateColumns(restore).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulihkan dokumen: " + err.Error()})
+		return
+	}
+	CreateActivityLog(user.ID, user.Name, "RESTORE_DOCUMENT", "Memulihkan dokumen dari recycle bin: "+doc.Subject)
+	c.JSON(http.StatusOK, gin.H{"message": "Dokumen berhasil dipulihkan", "id": doc.ID, "source": documentSource(doc)})
+}
+
+// PurgeRecycleBin menghapus permanen dokumen yang sudah melewati masa retensi,
+// termasuk semua versi file, preview, lampiran dan file tanda tangannya.
+// Dipanggil berkala oleh scheduler. Arsip yang masa simpan JRA-nya belum habis
+// tetap disimpan di recycle bin.
+func PurgeRecycleBin() {
+	now := time.Now()
+	cutoff := now.Add(-RecycleBinRetention())
+
+	var policies []models.RetentionPolicy
+	if err := config.DB.Find(&policies).Error; err != nil {
+		fmt.Printf("Warning: gagal membaca JRA: %v\n", err)
+		return
+	}
+	var docs []models.Document
+	if err := config.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&docs).Error; err != nil {
+		fmt.Printf("Warning: gagal membaca recycle bin: %v\n", err)
+		return
+	}
+	for _, doc := range docs {
+		if !retentionAllowsPurge(doc, policies, now) {
+			continue
+		}
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			return hardDeleteDocument(tx, &doc)
+		})
+		if err != nil {
+			fmt.Printf("Warning: gagal purge dokumen %s: %v\n", doc.ID, err)
+			continue
+		}
+		deleteDocumentVersions(doc.ID, documentDownloadable(doc))
+		deleteDocumentPreviews(doc.ID)
+		deleteDocumentAttachments(doc.ID)
+		deleteDocumentSignatureFiles(doc.ID)
+		LogActivity("", "System", "PURGE_DOCUMENT", "Menghapus permanen dokumen dari recycle bin: "+doc.Subject)
+	}
+}
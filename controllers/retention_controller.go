@@ -0,0 +1,476 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Status retensi dokumen
+const (
+	retentionActive    = "active"
+	retentionInactive  = "inactive"
+	retentionExpired   = "expired"   // masa simpan habis, nasib akhir musnah
+	retentionPermanent = "permanent" // masa simpan habis, nasib akhir permanen
+	retentionProposed  = "proposed"  // masuk usulan pemusnahan
+)
+
+var retentionStatuses = map[string]bool{
+	retentionActive: true, retentionInactive: true, retentionExpired: true,
+	retentionPermanent: true, retentionProposed: true,
+}
+
+var retentionDispositions = map[string]bool{"musnah": true, "permanen": true}
+
+// RetentionCheckInterval dibaca dari RETENTION_CHECK_INTERVAL_HOURS (default 24 jam).
+func RetentionCheckInterval() time.Duration {
+	hours := 24
+	if v, err := strconv.Atoi(os.Getenv("RETENTION_CHECK_INTERVAL_HOURS")); err == nil && v > 0 {
+		hours = v
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// ensureDocumentDeletable menolak penghapusan arsip permanen dan arsip yang
+// sedang diusulkan untuk dimusnahkan. Berlaku juga untuk admin.
+func ensureDocumentDeletable(doc models.Document) error {
+	switch doc.RetentionStatus {
+	case retentionPermanent:
+		return &requestError{
+			Status:  http.StatusConflict,
+			Code:    "permanent_document",
+			Message: "Arsip permanen tidak dapat dihapus",
+			Details: map[string]interface{}{"retention_status": doc.RetentionStatus},
+		}
+	case retentionProposed:
+		return &requestError{
+			Status:  http.StatusConflict,
+			Code:    "disposal_pending",
+			Message: "Arsip sedang diusulkan untuk dimusnahkan, selesaikan usulan pemusnahan terlebih dahulu",
+			Details: map[string]interface{}{"retention_status": doc.RetentionStatus},
+		}
+	}
+	return nil
+}
+
+// matchRetentionPolicy mencari JRA dengan kode klasifikasi paling spesifik
+// yang cocok: kode yang sama persis atau induknya (400.7 untuk 400.7.1).
+func matchRetentionPolicy(policies []models.RetentionPolicy, code string) *models.RetentionPolicy {
+	if code == "" {
+		return nil
+	}
+	var best *models.RetentionPolicy
+	for i := range policies {
+		p := &policies[i]
+		if code != p.ClassificationCode && !strings.HasPrefix(code, p.ClassificationCode+".") {
+			continue
+		}
+		if best == nil || len(p.ClassificationCode) > len(best.ClassificationCode) {
+			best = p
+		}
+	}
+	return best
+}
+
+// retentionBaseDate: masa retensi dihitung dari tanggal surat, tanggal
+// diterima, atau tanggal upload jika keduanya kosong.
+func retentionBaseDate(doc models.Document) time.Time {
+	switch {
+	case doc.LetterDate != nil:
+		return *doc.LetterDate
+	case doc.ReceivedDate != nil:
+		return *doc.ReceivedDate
+	}
+	return doc.CreatedAt
+}
+
+// computeRetention menghitung status dan batas masa aktif/inaktif dokumen.
+func computeRetention(doc models.Document, policy *models.RetentionPolicy, now time.Time) (string, *time.Time, *time.Time) {
+	if policy == nil {
+		return "", nil, nil
+	}
+	base := retentionBaseDate(doc)
+	activeUntil := base.AddDate(policy.ActiveYears, 0, 0)
+	expiresAt := activeUntil.AddDate(policy.InactiveYears, 0, 0)
+
+	status := retentionActive
+	switch {
+	case now.Before(activeUntil):
+	case now.Before(expiresAt):
+		status = retentionInactive
+	case policy.FinalDisposition == "permanen":
+		status = retentionPermanent
+	default:
+		status = retentionExpired
+	}
+	return status, &activeUntil, &expiresAt
+}
+
+// retentionAllowsPurge menentukan apakah dokumen di recycle bin boleh
+// dihapus permanen: tidak ber-JRA, atau masa simpannya sudah habis dengan
+// nasib akhir musnah. Status dihitung ulang karena pemeriksaan retensi
+// terjadwal mungkin belum berjalan sejak JRA atau klasifikasinya diubah.
+func retentionAllowsPurge(doc models.Document, policies []models.RetentionPolicy, now time.Time) bool {
+	if doc.RetentionStatus == retentionPermanent || doc.RetentionStatus == retentionProposed {
+		return false
+	}
+	status, _, _ := computeRetention(doc, matchRetentionPolicy(policies, doc.ClassificationCode), now)
+	return status == "" || status == retentionExpired
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+// retentionRun mencegah dua pemeriksaan retensi berjalan bersamaan
+// (job terjadwal dan pemicu manual dari admin).
+var retentionRun sync.Mutex
+
+// retentionCheckResult adalah ringkasan satu kali pemeriksaan retensi.
+type retentionCheckResult struct {
+	Checked          int `json:"checked"`
+	Updated          int `json:"updated"`
+	NewlyExpired     int `json:"newly_expired"`
+	NewlyPermanent   int `json:"newly_permanent"`
+	WithoutRetention int `json:"without_retention"`
+}
+
+// checkRetention menghitung ulang status retensi semua dokumen aktif,
+// termasuk yang ada di recycle bin karena dokumen tersebut masih bisa
+// dipulihkan. Dokumen permanent dan proposed tidak dihitung ulang: status permanen
+// tidak bisa dicabut dengan mengganti klasifikasi, dan usulan pemusnahan
+// hanya diubah lewat alur persetujuan.
+func checkRetention() (retentionCheckResult, error) {
+	retentionRun.Lock()
+	defer retentionRun.Unlock()
+
+	var result retentionCheckResult
+	var policies []models.RetentionPolicy
+	if err := config.DB.Find(&policies).Error; err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	var docs []models.Document
+	err := config.DB.Unscoped().
+		Select("id", "classification_code", "letter_date", "received_date", "created_at",
+			"retention_policy_id", "retention_status", "retention_active_until", "retention_expires_at").
+		Where("retention_status NOT IN ?", []string{retentionPermanent, retentionProposed}).
+		FindInBatches(&docs, 500, func(tx *gorm.DB, batch int) error {
+			for _, doc := range docs {
+				result.Checked++
+				policy := matchRetentionPolicy(policies, doc.ClassificationCode)
+				status, activeUntil, expiresAt := computeRetention(doc, policy, now)
+				if policy == nil {
+					result.WithoutRetention++
+				}
+
+				var policyID *string
+				if policy != nil {
+					policyID = &policy.ID
+				}
+				samePolicy := (policyID == nil && doc.RetentionPolicyID == nil) ||
+					(policyID != nil && doc.RetentionPolicyID != nil && *policyID == *doc.RetentionPolicyID)
+				if status == doc.RetentionStatus && samePolicy &&
+					sameDate(activeUntil, doc.RetentionActiveUntil) && sameDate(expiresAt, doc.RetentionExpiresAt) {
+					continue
+				}
+
+				// Status lama ikut di WHERE agar tidak menimpa dokumen yang baru
+				// saja dimasukkan ke usulan pemusnahan
+				res := config.DB.Unscoped().Model(&models.Document{}).
+					Where("id = ? AND retention_status = ?", doc.ID, doc.RetentionStatus).
+					UpdateColumns(map[string]interface{}{
+						"retention_policy_id":    policyID,
+						"retention_status":       status,
+						"retention_active_until": activeUntil,
+						"retention_expires_at":   expiresAt,
+					})
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected == 0 {
+					continue
+				}
+				result.Updated++
+				if status != doc.RetentionStatus {
+					switch status {
+					case retentionExpired:
+						result.NewlyExpired++
+					case retentionPermanent:
+						result.NewlyPermanent++
+					}
+				}
+			}
+			return nil
+		}).Error
+	return result, err
+}
+
+// CheckRetentionSchedule dijalankan scheduler untuk menandai dokumen yang
+// masa retensinya sudah habis lalu memberi tahu admin.
+func CheckRetentionSchedule() {
+	result, err := checkRetention()
+	if err != nil {
+		fmt.Printf("Warning: pemeriksaan retensi arsip gagal: %v\n", err)
+		return
+	}
+	notifyRetentionResult(result)
+}
+
+func notifyRetentionResult(result retentionCheckResult) {
+	if result.NewlyExpired == 0 && result.NewlyPermanent == 0 {
+		return
+	}
+	msg := fmt.Sprintf("Pemeriksaan retensi arsip: %d arsip siap diusulkan untuk dimusnahkan, %d arsip ditetapkan permanen",
+		result.NewlyExpired, result.NewlyPermanent)
+	LogActivity("", "System", "RETENTION_CHECK", msg)
+
+	go func() {
+		var admins []models.User
+		if err := config.DB.Where("role = ?", "admin").Find(&admins).Error; err == nil {
+			for _, admin := range admins {
+				_ = CreateNotification(admin.ID, msg, "/dashboard/retention")
+			}
+		}
+	}()
+}
+
+// retentionPolicyInput dipakai untuk membuat dan mengubah JRA.
+type retentionPolicyInput struct {
+	ClassificationCode string `json:"classification_code" binding:"required"`
+	Description        string `json:"description"`
+	ActiveYears        int    `json:"active_years"`
+	InactiveYears      int    `json:"inactive_years"`
+	FinalDisposition   string `json:"final_disposition" binding:"required"`
+	Note               string `json:"note"`
+}
+
+func (in *retentionPolicyInput) validate() error {
+	in.ClassificationCode = strings.TrimSpace(in.ClassificationCode)
+	in.FinalDisposition = strings.ToLower(strings.TrimSpace(in.FinalDisposition))
+	if !classificationCodePattern.MatchString(in.ClassificationCode) {
+		return fmt.Errorf("kode klasifikasi tidak valid (contoh: 460 atau 400.7.1)")
+	}
+	if in.ActiveYears < 0 || in.InactiveYears < 0 {
+		return fmt.Errorf("masa aktif dan inaktif tidak boleh negatif")
+	}
+	if !retentionDispositions[in.FinalDisposition] {
+		return fmt.Errorf("final_disposition harus musnah atau permanen")
+	}
+	return nil
+}
+
+// recheckRetentionAsync menghitung ulang retensi setelah JRA berubah.
+func recheckRetentionAsync() {
+	go CheckRetentionSchedule()
+}
+
+// ======================================================
+// GET RETENTION POLICIES (ADMIN)
+// ======================================================
+func GetRetentionPolicies(c *gin.Context) {
+	var policies []models.RetentionPolicy
+	if err := config.DB.Find(&policies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil JRA: " + err.Error()})
+		return
+	}
+	// Urut per segmen kode agar 400.10 berada setelah 400.9
+	sort.Slice(policies, func(i, j int) bool {
+		a := strings.Split(policies[i].ClassificationCode, ".")
+		b := strings.Split(policies[j].ClassificationCode, ".")
+		for k := 0; k < len(a) && k < len(b); k++ {
+			x, _ := strconv.Atoi(a[k])
+			y, _ := strconv.Atoi(b[k])
+			if x != y {
+				return x < y
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	// Jumlah dokumen per status retensi untuk ringkasan di halaman JRA
+	type statusCount struct {
+		RetentionStatus string
+		Total           int64
+	}
+	var counts []statusCount
+	config.DB.Model(&models.Document{}).
+		Select("retention_status, COUNT(*) AS total").
+		Group("retention_status").Scan(&counts)
+	summary := map[string]int64{}
+	for _, sc := range counts {
+		key := sc.RetentionStatus
+		if key == "" {
+			key = "none"
+		}
+		summary[key] = sc.Total
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policies, "summary": summary})
+}
+
+// ======================================================
+// CREATE RETENTION POLICY (ADMIN)
+// ======================================================
+func CreateRetentionPolicy(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input retentionPolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.RetentionPolicy{}).Where("classification_code = ?", input.ClassificationCode).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "JRA untuk kode klasifikasi " + input.ClassificationCode + " sudah ada"})
+		return
+	}
+
+	policy := models.RetentionPolicy{
+		ClassificationCode: input.ClassificationCode,
+		Description:        strings.TrimSpace(input.Description),
+		ActiveYears:        input.ActiveYears,
+		InactiveYears:      input.InactiveYears,
+		FinalDisposition:   input.FinalDisposition,
+		Note:               strings.TrimSpace(input.Note),
+		UpdatedByID:        &user.ID,
+	}
+	if err := config.DB.Create(&policy).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan JRA: " + err.Error()})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "CREATE_RETENTION_POLICY",
+		fmt.Sprintf("Menambahkan JRA %s: aktif %d tahun, inaktif %d tahun, %s",
+			policy.ClassificationCode, policy.ActiveYears, policy.InactiveYears, policy.FinalDisposition))
+	recheckRetentionAsync()
+
+	c.JSON(http.StatusCreated, gin.H{"message": "JRA berhasil ditambahkan", "policy": policy})
+}
+
+// ======================================================
+// UPDATE RETENTION POLICY (ADMIN)
+// ======================================================
+func UpdateRetentionPolicy(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var policy models.RetentionPolicy
+	if err := config.DB.First(&policy, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "JRA tidak ditemukan"})
+		return
+	}
+
+	var input retentionPolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.RetentionPolicy{}).
+		Where("classification_code = ? AND id <> ?", input.ClassificationCode, policy.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "JRA untuk kode klasifikasi " + input.ClassificationCode + " sudah ada"})
+		return
+	}
+
+	// Map dipakai agar nilai 0 tahun tetap tersimpan
+	updates := map[string]interface{}{
+		"classification_code": input.ClassificationCode,
+		"description":         strings.TrimSpace(input.Description),
+		"active_years":        input.ActiveYears,
+		"inactive_years":      input.InactiveYears,
+		"final_disposition":   input.FinalDisposition,
+		"note":                strings.TrimSpace(input.Note),
+		"updated_by_id":       user.ID,
+	}
+	if err := config.DB.Model(&policy).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan JRA: " + err.Error()})
+		return
+	}
+	config.DB.First(&policy, "id = ?", policy.ID)
+
+	CreateActivityLog(user.ID, user.Name, "UPDATE_RETENTION_POLICY",
+		fmt.Sprintf("Mengubah JRA %s: aktif %d tahun, inaktif %d tahun, %s",
+			policy.ClassificationCode, policy.ActiveYears, policy.InactiveYears, policy.FinalDisposition))
+	recheckRetentionAsync()
+
+	c.JSON(http.StatusOK, gin.H{"message": "JRA berhasil diperbarui", "policy": policy})
+}
+
+// ======================================================
+// DELETE RETENTION POLICY (ADMIN)
+// ======================================================
+func DeleteRetentionPolicy(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var policy models.RetentionPolicy
+	if err := config.DB.First(&policy, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "JRA tidak ditemukan"})
+		return
+	}
+	if err := config.DB.Delete(&policy).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus JRA: " + err.Error()})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "DELETE_RETENTION_POLICY", "Menghapus JRA "+policy.ClassificationCode)
+	// Dokumen yang memakai JRA ini dihitung ulang dengan JRA induknya (jika ada)
+	recheckRetentionAsync()
+
+	c.JSON(http.StatusOK, gin.H{"message": "JRA berhasil dihapus"})
+}
+
+// ======================================================
+// RUN RETENTION CHECK (ADMIN)
+// ======================================================
+// Menjalankan pemeriksaan retensi sekarang tanpa menunggu job terjadwal
+func RunRetentionCheck(c *gin.Context) {
+	result, err := checkRetention()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa retensi: " + err.Error()})
+		return
+	}
+	notifyRetentionResult(result)
+	c.JSON(http.StatusOK, gin.H{"message": "Pemeriksaan retensi selesai", "result": result})
+}
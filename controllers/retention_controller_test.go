@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"dinsos_kuburaya/models"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func datePtr(y int, m time.Month, d int) *time.Time {
+	t := date(y, m, d)
+	return &t
+}
+
+var testPolicies = []models.RetentionPolicy{
+	{ID: "p400", ClassificationCode: "400", ActiveYears: 2, InactiveYears: 3, FinalDisposition: "musnah"},
+	{ID: "p400.7", ClassificationCode: "400.7", ActiveYears: 1, InactiveYears: 1, FinalDisposition: "permanen"},
+	{ID: "p460", ClassificationCode: "460", ActiveYears: 5, InactiveYears: 5, FinalDisposition: "musnah"},
+}
+
+func TestMatchRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"400", "p400"},
+		{"400.1", "p400"},
+		{"400.7", "p400.7"},
+		{"400.7.1", "p400.7"},
+		{"4001", ""}, // bukan turunan 400
+		{"460.2", "p460"},
+		{"500", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if p := matchRetentionPolicy(testPolicies, tt.code); p != nil {
+			got = p.ID
+		}
+		if got != tt.want {
+			t.Errorf("matchRetentionPolicy(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRetentionBaseDate(t *testing.T) {
+	created := date(2020, 1, 1)
+	tests := []struct {
+		name string
+		doc  models.Document
+		want time.Time
+	}{
+		{"tanggal surat", models.Document{LetterMetadata: models.LetterMetadata{LetterDate: datePtr(2019, 5, 1), ReceivedDate: datePtr(2019, 5, 3)}, CreatedAt: created}, date(2019, 5, 1)},
+		{"tanggal diterima", models.Document{LetterMetadata: models.LetterMetadata{ReceivedDate: datePtr(2019, 5, 3)}, CreatedAt: created}, date(2019, 5, 3)},
+		{"tanggal upload", models.Document{CreatedAt: created}, created},
+	}
+	for _, tt := range tests {
+		if got := retentionBaseDate(tt.doc); !got.Equal(tt.want) {
+			t.Errorf("%s: retentionBaseDate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComputeRetention(t *testing.T) {
+	doc := models.Document{LetterMetadata: models.LetterMetadata{LetterDate: datePtr(2020, 3, 10)}}
+	musnah := &testPolicies[0]   // aktif 2 tahun, inaktif 3 tahun
+	permanen := &testPolicies[1] // aktif 1 tahun, inaktif 1 tahun
+
+	tests := []struct {
+		name   string
+		policy *models.RetentionPolicy
+		now    time.Time
+		want   string
+	}{
+		{"tanpa JRA", nil, date(2030, 1, 1), ""},
+		{"masih aktif", musnah, date(2022, 3, 9), retentionActive},
+		{"tepat akhir masa aktif", musnah, date(2022, 3, 10), retentionInactive},
+		{"inaktif", musnah, date(2024, 12, 31), retentionInactive},
+		{"habis, musnah", musnah, date(2025, 3, 10), retentionExpired},
+		{"habis, permanen", permanen, date(2022, 3, 10), retentionPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, activeUntil, expiresAt := computeRetention(doc, tt.policy, tt.now)
+			if status != tt.want {
+				t.Errorf("status = %q, want %q", status, tt.want)
+			}
+			if tt.policy == nil {
+				if activeUntil != nil || expiresAt != nil {
+					t.Error("batas retensi harus kosong tanpa JRA")
+				}
+				return
+			}
+			wantActive := date(2020+tt.policy.ActiveYears, 3, 10)
+			wantExpires := wantActive.AddDate(tt.policy.InactiveYears, 0, 0)
+			if !activeUntil.Equal(wantActive) || !expiresAt.Equal(wantExpires) {
+				t.Errorf("batas = %v / %v, want %v / %v", activeUntil, expiresAt, wantActive, wantExpires)
+			}
+		})
+	}
+}
+
+func TestRetentionAllowsPurge(t *testing.T) {
+	now := date(2025, 6, 1)
+	tests := []struct {
+		name string
+		doc  models.Document
+		want bool
+	}{
+		{"tanpa klasifikasi", models.Document{CreatedAt: date(2025, 1, 1)}, true},
+		{"klasifikasi tanpa JRA", models.Document{LetterMetadata: models.LetterMetadata{ClassificationCode: "500"}, CreatedAt: date(2025, 1, 1)}, true},
+		{"masih aktif", models.Document{LetterMetadata: models.LetterMetadata{ClassificationCode: "460", LetterDate: datePtr(2024, 1, 1)}}, false},
+		{"inaktif", models.Document{LetterMetadata: models.LetterMetadata{ClassificationCode: "400.1", LetterDate: datePtr(2022, 1, 1)}}, false},
+		{"masa simpan habis", models.Document{LetterMetadata: models.LetterMetadata{ClassificationCode: "400.1", LetterDate: datePtr(2019, 1, 1)}}, true},
+		{"nasib akhir permanen", models.Document{LetterMetadata: models.LetterMetadata{ClassificationCode: "400.7.1", LetterDate: datePtr(2019, 1, 1)}}, false},
+		{"status permanent", models.Document{RetentionStatus: retentionPermanent}, false},
+		{"sedang diusulkan", models.Document{RetentionStatus: retentionProposed}, false},
+	}
+	for _, tt := range tests {
+		if got := retentionAllowsPurge(tt.doc, testPolicies, now); got != tt.want {
+			t.Errorf("%s: retentionAllowsPurge = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
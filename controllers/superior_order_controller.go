@@ -1,63 +1,283 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"dinsos_kuburaya/config"
 	"dinsos_kuburaya/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Pilihan instruksi disposisi yang baku, urutannya dipakai di form
+var dispositionInstructionOptions = []struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}{
+	{"untuk_diketahui", "Untuk diketahui"},
+	{"tindak_lanjuti", "Tindak lanjuti"},
+	{"koordinasikan", "Koordinasikan"},
+	{"pelajari", "Pelajari dan beri saran"},
+	{"siapkan_jawaban", "Siapkan jawaban"},
+	{"hadiri", "Hadiri / wakili"},
+	{"edarkan", "Edarkan"},
+	{"arsipkan", "Arsipkan"},
+}
+
+// Urutan status disposisi; status hanya boleh maju
+var dispositionStatusOrder = map[string]int{"sent": 0, "read": 1, "in_progress": 2, "completed": 3}
+
+// dispositionInput adalah isi disposisi yang sama untuk semua penerima.
+type dispositionInput struct {
+	Instruction        string   `json:"instruction"`
+	InstructionOptions []string `json:"instruction_options"`
+	DueDate            string   `json:"due_date"`
+	Priority           string   `json:"priority"`
+}
+
+// parse memvalidasi input dan mengembalikan kolom SuperiorOrder yang diisi.
+func (in dispositionInput) parse() (models.SuperiorOrder, error) {
+	order := models.SuperiorOrder{
+		Instruction:        strings.TrimSpace(in.Instruction),
+		InstructionOptions: []string{},
+		Priority:           "biasa",
+	}
+	if len([]rune(order.Instruction)) > 2000 {
+		return order, fmt.Errorf("instruksi maksimal 2000 karakter")
+	}
+
+	known := map[string]bool{}
+	for _, o := range dispositionInstructionOptions {
+		known[o.Key] = true
+	}
+	seen := map[string]bool{}
+	for _, key := range in.InstructionOptions {
+		key = normalizeLetterOption(key)
+		if !known[key] {
+			return order, fmt.Errorf("pilihan instruksi %s tidak dikenal", key)
+		}
+		if !seen[key] {
+			seen[key] = true
+			order.InstructionOptions = append(order.InstructionOptions, key)
+		}
+	}
+
+	if v := strings.TrimSpace(in.DueDate); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return order, fmt.Errorf("due_date harus berformat YYYY-MM-DD")
+		}
+		order.DueDate = &t
+	}
+	if v := normalizeLetterOption(in.Priority); v != "" {
+		if !letterUrgencies[v] {
+			return order, fmt.Errorf("prioritas harus biasa, segera atau sangat_segera")
+		}
+		order.Priority = v
+	}
+	return order, nil
+}
+
+// dispositionMessage menyusun pesan notifikasi disposisi baru.
+func dispositionMessage(subject string, order models.SuperiorOrder) string {
+	msg := fmt.Sprintf("Anda menerima disposisi baru: %s", subject)
+	if order.Priority != "" && order.Priority != "biasa" {
+		msg = fmt.Sprintf("[%s] %s", strings.ToUpper(strings.ReplaceAll(order.Priority, "_", " ")), msg)
+	}
+	if order.DueDate != nil {
+		msg += " (batas " + order.DueDate.Format("02-01-2006") + ")"
+	}
+	return msg
+}
+
+// dispositionDiff adalah hasil perubahan daftar penerima disposisi satu dokumen.
+type dispositionDiff struct {
+	Added     []models.SuperiorOrder `json:"added"`
+	Removed   []models.SuperiorOrder `json:"removed"`
+	Unchanged []models.SuperiorOrder `json:"unchanged"`
+}
+
+// syncDispositions menyamakan penerima disposisi langsung (tanpa induk) sebuah
+// dokumen dengan userIDs di dalam transaksi tx. Satu user hanya memiliki satu
+// disposisi per dokumen, baik langsung maupun lanjutan: user yang sudah
+// menerimanya masuk ke unchanged beserta statusnya, user lain dibuat dengan
+// fields. Jika replace bernilai true, disposisi langsung yang tidak ada di
+// userIDs dihapus bersama disposisi lanjutannya, kecuali disposisi lanjutan
+// milik user di userIDs yang dinaikkan menjadi disposisi langsung sehingga
+// status dan riwayatnya tidak hilang.
+func syncDispositions(tx *gorm.DB, doc models.Document, userIDs []string, fields models.SuperiorOrder, creatorID *string, replace bool) (dispositionDiff, error) {
+	diff := dispositionDiff{Added: []models.SuperiorOrder{}, Removed: []models.SuperiorOrder{}, Unchanged: []models.SuperiorOrder{}}
+
+	if len(userIDs) > 0 {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("id IN ?", userIDs).Count(&count).Error; err != nil {
+			return diff, err
+		}
+		if int(count) != len(userIDs) {
+			return diff, &requestError{Status: http.StatusBadRequest, Code: "invalid_users", Message: "Sebagian penerima tidak ditemukan"}
+		}
+	}
+
+	// Perubahan disposisi satu dokumen dijalankan bergantian
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		First(&models.Document{}, "id = ?", doc.ID).Error; err != nil {
+		return diff, err
+	}
+	var existing []models.SuperiorOrder
+	if err := tx.Where("document_id = ?", doc.ID).Order("created_at ASC").Find(&existing).Error; err != nil {
+		return diff, err
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range userIDs {
+		wanted[id] = true
+	}
+	byUser := map[string]models.SuperiorOrder{}
+	children := map[string][]models.SuperiorOrder{}
+	for _, o := range existing {
+		byUser[o.UserID] = o
+		if o.ParentOrderID != nil {
+			children[*o.ParentOrderID] = append(children[*o.ParentOrderID], o)
+		}
+	}
+
+	// Disposisi langsung yang dihapus dan turunannya; turunan milik user yang
+	// masih diinginkan dinaikkan bersama subtree-nya
+	var removedIDs []string
+	promoted := map[string]bool{}
+	var collect func(o models.SuperiorOrder)
+	collect = func(o models.SuperiorOrder) {
+		diff.Removed = append(diff.Removed, o)
+		removedIDs = append(removedIDs, o.ID)
+		for _, child := range children[o.ID] {
+			if !wanted[child.UserID] {
+				collect(child)
+				continue
+			}
+			child.ParentOrderID = nil
+			byUser[child.UserID] = child
+			promoted[child.ID] = true
+		}
+	}
+	if replace {
+		for _, o := range existing {
+			if o.ParentOrderID == nil && !wanted[o.UserID] {
+				collect(o)
+			}
+		}
+	}
+
+	for _, id := range userIDs {
+		if o, ok := byUser[id]; ok {
+			if promoted[o.ID] {
+				if err := tx.Model(&o).Update("parent_order_id", nil).Error; err != nil {
+					return diff, err
+				}
+			}
+			diff.Unchanged = append(diff.Unchanged, o)
+			continue
+		}
+		order := fields
+		order.DocumentID = doc.ID
+		order.UserID = id
+		order.CreatedByID = creatorID
+		order.Status = "sent"
+		if err := tx.Create(&order).Error; err != nil {
+			return diff, err
+		}
+		diff.Added = append(diff.Added, order)
+	}
+
+	if len(removedIDs) == 0 {
+		return diff, nil
+	}
+	return diff, tx.Where("id IN ?", removedIDs).Delete(&models.SuperiorOrder{}).Error
+}
+
+// notifyDispositionDiff memberi tahu penerima baru dan penerima yang dibatalkan.
+func notifyDispositionDiff(doc models.Document, diff dispositionDiff) {
+	go func() {
+		link := fmt.Sprintf("/dashboard/my-document/%s", doc.ID)
+		for _, o := range diff.Added {
+			_ = CreateNotification(o.UserID, dispositionMessage(doc.Subject, o), link)
+		}
+		for _, o := range diff.Removed {
+			_ = CreateNotification(o.UserID, fmt.Sprintf("Disposisi untuk surat %s dibatalkan", doc.Subject), link)
+		}
+	}()
+}
+
+func respondDispositionError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		respondFileError(c, err)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan disposisi: " + err.Error()})
+}
+
 // ======================================================
 // CREATE SuperiorOrder WITH NOTIFICATION
 // ======================================================
+// Menambah penerima disposisi; penerima yang sudah ada tidak diubah.
 func CreateSuperiorOrder(c *gin.Context) {
 	var input struct {
 		DocumentID string   `json:"document_id" binding:"required"`
 		UserIDs    []string `json:"user_ids" binding:"required"`
+		dispositionInput
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 		return
 	}
+	fields, err := input.dispositionInput.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
 
 	// Ambil info dokumen untuk pesan notifikasi
-	var doc models.Document
-	if err := config.DB.First(&doc, "id = ?", input.DocumentID).Error; err != nil {
+	doc, found := findDocument(input.DocumentID)
+	if !found {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
 
-	var created []models.SuperiorOrder
-	for _, userID := range input.UserIDs {
-		// Cek duplikasi
-		var count int64
-		config.DB.Model(&models.SuperiorOrder{}).Where("document_id = ? AND user_id = ?", input.DocumentID, userID).Count(&count)
-		if count > 0 {
-			continue
-		}
-
-		order := models.SuperiorOrder{
-			DocumentID: input.DocumentID,
-			UserID:     userID,
-		}
-		if err := config.DB.Create(&order).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create record: " + err.Error()})
-			return
-		}
-		created = append(created, order)
-
-		go func(uid string) {
-			msg := fmt.Sprintf("Anda menerima disposisi baru: %s", doc.Subject)
-			link := fmt.Sprintf("/dashboard/my-document/%s", doc.ID)
-			_ = CreateNotification(uid, msg, link)
-		}(userID)
+	var diff dispositionDiff
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		diff, err = syncDispositions(tx, doc, splitIDs(strings.Join(input.UserIDs, ",")), fields, &user.ID, false)
+		return err
+	})
+	if err != nil {
+		respondDispositionError(c, err)
+		return
+	}
+	notifyDispositionDiff(doc, diff)
+	if len(diff.Added) > 0 {
+		LogActivity(user.ID, user.Name, "CREATE_DISPOSITION", fmt.Sprintf("Menambah %d penerima disposisi surat %s", len(diff.Added), doc.Subject))
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "SuperiorOrder created and notifications sent", "data": created})
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "SuperiorOrder created and notifications sent",
+		"data":      diff.Added,
+		"added":     diff.Added,
+		"removed":   diff.Removed,
+		"unchanged": diff.Unchanged,
+	})
 }
 
 // ======================================================
@@ -65,13 +285,19 @@ func CreateSuperiorOrder(c *gin.Context) {
 // ======================================================
 func GetSuperiorOrders(c *gin.Context) {
 	var orders []models.SuperiorOrder
-	if err := config.DB.Preload("User").Preload("Document").Find(&orders).Error; err != nil {
+	// Disposisi untuk dokumen yang ada di recycle bin tidak ditampilkan
+	activeDocs := config.DB.Model(&models.Document{}).Select("id")
+	if err := config.DB.Preload("User").Preload("Document").Where("document_id IN (?)", activeDocs).Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records: " + err.Error()})
 		return
 	}
 
 	type UserInfo struct {
-		Name string `json:"name"`
+		UserID   string     `json:"user_id"`
+		Name     string     `json:"name"`
+		Status   string     `json:"status"`
+		Priority string     `json:"priority"`
+		DueDate  *time.Time `json:"due_date"`
 	}
 
 	type DocumentInfo struct {
@@ -92,7 +318,13 @@ func GetSuperiorOrders(c *gin.Context) {
 				Users:      []UserInfo{},
 			}
 		}
-		grouped[o.DocumentID].Users = append(grouped[o.DocumentID].Users, UserInfo{Name: o.User.Name})
+		grouped[o.DocumentID].Users = append(grouped[o.DocumentID].Users, UserInfo{
+			UserID:   o.UserID,
+			Name:     o.User.Name,
+			Status:   o.Status,
+			Priority: o.Priority,
+			DueDate:  o.DueDate,
+		})
 	}
 
 	var result []DocumentInfo
@@ -107,7 +339,7 @@ func GetSuperiorOrders(c *gin.Context) {
 // GET SuperiorOrders by document_id
 // ======================================================
 func GetSuperiorOrdersByDocument(c *gin.Context) {
-	documentID := c.Param("document_id")
+	documentID := c.Param("id")
 	var orders []models.SuperiorOrder
 	if err := config.DB.Preload("User").Preload("Document").Where("document_id = ?", documentID).Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records: " + err.Error()})
@@ -125,44 +357,67 @@ func GetSuperiorOrdersByDocument(c *gin.Context) {
 // ======================================================
 // UPDATE SuperiorOrder by document_id
 // ======================================================
+// user_ids adalah daftar lengkap penerima disposisi langsung. Penerima baru
+// dibuat dengan instruksi/batas waktu/prioritas dari body, penerima lama tetap
+// beserta status dan laporannya, penerima yang dihapus ikut menghapus disposisi
+// lanjutannya. Response berisi added, removed dan unchanged.
 func UpdateSuperiorOrder(c *gin.Context) {
-	documentID := c.Param("document_id")
-
 	var input struct {
 		UserIDs []string `json:"user_ids" binding:"required"`
+		dispositionInput
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 		return
 	}
+	fields, err := input.dispositionInput.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
 
-	if err := config.DB.Where("document_id = ?", documentID).Delete(&models.SuperiorOrder{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete old records: " + err.Error()})
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
 
-	var created []models.SuperiorOrder
-	for _, userID := range input.UserIDs {
-		order := models.SuperiorOrder{
-			DocumentID: documentID,
-			UserID:     userID,
-		}
-		if err := config.DB.Create(&order).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create record: " + err.Error()})
-			return
-		}
-		created = append(created, order)
+	var diff dispositionDiff
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		diff, err = syncDispositions(tx, doc, splitIDs(strings.Join(input.UserIDs, ",")), fields, &user.ID, true)
+		return err
+	})
+	if err != nil {
+		respondDispositionError(c, err)
+		return
 	}
+	notifyDispositionDiff(doc, diff)
+	LogActivity(user.ID, user.Name, "UPDATE_DISPOSITION", fmt.Sprintf(
+		"Mengubah penerima disposisi surat %s: %d ditambah, %d dihapus", doc.Subject, len(diff.Added), len(diff.Removed),
+	))
 
-	c.JSON(http.StatusOK, gin.H{"message": "SuperiorOrder updated", "data": created})
+	current := append(append([]models.SuperiorOrder{}, diff.Unchanged...), diff.Added...)
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "SuperiorOrder updated",
+		"data":      current,
+		"added":     diff.Added,
+		"removed":   diff.Removed,
+		"unchanged": diff.Unchanged,
+	})
 }
 
 // ======================================================
 // DELETE SuperiorOrder by document_id
 // ======================================================
 func DeleteSuperiorOrder(c *gin.Context) {
-	documentID := c.Param("document_id")
+	documentID := c.Param("id")
 
 	if err := config.DB.Where("document_id = ?", documentID).Delete(&models.SuperiorOrder{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete records: " + err.Error()})
@@ -171,3 +426,626 @@ func DeleteSuperiorOrder(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "All SuperiorOrders for document deleted", "document_id": documentID})
 }
+
+// ======================================================
+// GET instruction options
+// ======================================================
+func GetDispositionInstructionOptions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"instruction_options": dispositionInstructionOptions,
+		"priorities":          []string{"biasa", "segera", "sangat_segera"},
+		"statuses":            []string{"sent", "read", "in_progress", "completed"},
+	})
+}
+
+// ======================================================
+// UPDATE disposition status
+// ======================================================
+// Body: status (read, in_progress, completed), response_note. Hanya penerima
+// disposisi (atau admin) yang boleh mengubah; status hanya boleh maju dan
+// laporan wajib diisi saat disposisi diselesaikan.
+func UpdateSuperiorOrderStatus(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		Status       string `json:"status" binding:"required"`
+		ResponseNote string `json:"response_note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	var order models.SuperiorOrder
+	if err := config.DB.Preload("Document").First(&order, "id = ?", c.Param("order_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Disposisi tidak ditemukan"})
+		return
+	}
+	if order.UserID != user.ID && user.Role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda bukan penerima disposisi ini"})
+		return
+	}
+
+	updates, err := dispositionStatusUpdates(order, input.Status, input.ResponseNote)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+	if err := config.DB.Model(&order).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengubah status disposisi: " + err.Error()})
+		return
+	}
+	config.DB.Preload("Document").First(&order, "id = ?", order.ID)
+
+	LogActivity(user.ID, user.Name, "UPDATE_DISPOSITION", fmt.Sprintf("Mengubah status disposisi %s menjadi %s", order.Document.Subject, order.Status))
+	notifyDispositionStatus(order, user)
+	c.JSON(http.StatusOK, gin.H{"message": "Status disposisi diperbarui", "data": order})
+}
+
+// dispositionStatusUpdates memeriksa perpindahan status dan menyiapkan kolom
+// yang diubah, termasuk waktu setiap tahap yang belum terisi.
+func dispositionStatusUpdates(order models.SuperiorOrder, status, note string) (map[string]interface{}, error) {
+	status = strings.TrimSpace(status)
+	note = strings.TrimSpace(note)
+	next, ok := dispositionStatusOrder[status]
+	if !ok || status == "sent" {
+		return nil, &requestError{Status: http.StatusBadRequest, Code: "invalid_status", Message: "Status harus read, in_progress atau completed"}
+	}
+	current := dispositionStatusOrder[order.Status]
+	if next < current || (next == current && status != "in_progress") {
+		return nil, &requestError{
+			Status:  http.StatusConflict,
+			Code:    "invalid_transition",
+			Message: fmt.Sprintf("Status disposisi tidak bisa diubah dari %s ke %s", order.Status, status),
+		}
+	}
+	if status == "completed" && note == "" && order.ResponseNote == "" {
+		return nil, &requestError{Status: http.StatusBadRequest, Code: "response_required", Message: "Laporan tindak lanjut (response_note) wajib diisi"}
+	}
+	if len([]rune(note)) > 5000 {
+		return nil, &requestError{Status: http.StatusBadRequest, Code: "invalid_note", Message: "Laporan maksimal 5000 karakter"}
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": status}
+	if note != "" {
+		updates["response_note"] = note
+	}
+	if order.ReadAt == nil {
+		updates["read_at"] = now
+	}
+	if next >= dispositionStatusOrder["in_progress"] && order.StartedAt == nil {
+		updates["started_at"] = now
+	}
+	if status == "completed" {
+		updates["completed_at"] = now
+	}
+	return updates, nil
+}
+
+// notifyDispositionStatus memberi tahu pemberi disposisi saat penerima mulai
+// mengerjakan atau menyelesaikan disposisi.
+func notifyDispositionStatus(order models.SuperiorOrder, actor models.User) {
+	if order.CreatedByID == nil || *order.CreatedByID == actor.ID {
+		return
+	}
+	var msg string
+	switch order.Status {
+	case "in_progress":
+		msg = fmt.Sprintf("Disposisi %s sedang ditindaklanjuti oleh %s", order.Document.Subject, actor.Name)
+	case "completed":
+		msg = fmt.Sprintf("Disposisi %s telah diselesaikan oleh %s", order.Document.Subject, actor.Name)
+	default:
+		return
+	}
+	go func(uid, m, link string) {
+		_ = CreateNotification(uid, m, link)
+	}(*order.CreatedByID, msg, fmt.Sprintf("/dashboard/my-document/%s", order.DocumentID))
+}
+
+// ======================================================
+// MY DISPOSITIONS (STAFF INBOX)
+// ======================================================
+// Disposisi milik user yang login. Query: status (boleh dipisah koma), priority,
+// due (overdue, today, week, none), due_from, due_to (YYYY-MM-DD), page, per_page.
+func GetMySuperiorOrders(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 20
+	}
+
+	// Disposisi untuk dokumen yang ada di recycle bin tidak ditampilkan
+	activeDocs := config.DB.Model(&models.Document{}).Select("id")
+	base := config.DB.Model(&models.SuperiorOrder{}).Where("user_id = ? AND document_id IN (?)", user.ID, activeDocs)
+
+	query := base.Session(&gorm.Session{})
+	if v := c.Query("status"); v != "" {
+		statuses := splitIDs(v)
+		for _, s := range statuses {
+			if _, ok := dispositionStatusOrder[s]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Status harus sent, read, in_progress atau completed"})
+				return
+			}
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if v := c.Query("priority"); v != "" {
+		query = query.Where("priority = ?", normalizeLetterOption(v))
+	}
+
+	today := time.Now().Format("2006-01-02")
+	switch c.Query("due") {
+	case "":
+	case "overdue":
+		query = query.Where("due_date < ? AND status <> ?", today, "completed")
+	case "today":
+		query = query.Where("due_date = ?", today)
+	case "week":
+		query = query.Where("due_date BETWEEN ? AND ?", today, time.Now().AddDate(0, 0, 7).Format("2006-01-02"))
+	case "none":
+		query = query.Where("due_date IS NULL")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "due harus overdue, today, week atau none"})
+		return
+	}
+	for param, op := range map[string]string{"due_from": ">=", "due_to": "<="} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": param + " harus berformat YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("due_date "+op+" ?", v)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	// Belum selesai di atas, lalu yang paling dekat batas waktunya
+	var orders []models.SuperiorOrder
+	if err := query.
+		Preload("Document", func(db *gorm.DB) *gorm.DB { return db.Omit("extracted_text") }).
+		Order("status = 'completed' ASC, due_date IS NULL ASC, due_date ASC, created_at DESC").
+		Limit(perPage).Offset((page - 1) * perPage).
+		Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil disposisi: " + err.Error()})
+		return
+	}
+
+	// Ringkasan jumlah per status untuk badge di inbox
+	var counts []struct {
+		Status string
+		Total  int64
+	}
+	base.Session(&gorm.Session{}).Select("status, COUNT(*) AS total").Group("status").Scan(&counts)
+	summary := gin.H{"sent": 0, "read": 0, "in_progress": 0, "completed": 0}
+	for _, sc := range counts {
+		summary[sc.Status] = sc.Total
+	}
+	var overdue int64
+	base.Session(&gorm.Session{}).Where("due_date < ? AND status <> ?", today, "completed").Count(&overdue)
+	summary["overdue"] = overdue
+
+	data := make([]gin.H, 0, len(orders))
+	for _, o := range orders {
+		data = append(data, myDispositionResponse(o))
+	}
+
+	lastPage := int((total + int64(perPage) - 1) / int64(perPage))
+	c.JSON(http.StatusOK, gin.H{
+		"data":         data,
+		"summary":      summary,
+		"total":        total,
+		"current_page": page,
+		"last_page":    lastPage,
+		"per_page":     perPage,
+	})
+}
+
+// myDispositionResponse: disposisi beserta dokumennya dan penanda terlambat.
+func myDispositionResponse(o models.SuperiorOrder) gin.H {
+	overdue := false
+	if o.DueDate != nil && o.Status != "completed" {
+		y, m, d := time.Now().Date()
+		overdue = o.DueDate.Before(time.Date(y, m, d, 0, 0, 0, 0, o.DueDate.Location()))
+	}
+	return gin.H{
+		"id":                  o.ID,
+		"document_id":         o.DocumentID,
+		"instruction":         o.Instruction,
+		"instruction_options": o.InstructionOptions,
+		"due_date":            o.DueDate,
+		"priority":            o.Priority,
+		"status":              o.Status,
+		"response_note":       o.ResponseNote,
+		"created_by_id":       o.CreatedByID,
+		"read_at":             o.ReadAt,
+		"started_at":          o.StartedAt,
+		"completed_at":        o.CompletedAt,
+		"created_at":          o.CreatedAt,
+		"overdue":             overdue,
+		"document":            o.Document,
+	}
+}
+
+// findMySuperiorOrder mengambil disposisi milik user yang login. Disposisi
+// user lain dijawab 404 agar keberadaannya tidak terlihat.
+func findMySuperiorOrder(c *gin.Context, user models.User) (models.SuperiorOrder, bool) {
+	var order models.SuperiorOrder
+	activeDocs := config.DB.Model(&models.Document{}).Select("id")
+	err := config.DB.Preload("Document", func(db *gorm.DB) *gorm.DB { return db.Omit("extracted_text") }).
+		Where("id = ? AND user_id = ? AND document_id IN (?)", c.Param("order_id"), user.ID, activeDocs).
+		First(&order).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Disposisi tidak ditemukan"})
+		return order, false
+	}
+	return order, true
+}
+
+// ======================================================
+// MY DISPOSITION DETAIL
+// ======================================================
+func GetMySuperiorOrder(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	order, ok := findMySuperiorOrder(c, userRaw.(models.User))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": myDispositionResponse(order)})
+}
+
+// ======================================================
+// ACKNOWLEDGE / START / COMPLETE MY DISPOSITION
+// ======================================================
+// acknowledge: sent -> read (tidak berubah jika sudah dibaca).
+// start: -> in_progress. complete: -> completed, body response_note wajib.
+func UpdateMySuperiorOrder(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	actions := map[string]string{"acknowledge": "read", "start": "in_progress", "complete": "completed"}
+	status, ok := actions[c.Param("action")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Aksi harus acknowledge, start atau complete"})
+		return
+	}
+
+	var input struct {
+		ResponseNote string `json:"response_note"`
+	}
+	_ = c.ShouldBindJSON(&input)
+
+	order, found := findMySuperiorOrder(c, user)
+	if !found {
+		return
+	}
+	// Konfirmasi baca berulang tidak dianggap error
+	if status == "read" && order.Status != "sent" {
+		c.JSON(http.StatusOK, gin.H{"message": "Disposisi sudah dibaca", "data": myDispositionResponse(order)})
+		return
+	}
+
+	updates, err := dispositionStatusUpdates(order, status, input.ResponseNote)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+	if err := config.DB.Model(&order).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengubah status disposisi: " + err.Error()})
+		return
+	}
+	config.DB.Preload("Document", func(db *gorm.DB) *gorm.DB { return db.Omit("extracted_text") }).First(&order, "id = ?", order.ID)
+
+	LogActivity(user.ID, user.Name, "UPDATE_DISPOSITION", fmt.Sprintf("Mengubah status disposisi %s menjadi %s", order.Document.Subject, order.Status))
+	notifyDispositionStatus(order, user)
+	c.JSON(http.StatusOK, gin.H{"message": "Status disposisi diperbarui", "data": myDispositionResponse(order)})
+}
+
+// ======================================================
+// FORWARD MY DISPOSITION
+// ======================================================
+// Penerima meneruskan disposisinya ke user lain dengan instruksinya sendiri.
+// Body: user_ids, instruction, instruction_options, due_date (default batas
+// waktu disposisi induk), priority. Disposisi induk otomatis menjadi in_progress.
+func ForwardSuperiorOrder(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		UserIDs []string `json:"user_ids" binding:"required"`
+		dispositionInput
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	fields, err := input.dispositionInput.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	userIDs := splitIDs(strings.Join(input.UserIDs, ","))
+	if len(userIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pilih minimal satu penerima"})
+		return
+	}
+
+	parent, found := findMySuperiorOrder(c, user)
+	if !found {
+		return
+	}
+	subject := parent.Document.Subject
+	if fields.DueDate == nil {
+		fields.DueDate = parent.DueDate
+	}
+
+	for _, id := range userIDs {
+		if id == user.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Disposisi tidak bisa diteruskan ke diri sendiri"})
+			return
+		}
+	}
+	var recipients []models.User
+	config.DB.Where("id IN ?", userIDs).Find(&recipients)
+	if len(recipients) != len(userIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sebagian penerima tidak ditemukan"})
+		return
+	}
+	// Disposisi hanya diteruskan ke bawah, admin memberi disposisi langsung
+	var notStaff []string
+	for _, r := range recipients {
+		if r.Role != "staff" {
+			notStaff = append(notStaff, r.ID)
+		}
+	}
+	if len(notStaff) > 0 {
+		respondFileError(c, &requestError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_recipients",
+			Message: "Disposisi hanya bisa diteruskan kepada staff",
+			Details: map[string]interface{}{"user_ids": notStaff},
+		})
+		return
+	}
+
+	var created []models.SuperiorOrder
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Dokumen dikunci seperti di syncDispositions agar penerusan dan
+		// perubahan penerima yang bersamaan tidak menggandakan penerima
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			First(&models.Document{}, "id = ?", parent.DocumentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&parent, "id = ?", parent.ID).Error; err != nil {
+			return err
+		}
+		if parent.Status == "completed" {
+			return &requestError{Status: http.StatusConflict, Code: "disposition_completed", Message: "Disposisi yang sudah selesai tidak bisa diteruskan"}
+		}
+		var assigned []string
+		if err := tx.Model(&models.SuperiorOrder{}).
+			Where("document_id = ? AND user_id IN ?", parent.DocumentID, userIDs).
+			Pluck("user_id", &assigned).Error; err != nil {
+			return err
+		}
+		if len(assigned) > 0 {
+			return &requestError{
+				Status:  http.StatusConflict,
+				Code:    "already_assigned",
+				Message: "Sebagian penerima sudah menerima disposisi surat ini",
+				Details: map[string]interface{}{"user_ids": assigned},
+			}
+		}
+
+		for _, recipient := range recipients {
+			order := fields
+			order.DocumentID = parent.DocumentID
+			order.UserID = recipient.ID
+			order.CreatedByID = &user.ID
+			order.ParentOrderID = &parent.ID
+			order.Status = "sent"
+			if err := tx.Create(&order).Error; err != nil {
+				return err
+			}
+			created = append(created, order)
+		}
+		if dispositionStatusOrder[parent.Status] < dispositionStatusOrder["in_progress"] {
+			updates, err := dispositionStatusUpdates(parent, "in_progress", "")
+			if err != nil {
+				return err
+			}
+			return tx.Model(&parent).Updates(updates).Error
+		}
+		return nil
+	})
+	if err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			respondFileError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal meneruskan disposisi: " + err.Error()})
+		return
+	}
+
+	names := make([]string, 0, len(recipients))
+	for _, r := range recipients {
+		names = append(names, r.Name)
+	}
+	LogActivity(user.ID, user.Name, "FORWARD_DISPOSITION", fmt.Sprintf("Meneruskan disposisi %s kepada %s", subject, strings.Join(names, ", ")))
+	go func(orders []models.SuperiorOrder, subject, from string) {
+		for _, o := range orders {
+			msg := dispositionMessage(subject, o) + " dari " + from
+			_ = CreateNotification(o.UserID, msg, fmt.Sprintf("/dashboard/my-document/%s", o.DocumentID))
+		}
+	}(created, subject, user.Name)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Disposisi diteruskan", "data": created})
+}
+
+// ======================================================
+// DISPOSITION TREE by document_id
+// ======================================================
+// Alur disposisi satu surat: siapa memberi disposisi kepada siapa, dengan
+// instruksi dan status di setiap tahap. Isi pohon dibatasi sesuai peran
+// user, lihat komentar di bawah.
+func GetSuperiorOrderTree(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	var orders []models.SuperiorOrder
+	if err := config.DB.Preload("User").Where("document_id = ?", doc.ID).Order("created_at ASC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil disposisi: " + err.Error()})
+		return
+	}
+
+	// Nama pemberi disposisi
+	var senderIDs []string
+	for _, o := range orders {
+		if o.CreatedByID != nil {
+			senderIDs = append(senderIDs, *o.CreatedByID)
+		}
+	}
+	senders := map[string]string{}
+	if len(senderIDs) > 0 {
+		var users []models.User
+		config.DB.Select("id", "name").Where("id IN ?", senderIDs).Find(&users)
+		for _, u := range users {
+			senders[u.ID] = u.Name
+		}
+	}
+
+	byID := map[string]models.SuperiorOrder{}
+	for _, o := range orders {
+		byID[o.ID] = o
+	}
+	children := map[string][]models.SuperiorOrder{}
+	var roots []models.SuperiorOrder
+	for _, o := range orders {
+		if o.ParentOrderID != nil {
+			if _, ok := byID[*o.ParentOrderID]; ok {
+				children[*o.ParentOrderID] = append(children[*o.ParentOrderID], o)
+				continue
+			}
+		}
+		roots = append(roots, o)
+	}
+
+	// Admin dan pemilik dokumen melihat seluruh pohon. User lain hanya melihat
+	// cabangnya sendiri: disposisi yang ia terima beserta turunannya, dan
+	// jalur ke atasnya tanpa instruksi dan laporan milik orang lain.
+	full := map[string]bool{}
+	path := map[string]bool{}
+	if canEditDocument(user, doc) {
+		for _, o := range orders {
+			full[o.ID] = true
+		}
+	} else {
+		var mark func(o models.SuperiorOrder)
+		mark = func(o models.SuperiorOrder) {
+			full[o.ID] = true
+			for _, child := range children[o.ID] {
+				mark(child)
+			}
+		}
+		for _, o := range orders {
+			if o.UserID != user.ID {
+				continue
+			}
+			mark(o)
+			for p := o.ParentOrderID; p != nil; {
+				parent, ok := byID[*p]
+				if !ok || path[parent.ID] {
+					break
+				}
+				path[parent.ID] = true
+				p = parent.ParentOrderID
+			}
+		}
+	}
+	visible := 0
+
+	var build func(o models.SuperiorOrder) gin.H
+	build = func(o models.SuperiorOrder) gin.H {
+		visible++
+		node := myDispositionResponse(o)
+		delete(node, "document")
+		if !full[o.ID] {
+			delete(node, "instruction")
+			delete(node, "instruction_options")
+			delete(node, "response_note")
+		}
+		node["parent_order_id"] = o.ParentOrderID
+		node["user"] = gin.H{"id": o.User.ID, "name": o.User.Name, "role": o.User.Role}
+		node["sender"] = nil
+		if o.CreatedByID != nil {
+			node["sender"] = gin.H{"id": *o.CreatedByID, "name": senders[*o.CreatedByID]}
+		}
+		kids := []gin.H{}
+		for _, child := range children[o.ID] {
+			if full[child.ID] || path[child.ID] {
+				kids = append(kids, build(child))
+			}
+		}
+		node["children"] = kids
+		return node
+	}
+
+	tree := []gin.H{}
+	for _, o := range roots {
+		if full[o.ID] || path[o.ID] {
+			tree = append(tree, build(o))
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"document_id": doc.ID,
+		"subject":     doc.Subject,
+		"total":       visible,
+		"tree":        tree,
+	})
+}
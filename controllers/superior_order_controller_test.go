@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dinsos_kuburaya/models"
+)
+
+func TestDispositionInputParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           dispositionInput
+		wantOptions  []string
+		wantDue      string
+		wantPriority string
+		wantErr      bool
+	}{
+		{"kosong", dispositionInput{}, []string{}, "", "biasa", false},
+		{
+			"lengkap",
+			dispositionInput{Instruction: "  Segera tindak lanjuti ", InstructionOptions: []string{"Tindak Lanjuti", "koordinasikan", "tindak-lanjuti"}, DueDate: "2025-03-20", Priority: "Sangat Segera"},
+			[]string{"tindak_lanjuti", "koordinasikan"}, "2025-03-20", "sangat_segera", false,
+		},
+		{"instruksi terlalu panjang", dispositionInput{Instruction: strings.Repeat("a", 2001)}, nil, "", "", true},
+		{"pilihan tidak dikenal", dispositionInput{InstructionOptions: []string{"musnahkan"}}, nil, "", "", true},
+		{"format tanggal salah", dispositionInput{DueDate: "20-03-2025"}, nil, "", "", true},
+		{"prioritas tidak dikenal", dispositionInput{Priority: "kilat"}, nil, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := tt.in.parse()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if order.Instruction != strings.TrimSpace(tt.in.Instruction) {
+				t.Errorf("instruction = %q", order.Instruction)
+			}
+			if !reflect.DeepEqual(order.InstructionOptions, tt.wantOptions) {
+				t.Errorf("instruction_options = %v, want %v", order.InstructionOptions, tt.wantOptions)
+			}
+			due := ""
+			if order.DueDate != nil {
+				due = order.DueDate.Format("2006-01-02")
+			}
+			if due != tt.wantDue {
+				t.Errorf("due_date = %q, want %q", due, tt.wantDue)
+			}
+			if order.Priority != tt.wantPriority {
+				t.Errorf("priority = %q, want %q", order.Priority, tt.wantPriority)
+			}
+		})
+	}
+}
+
+func TestDispositionStatusUpdates(t *testing.T) {
+	earlier := time.Now().Add(-time.Hour)
+	read := models.SuperiorOrder{Status: "read", ReadAt: &earlier}
+	started := models.SuperiorOrder{Status: "in_progress", ReadAt: &earlier, StartedAt: &earlier}
+	reported := models.SuperiorOrder{Status: "in_progress", ReadAt: &earlier, StartedAt: &earlier, ResponseNote: "Sudah dikoordinasikan"}
+
+	tests := []struct {
+		name     string
+		order    models.SuperiorOrder
+		status   string
+		note     string
+		wantCode string
+		wantSet  []string // kolom yang harus diubah
+	}{
+		{"dibaca", models.SuperiorOrder{Status: "sent"}, "read", "", "", []string{"status", "read_at"}},
+		{"langsung dikerjakan", models.SuperiorOrder{Status: "sent"}, "in_progress", "", "", []string{"status", "read_at", "started_at"}},
+		{"dikerjakan setelah dibaca", read, "in_progress", "", "", []string{"status", "started_at"}},
+		{"perbarui laporan sementara", started, "in_progress", "Progres 50%", "", []string{"status", "response_note"}},
+		{"selesai dengan laporan", started, "completed", "Selesai", "", []string{"status", "response_note", "completed_at"}},
+		{"selesai memakai laporan lama", reported, "completed", "", "", []string{"status", "completed_at"}},
+		{"langsung selesai", models.SuperiorOrder{Status: "sent"}, "completed", "Selesai", "", []string{"status", "response_note", "read_at", "started_at", "completed_at"}},
+		{"status tidak dikenal", read, "done", "", "invalid_status", nil},
+		{"kembali ke sent", read, "sent", "", "invalid_status", nil},
+		{"mundur", started, "read", "", "invalid_transition", nil},
+		{"dibaca dua kali", read, "read", "", "invalid_transition", nil},
+		{"sudah selesai", models.SuperiorOrder{Status: "completed"}, "completed", "Lagi", "invalid_transition", nil},
+		{"selesai tanpa laporan", started, "completed", "  ", "response_required", nil},
+		{"laporan terlalu panjang", started, "in_progress", strings.Repeat("x", 5001), "invalid_note", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates, err := dispositionStatusUpdates(tt.order, tt.status, tt.note)
+			if tt.wantCode != "" {
+				var reqErr *requestError
+				if !errors.As(err, &reqErr) || reqErr.Code != tt.wantCode {
+					t.Fatalf("error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(updates) != len(tt.wantSet) {
+				t.Errorf("updates = %v, want kolom %v", updates, tt.wantSet)
+			}
+			for _, key := range tt.wantSet {
+				if _, ok := updates[key]; !ok {
+					t.Errorf("kolom %s tidak diubah", key)
+				}
+			}
+			if updates["status"] != tt.status {
+				t.Errorf("status = %v, want %s", updates["status"], tt.status)
+			}
+		})
+	}
+}
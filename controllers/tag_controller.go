@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Warna tag dalam format hex (#1e88e5), boleh kosong
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// documentTags mengambil tag untuk sekumpulan dokumen sekaligus.
+func documentTags(documentIDs []string) map[string][]models.Tag {
+	result := map[string][]models.Tag{}
+	if len(documentIDs) == 0 {
+		return result
+	}
+	var links []models.DocumentTag
+	config.DB.Preload("Tag").Where("document_id IN ?", documentIDs).Find(&links)
+	for _, l := range links {
+		result[l.DocumentID] = append(result[l.DocumentID], l.Tag)
+	}
+	for id := range result {
+		tags := result[id]
+		sort.Slice(tags, func(i, j int) bool { return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name) })
+	}
+	return result
+}
+
+// tagsOf mengembalikan slice kosong (bukan null di JSON) untuk dokumen tanpa tag.
+func tagsOf(tags map[string][]models.Tag, documentID string) []models.Tag {
+	if t, ok := tags[documentID]; ok {
+		return t
+	}
+	return []models.Tag{}
+}
+
+// splitIDs memecah daftar id dipisah koma dari query string.
+func splitIDs(v string) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, id := range strings.Split(v, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+type tagInput struct {
+	Name  string `json:"name" binding:"required"`
+	Color string `json:"color"`
+}
+
+func (in *tagInput) validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Color = strings.TrimSpace(in.Color)
+	if in.Name == "" || len([]rune(in.Name)) > 100 {
+		return errors.New("nama tag wajib diisi, maksimal 100 karakter")
+	}
+	if in.Color != "" && !tagColorPattern.MatchString(in.Color) {
+		return errors.New("warna harus berformat hex, contoh #1e88e5")
+	}
+	return nil
+}
+
+// ======================================================
+// GET TAGS
+// ======================================================
+// Jumlah dokumen per tag hanya menghitung dokumen yang boleh dilihat user.
+// Query origin (admin/staff) membatasi hitungan seperti filter daftar dokumen.
+func GetTags(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var tags []models.Tag
+	if err := config.DB.Order("name ASC").Find(&tags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil tag: " + err.Error()})
+		return
+	}
+
+	query := config.DB.Table("document_tags").
+		Select("document_tags.tag_id, COUNT(*) AS total").
+		Joins("JOIN documents ON documents.id = document_tags.document_id AND documents.deleted_at IS NULL")
+	if origin := c.Query("origin"); origin == "admin" || origin == "staff" {
+		query = query.Where("documents.origin = ?", origin)
+	}
+	query = visibleDocuments(query, user)
+
+	var counts []struct {
+		TagID string
+		Total int64
+	}
+	if err := query.Group("document_tags.tag_id").Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghitung dokumen per tag: " + err.Error()})
+		return
+	}
+	totals := map[string]int64{}
+	for _, tc := range counts {
+		totals[tc.TagID] = tc.Total
+	}
+
+	response := []gin.H{}
+	for _, t := range tags {
+		response = append(response, gin.H{
+			"id":             t.ID,
+			"name":           t.Name,
+			"color":          t.Color,
+			"created_by_id":  t.CreatedByID,
+			"created_at":     t.CreatedAt,
+			"document_count": totals[t.ID],
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// ======================================================
+// CREATE TAG
+// ======================================================
+// Semua user boleh membuat tag; ubah dan hapus hanya admin.
+func CreateTag(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input tagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	var existing models.Tag
+	if err := config.DB.First(&existing, "name = ?", input.Name).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tag " + input.Name + " sudah ada", "tag": existing})
+		return
+	}
+
+	tag := models.Tag{Name: input.Name, Color: input.Color, CreatedByID: &user.ID}
+	if err := config.DB.Create(&tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan tag: " + err.Error()})
+		return
+	}
+	CreateActivityLog(user.ID, user.Name, "CREATE_TAG", "Membuat tag: "+tag.Name)
+	c.JSON(http.StatusCreated, gin.H{"message": "Tag berhasil dibuat", "tag": tag})
+}
+
+// ======================================================
+// UPDATE TAG (ADMIN)
+// ======================================================
+func UpdateTag(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var tag models.Tag
+	if err := config.DB.First(&tag, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag tidak ditemukan"})
+		return
+	}
+
+	var input tagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.Tag{}).Where("name = ? AND id <> ?", input.Name, tag.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tag " + input.Name + " sudah ada"})
+		return
+	}
+
+	oldName := tag.Name
+	if err := config.DB.Model(&tag).Updates(map[string]interface{}{"name": input.Name, "color": input.Color}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan tag: " + err.Error()})
+		return
+	}
+	tag.Name, tag.Color = input.Name, input.Color
+
+	CreateActivityLog(user.ID, user.Name, "UPDATE_TAG", fmt.Sprintf("Mengubah tag %s menjadi %s", oldName, tag.Name))
+	c.JSON(http.StatusOK, gin.H{"message": "Tag berhasil diperbarui", "tag": tag})
+}
+
+// ======================================================
+// DELETE TAG (ADMIN)
+// ======================================================
+// Tag dilepas dari semua dokumen lewat ON DELETE CASCADE.
+func DeleteTag(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var tag models.Tag
+	if err := config.DB.First(&tag, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag tidak ditemukan"})
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.DocumentTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus tag: " + err.Error()})
+		return
+	}
+
+	CreateActivityLog(user.ID, user.Name, "DELETE_TAG", "Menghapus tag: "+tag.Name)
+	c.JSON(http.StatusOK, gin.H{"message": "Tag berhasil dihapus"})
+}
+
+// ======================================================
+// SET DOCUMENT TAGS
+// ======================================================
+// Body: tag_ids (daftar lengkap, menggantikan tag sebelumnya). Tag hanya
+// label sehingga tetap bisa diubah pada surat yang terkunci alur persetujuan.
+func SetDocumentTags(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		TagIDs []string `json:"tag_ids"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	tagIDs := splitIDs(strings.Join(input.TagIDs, ","))
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+
+	if len(tagIDs) > 0 {
+		var count int64
+		config.DB.Model(&models.Tag{}).Where("id IN ?", tagIDs).Count(&count)
+		if int(count) != len(tagIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sebagian tag tidak ditemukan"})
+			return
+		}
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", doc.ID).Delete(&models.DocumentTag{}).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+		links := make([]models.DocumentTag, 0, len(tagIDs))
+		for _, id := range tagIDs {
+			links = append(links, models.DocumentTag{DocumentID: doc.ID, TagID: id})
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan tag dokumen: " + err.Error()})
+		return
+	}
+
+	LogActivity(user.ID, user.Name, "UPDATE_DOCUMENT_TAGS", "Mengubah tag dokumen: "+doc.Subject)
+	c.JSON(http.StatusOK, gin.H{"message": "Tag dokumen diperbarui", "tags": tagsOf(documentTags([]string{doc.ID}), doc.ID)})
+}
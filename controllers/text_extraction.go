@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/ocr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resetExtractionFields dipakai saat ekstraksi diulang agar teks lama tidak ikut dicari.
+func resetExtractionFields() map[string]interface{} {
+	return map[string]interface{}{
+		"extracted_text":    "",
+		"extraction_status": "pending",
+		"extraction_error":  "",
+		"extracted_at":      nil,
+	}
+}
+
+// queueTextExtraction menjalankan ekstraksi teks di background.
+func queueTextExtraction(id string) {
+	if !ocr.Enabled() {
+		return
+	}
+	runFileJob("extract", id, func() { runTextExtraction(id) })
+}
+
+// runTextExtraction mengunduh file aktif dokumen ke file sementara lalu
+// menjalankan pdftotext/tesseract dan menyimpan hasilnya.
+func runTextExtraction(id string) {
+	var doc models.Document
+	if err := config.DB.First(&doc, "id = ?", id).Error; err != nil {
+		return
+	}
+	current := documentDownloadable(doc)
+
+	// Hasil hanya disimpan jika file dokumen belum diganti selama proses berjalan
+	finish := func(updates map[string]interface{}) {
+		config.DB.Model(&models.Document{}).
+			Where("id = ? AND storage_key = ?", id, current.Key).
+			UpdateColumns(updates)
+	}
+
+	if current.Key == "" {
+		finish(map[string]interface{}{"extraction_status": "skipped", "extraction_error": "File lama tanpa storage key"})
+		return
+	}
+
+	config.DB.Model(&models.Document{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"extraction_status": "processing", "extraction_started_at": time.Now()})
+
+	text, method, err := extractStoredFile(current)
+	now := time.Now()
+	switch {
+	case errors.Is(err, ocr.ErrUnsupported):
+		finish(map[string]interface{}{"extraction_status": "skipped", "extraction_error": err.Error(), "extracted_at": now})
+	case err != nil:
+		fmt.Printf("Warning: ekstraksi teks %s gagal: %v\n", id, err)
+		finish(map[string]interface{}{"extraction_status": "failed", "extraction_error": err.Error(), "extracted_at": now})
+	default:
+		finish(map[string]interface{}{
+			"extracted_text":    text,
+			"extraction_status": "completed",
+			"extraction_error":  "",
+			"extracted_at":      now,
+		})
+		fmt.Printf("Ekstraksi teks %s selesai dengan %s (%d karakter)\n", id, method, len(text))
+	}
+}
+
+func extractStoredFile(file downloadable) (string, string, error) {
+	path, ext, cleanup, err := downloadToTemp(file)
+	if err != nil {
+		return "", "", err
+	}
+	defer cleanup()
+
+	result, err := ocr.Extract(context.Background(), path, ext)
+	return result.Text, result.Method, err
+}
+
+// ProcessPendingExtractions dijalankan scheduler untuk memproses dokumen yang
+// belum diekstrak, termasuk dokumen lama dan proses yang terhenti karena restart.
+func ProcessPendingExtractions() {
+	if !ocr.Enabled() {
+		return
+	}
+
+	stale := time.Now().Add(-staleFileJobAfter)
+	var ids []string
+	config.DB.Model(&models.Document{}).
+		Where("extraction_status = ? OR (extraction_status = ? AND (extraction_started_at IS NULL OR extraction_started_at < ?))",
+			"pending", "processing", stale).
+		Order("created_at DESC").
+		Limit(fileJobBatchSize).
+		Pluck("id", &ids)
+
+	for _, id := range ids {
+		queueTextExtraction(id)
+	}
+}
+
+// ======================================================
+// GET TEXT EXTRACTION STATUS
+// ======================================================
+func GetTextExtraction(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canAccessDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak memiliki akses"})
+		return
+	}
+
+	response := gin.H{
+		"document_id":  doc.ID,
+		"source":       documentSource(doc),
+		"status":       doc.ExtractionStatus,
+		"error":        doc.ExtractionError,
+		"extracted_at": doc.ExtractedAt,
+		"text_length":  len([]rune(doc.ExtractedText)),
+	}
+	// Teks lengkap hanya dikirim jika diminta karena bisa sangat panjang
+	if c.Query("include_text") == "1" {
+		response["text"] = doc.ExtractedText
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ======================================================
+// RETRY TEXT EXTRACTION
+// ======================================================
+func RetryTextExtraction(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	doc, found := findDocument(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dokumen tidak ditemukan"})
+		return
+	}
+	if !canEditDocument(user, doc) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki izin untuk mengubah dokumen ini"})
+		return
+	}
+	if !ocr.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Ekstraksi teks tidak aktif di server ini"})
+		return
+	}
+
+	if err := config.DB.Model(&doc).
+		UpdateColumns(resetExtractionFields()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengulang ekstraksi: " + err.Error()})
+		return
+	}
+	queueTextExtraction(doc.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Ekstraksi teks dijadwalkan ulang", "status": "pending"})
+}
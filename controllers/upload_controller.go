@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sesi upload yang tidak selesai dalam waktu ini dianggap kedaluwarsa
+const uploadSessionTTL = 24 * time.Hour
+
+// Kunci per sesi upload agar dua PATCH untuk upload yang sama tidak menulis
+// bersamaan, dan sesi tidak dihapus selama sebuah PATCH masih menulis
+var uploadLocks sync.Map
+
+// tryLockUpload mengambil kunci sesi upload tanpa menunggu.
+func tryLockUpload(id string) (*sync.Mutex, bool) {
+	lock, _ := uploadLocks.LoadOrStore(id, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	return mu, mu.TryLock()
+}
+
+// removeUpload menghapus file sementara dan sesi upload. Dipanggil sambil
+// memegang kunci sesi; kuncinya dibuang sebelum dilepas.
+func removeUpload(upload models.Upload, mu *sync.Mutex) {
+	os.Remove(uploadStagingPath(upload.ID))
+	config.DB.Delete(&upload)
+	uploadLocks.Delete(upload.ID)
+	mu.Unlock()
+}
+
+func uploadStagingDir() string {
+	dir := os.Getenv("UPLOAD_TMP_DIR")
+	if dir == "" {
+		dir = "uploads_tmp"
+	}
+	return dir
+}
+
+func uploadStagingPath(id string) string {
+	return filepath.Join(uploadStagingDir(), id+".part")
+}
+
+// maxUploadSize membaca UPLOAD_MAX_SIZE_MB (default 100 MB).
+func maxUploadSize() int64 {
+	if mb, err := strconv.ParseInt(os.Getenv("UPLOAD_MAX_SIZE_MB"), 10, 64); err == nil && mb > 0 {
+		return mb << 20
+	}
+	return 100 << 20
+}
+
+func setUploadHeaders(c *gin.Context, upload models.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "no-store")
+}
+
+// findOwnUpload mengambil sesi upload milik user yang sedang login.
+func findOwnUpload(c *gin.Context, user models.User) (models.Upload, bool) {
+	var upload models.Upload
+	if err := config.DB.First(&upload, "id = ? AND user_id = ?", c.Param("id"), user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sesi upload tidak ditemukan"})
+		return upload, false
+	}
+	return upload, true
+}
+
+// ======================================================
+// CREATE UPLOAD SESSION
+// ======================================================
+func CreateUpload(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	var input struct {
+		FileName    string `json:"file_name" binding:"required"`
+		Size        int64  `json:"size" binding:"required"`
+		ContentType string `json:"content_type"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	if input.Size <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ukuran file tidak valid"})
+		return
+	}
+	if input.Size > maxUploadSize() {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Ukuran file melebihi batas maksimal"})
+		return
+	}
+	// Tolak lebih awal supaya client tidak sia-sia mengirim file yang pasti ditolak
+	if _, err := checkUploadMeta(input.FileName, input.Size); err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	if err := os.MkdirAll(uploadStagingDir(), 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyiapkan folder upload"})
+		return
+	}
+
+	upload := models.Upload{
+		UserID:      user.ID,
+		FileName:    filepath.Base(input.FileName),
+		ContentType: input.ContentType,
+		Size:        input.Size,
+		Status:      "uploading",
+		ExpiresAt:   time.Now().Add(uploadSessionTTL),
+	}
+	if err := config.DB.Create(&upload).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat sesi upload: " + err.Error()})
+		return
+	}
+
+	f, err := os.Create(uploadStagingPath(upload.ID))
+	if err != nil {
+		config.DB.Delete(&upload)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat file sementara"})
+		return
+	}
+	f.Close()
+
+	go cleanupExpiredUploads()
+
+	setUploadHeaders(c, upload)
+	c.Header("Location", "/api/uploads/"+upload.ID)
+	c.JSON(http.StatusCreated, gin.H{"message": "Sesi upload dibuat", "upload": upload})
+}
+
+// ======================================================
+// GET UPLOAD STATUS (HEAD & GET)
+// ======================================================
+func GetUpload(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	upload, ok := findOwnUpload(c, user)
+	if !ok {
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"upload": upload})
+}
+
+// ======================================================
+// PATCH UPLOAD CHUNK
+// ======================================================
+// Body request adalah potongan file mentah yang dimulai pada header Upload-Offset.
+// Data langsung di-stream ke file staging tanpa ditampung di memori.
+func PatchUpload(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	// Kunci hanya dibuat untuk sesi milik user sendiri, lalu sesi dibaca ulang
+	// karena offset bisa berubah selama request lain memegang kunci
+	if _, ok := findOwnUpload(c, user); !ok {
+		return
+	}
+	mu, locked := tryLockUpload(c.Param("id"))
+	if !locked {
+		c.JSON(http.StatusLocked, gin.H{"error": "Upload sedang diproses oleh request lain"})
+		return
+	}
+	defer mu.Unlock()
+
+	upload, ok := findOwnUpload(c, user)
+	if !ok {
+		// Sesi dihapus sebelum kunci didapat, kunci yang baru dibuat tidak dipakai lagi
+		uploadLocks.Delete(c.Param("id"))
+		return
+	}
+
+	if upload.Status != "uploading" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload sudah selesai"})
+		return
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "Sesi upload sudah kedaluwarsa"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Header Upload-Offset wajib diisi"})
+		return
+	}
+	if offset != upload.Offset {
+		setUploadHeaders(c, upload)
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset tidak sesuai", "offset": upload.Offset})
+		return
+	}
+
+	f, err := os.OpenFile(uploadStagingPath(upload.ID), os.O_WRONLY, 0o644)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "File sementara tidak ditemukan"})
+		return
+	}
+
+	// Simpan data yang sempat diterima walaupun koneksi putus di tengah jalan,
+	// supaya client bisa melanjutkan dari offset terakhir
+	remaining := upload.Size - upload.Offset
+	written, copyErr := io.Copy(io.NewOffsetWriter(f, upload.Offset), io.LimitReader(c.Request.Body, remaining))
+	closeErr := f.Close()
+
+	if closeErr == nil && written > 0 {
+		upload.Offset += written
+		if upload.Offset == upload.Size {
+			upload.Status = "completed"
+		}
+		if err := config.DB.Model(&upload).Updates(map[string]interface{}{
+			"offset": upload.Offset,
+			"status": upload.Status,
+		}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan progres upload"})
+			return
+		}
+	}
+
+	if copyErr != nil || closeErr != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Upload terputus", "offset": upload.Offset})
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusNoContent)
+}
+
+// ======================================================
+// CANCEL UPLOAD
+// ======================================================
+func DeleteUpload(c *gin.Context) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user := userRaw.(models.User)
+
+	upload, ok := findOwnUpload(c, user)
+	if !ok {
+		return
+	}
+	mu, locked := tryLockUpload(upload.ID)
+	if !locked {
+		c.JSON(http.StatusLocked, gin.H{"error": "Upload sedang diproses oleh request lain"})
+		return
+	}
+	removeUpload(upload, mu)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sesi upload dibatalkan"})
+}
+
+// cleanupExpiredUploads menghapus sesi upload yang kedaluwarsa beserta file
+// sementaranya. Sesi yang sedang menerima PATCH dilewati sampai putaran berikutnya.
+func cleanupExpiredUploads() {
+	var expired []models.Upload
+	if err := config.DB.Where("expires_at < ?", time.Now()).Find(&expired).Error; err != nil {
+		fmt.Printf("Warning: gagal membersihkan upload kedaluwarsa: %v\n", err)
+		return
+	}
+	for _, upload := range expired {
+		mu, locked := tryLockUpload(upload.ID)
+		if !locked {
+			continue
+		}
+		removeUpload(upload, mu)
+	}
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Jumlah byte awal file yang dibaca untuk mendeteksi tipe konten
+const sniffLength = 3072
+
+// fileCategory mengelompokkan ekstensi yang punya batas ukuran yang sama.
+type fileCategory struct {
+	Name           string
+	SizeEnv        string
+	DefaultMaxSize int64
+}
+
+var (
+	categoryImage  = fileCategory{Name: "image", SizeEnv: "UPLOAD_MAX_SIZE_IMAGE_MB", DefaultMaxSize: 10 << 20}
+	categoryPDF    = fileCategory{Name: "pdf", SizeEnv: "UPLOAD_MAX_SIZE_PDF_MB", DefaultMaxSize: 50 << 20}
+	categoryOffice = fileCategory{Name: "office", SizeEnv: "UPLOAD_MAX_SIZE_OFFICE_MB", DefaultMaxSize: 25 << 20}
+)
+
+func (fc fileCategory) MaxSize() int64 {
+	if mb, err := strconv.ParseInt(os.Getenv(fc.SizeEnv), 10, 64); err == nil && mb > 0 {
+		return mb << 20
+	}
+	return fc.DefaultMaxSize
+}
+
+// allowedFileType: ekstensi yang diterima beserta tipe konten yang harus cocok.
+type allowedFileType struct {
+	Category fileCategory
+	MIMEs    []string
+}
+
+// Kebijakan upload yang sama untuk dokumen admin dan staff.
+// File OOXML (docx/xlsx/pptx) kadang hanya terdeteksi sebagai zip dari
+// potongan awal file, jadi application/zip juga diterima untuk ketiganya.
+var uploadPolicy = map[string]allowedFileType{
+	".jpg":  {categoryImage, []string{"image/jpeg"}},
+	".jpeg": {categoryImage, []string{"image/jpeg"}},
+	".png":  {categoryImage, []string{"image/png"}},
+	".gif":  {categoryImage, []string{"image/gif"}},
+	".webp": {categoryImage, []string{"image/webp"}},
+	".pdf":  {categoryPDF, []string{"application/pdf"}},
+	".doc":  {categoryOffice, []string{"application/msword", "application/x-ole-storage"}},
+	".xls":  {categoryOffice, []string{"application/vnd.ms-excel", "application/x-ole-storage"}},
+	".ppt":  {categoryOffice, []string{"application/vnd.ms-powerpoint", "application/x-ole-storage"}},
+	".docx": {categoryOffice, []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip"}},
+	".xlsx": {categoryOffice, []string{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/zip"}},
+	".pptx": {categoryOffice, []string{"application/vnd.openxmlformats-officedocument.presentationml.presentation", "application/zip"}},
+}
+
+func allowedExtensions() []string {
+	exts := make([]string, 0, len(uploadPolicy))
+	for ext := range uploadPolicy {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+func unsupportedTypeError(ext, detected string) *requestError {
+	details := map[string]interface{}{
+		"extension":          ext,
+		"allowed_extensions": allowedExtensions(),
+	}
+	if detected != "" {
+		details["detected_type"] = detected
+	}
+	return &requestError{
+		Status:  http.StatusUnsupportedMediaType,
+		Code:    "unsupported_media_type",
+		Message: "Tipe file tidak didukung atau isi file tidak sesuai dengan ekstensinya",
+		Details: details,
+	}
+}
+
+// checkUploadMeta memeriksa ekstensi dan ukuran sebelum isi file dibaca,
+// dipakai juga saat membuat sesi upload bertahap.
+func checkUploadMeta(fileName string, size int64) (allowedFileType, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	policy, ok := uploadPolicy[ext]
+	if !ok {
+		return allowedFileType{}, unsupportedTypeError(ext, "")
+	}
+
+	if max := policy.Category.MaxSize(); size > max {
+		return allowedFileType{}, &requestError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    "file_too_large",
+			Message: "Ukuran file melebihi batas maksimal",
+			Details: map[string]interface{}{
+				"category": policy.Category.Name,
+				"size":     size,
+				"max_size": max,
+			},
+		}
+	}
+	return policy, nil
+}
+
+// validateUpload mencocokkan magic bytes file dengan ekstensinya.
+// Reader yang dikembalikan tetap berisi file utuh (byte awal yang sudah dibaca
+// disambung kembali) beserta content type hasil deteksi.
+func validateUpload(fileName string, size int64, src io.Reader) (io.Reader, string, error) {
+	policy, err := checkUploadMeta(fileName, size)
+	if err != nil {
+		return nil, "", err
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, "", err
+	}
+	head = head[:n]
+
+	detected := mimetype.Detect(head)
+	if !mimeMatches(detected, policy.MIMEs) {
+		return nil, "", unsupportedTypeError(strings.ToLower(filepath.Ext(fileName)), detected.String())
+	}
+
+	return io.MultiReader(bytes.NewReader(head), src), detected.String(), nil
+}
+
+// mimeMatches memeriksa tipe terdeteksi beserta induknya (mis. msword -> x-ole-storage).
+func mimeMatches(detected *mimetype.MIME, allowed []string) bool {
+	for m := detected; m != nil; m = m.Parent() {
+		if mimetype.EqualsAny(m.String(), allowed...) {
+			return true
+		}
+	}
+	return false
+}
@@ -129,6 +129,8 @@ func UpdateUser(c *gin.Context) {
 		Username string `json:"username"`
 		Password string `json:"password"`
 		Role     string `json:"role"`
+		// Hanya admin yang boleh menunjuk pemeriksa surat keluar
+		CanApproveLetters *bool `json:"can_approve_letters"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -146,6 +148,14 @@ func UpdateUser(c *gin.Context) {
 	if input.Role != "" {
 		updates["role"] = input.Role
 	}
+	if input.CanApproveLetters != nil {
+		requester, _ := c.Get("user")
+		if u, ok := requester.(models.User); !ok || u.Role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Hanya admin yang dapat menunjuk pemeriksa surat"})
+			return
+		}
+		updates["can_approve_letters"] = *input.CanApproveLetters
+	}
 	if input.Password != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 		if err != nil {
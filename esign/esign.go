@@ -0,0 +1,221 @@
+package esign
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mozilla.org/pkcs7"
+)
+
+// ErrDisabled dikembalikan jika tanda tangan elektronik tidak aktif di server.
+var ErrDisabled = errors.New("tanda tangan elektronik tidak aktif")
+
+// Certificate adalah ringkasan sertifikat penanda tangan untuk ditampilkan ke user.
+type Certificate struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	Fingerprint string    `json:"fingerprint"` // SHA-256 dari sertifikat DER, hex
+	NotAfter    time.Time `json:"not_after"`
+}
+
+// Config berisi lokasi kunci, sertifikat dan qpdf.
+type Config struct {
+	KeyFile       string
+	CertFile      string
+	QPDF          string
+	VerifyBaseURL string
+	Timeout       time.Duration
+}
+
+var (
+	config  Config
+	enabled bool
+	signer  crypto.Signer
+	cert    *x509.Certificate
+)
+
+// Enabled bernilai true jika kunci, sertifikat dan qpdf tersedia.
+func Enabled() bool {
+	return enabled
+}
+
+// Init membaca konfigurasi dari environment:
+//
+//   - ESIGN_KEY_FILE: kunci privat PEM (RSA atau ECDSA) milik instansi
+//   - ESIGN_CERT_FILE: sertifikat PEM yang cocok dengan kunci tersebut
+//   - QPDF_PATH: lokasi qpdf untuk menempel cap ke PDF (default dari PATH)
+//   - ESIGN_VERIFY_BASE_URL: alamat halaman verifikasi yang dimasukkan ke QR code,
+//     kode verifikasi ditambahkan di belakangnya (default http://localhost:8080/api/verify/)
+//   - ESIGN_TIMEOUT_SECONDS: batas waktu qpdf per file (default 60)
+func Init() {
+	config = Config{
+		KeyFile:       os.Getenv("ESIGN_KEY_FILE"),
+		CertFile:      os.Getenv("ESIGN_CERT_FILE"),
+		QPDF:          envOrDefault("QPDF_PATH", "qpdf"),
+		VerifyBaseURL: envOrDefault("ESIGN_VERIFY_BASE_URL", "http://localhost:8080/api/verify/"),
+		Timeout:       time.Duration(envInt("ESIGN_TIMEOUT_SECONDS", 60)) * time.Second,
+	}
+
+	if config.KeyFile == "" || config.CertFile == "" {
+		log.Println("⚠️  Tanda tangan elektronik tidak aktif: ESIGN_KEY_FILE dan ESIGN_CERT_FILE belum diatur")
+		return
+	}
+	if err := loadKeyPair(config.KeyFile, config.CertFile); err != nil {
+		log.Printf("⚠️  Tanda tangan elektronik tidak aktif: %v\n", err)
+		return
+	}
+	if _, err := exec.LookPath(config.QPDF); err != nil {
+		log.Println("⚠️  Tanda tangan elektronik tidak aktif: qpdf tidak ditemukan")
+		return
+	}
+
+	enabled = true
+	log.Printf("✅ Tanda tangan elektronik aktif: %s\n", cert.Subject.String())
+}
+
+func loadKeyPair(keyFile, certFile string) error {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return fmt.Errorf("gagal membaca sertifikat: %w", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return errors.New("ESIGN_CERT_FILE bukan sertifikat PEM")
+	}
+	parsedCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("sertifikat tidak valid: %w", err)
+	}
+
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("gagal membaca kunci privat: %w", err)
+	}
+	block, _ = pem.Decode(keyPEM)
+	if block == nil {
+		return errors.New("ESIGN_KEY_FILE bukan kunci PEM")
+	}
+	key, err := parsePrivateKey(block)
+	if err != nil {
+		return err
+	}
+
+	// Kunci harus pasangan dari sertifikat, jika tidak tanda tangan tidak bisa diverifikasi
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return err
+	}
+	certPub, err := x509.MarshalPKIXPublicKey(parsedCert.PublicKey)
+	if err != nil {
+		return err
+	}
+	if string(pub) != string(certPub) {
+		return errors.New("kunci privat tidak cocok dengan sertifikat")
+	}
+
+	signer = key
+	cert = parsedCert
+	return nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kunci privat tidak valid: %w", err)
+	}
+	s, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("tipe kunci privat tidak didukung")
+	}
+	return s, nil
+}
+
+// CertificateInfo mengembalikan ringkasan sertifikat penanda tangan.
+func CertificateInfo() Certificate {
+	if cert == nil {
+		return Certificate{}
+	}
+	sum := sha256.Sum256(cert.Raw)
+	return Certificate{
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		Fingerprint: hex.EncodeToString(sum[:]),
+		NotAfter:    cert.NotAfter,
+	}
+}
+
+// Sign membuat tanda tangan CMS/PKCS#7 terpisah (detached, .p7s) atas data
+// dengan digest SHA-256. Sertifikat penanda tangan ikut disertakan sehingga
+// file bisa diperiksa dengan `openssl cms -verify -binary -content file.pdf`.
+func Sign(data []byte) ([]byte, error) {
+	if !enabled {
+		return nil, ErrDisabled
+	}
+	sd, err := pkcs7.NewSignedData(data)
+	if err != nil {
+		return nil, err
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := sd.AddSigner(cert, signer, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, err
+	}
+	sd.Detach()
+	return sd.Finish()
+}
+
+// NewCode membuat kode verifikasi acak yang dimuat di QR code.
+func NewCode() (string, error) {
+	b := make([]byte, 15)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
+
+// VerificationURL adalah alamat yang dibuka saat QR code dipindai.
+func VerificationURL(code string) string {
+	base := config.VerifyBaseURL
+	if base == "" {
+		base = "/api/verify/"
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base + code
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
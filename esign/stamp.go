@@ -0,0 +1,190 @@
+package esign
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"rsc.io/qr"
+)
+
+// Stamp adalah isi blok tanda tangan yang ditempel di halaman terakhir surat.
+type Stamp struct {
+	LetterNumber string
+	SignerName   string
+	SignedAt     time.Time
+	Code         string
+}
+
+// Ukuran halaman cap (A4, satuan point). qpdf mengecilkan cap agar muat di
+// halaman yang lebih kecil dan menaruhnya di tengah halaman yang lebih besar.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+)
+
+// Posisi blok tanda tangan: pojok kanan bawah halaman
+const (
+	boxX      = 290
+	boxY      = 36
+	boxWidth  = 269
+	boxHeight = 104
+	qrSize    = 88
+	fontSize  = 7
+	// Perkiraan jumlah karakter Helvetica 7pt yang muat di kolom teks
+	maxLineLength = 40
+)
+
+// StampPDF menempel blok tanda tangan dan QR code verifikasi ke halaman
+// terakhir PDF di inPath lalu menulis hasilnya ke outPath.
+func StampPDF(ctx context.Context, inPath, outPath string, s Stamp) error {
+	if !enabled {
+		return ErrDisabled
+	}
+
+	overlay, err := stampPage(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp("", "esign-stamp-*.pdf")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(overlay); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, config.QPDF,
+		inPath,
+		"--overlay", tmp.Name(), "--to=z", "--from=1", "--",
+		outPath,
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("qpdf melebihi batas waktu %s", config.Timeout)
+		}
+		// Exit code 3: berhasil dengan peringatan (mis. PDF sedikit rusak tapi bisa diperbaiki)
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+			return fmt.Errorf("qpdf gagal: %v %s", err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return nil
+}
+
+// stampPage membuat PDF satu halaman berisi blok tanda tangan. PDF ditulis
+// langsung tanpa library karena isinya hanya teks dan kotak-kotak QR code.
+func stampPage(s Stamp) ([]byte, error) {
+	code, err := qr.Encode(VerificationURL(s.Code), qr.M)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat QR code: %w", err)
+	}
+
+	var content bytes.Buffer
+	// Latar putih dan garis tepi agar blok tetap terbaca di atas isi surat
+	fmt.Fprintf(&content, "q 1 g %d %d %d %d re f Q\n", boxX, boxY, boxWidth, boxHeight)
+	fmt.Fprintf(&content, "q 0.5 w 0 G %d %d %d %d re S Q\n", boxX, boxY, boxWidth, boxHeight)
+
+	// QR code digambar sebagai kotak vektor, satu kotak per modul hitam,
+	// dengan tepi kosong 4 modul di tiap sisi agar mudah dipindai
+	module := float64(qrSize) / float64(code.Size+8)
+	qrX := float64(boxX+8) + 4*module
+	qrY := float64(boxY+(boxHeight-qrSize)/2) + 4*module
+	content.WriteString("q 0 g\n")
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fmt.Fprintf(&content, "%.3f %.3f %.3f %.3f re\n",
+					qrX+float64(x)*module, qrY+float64(code.Size-1-y)*module, module, module)
+			}
+		}
+	}
+	content.WriteString("f Q\n")
+
+	lines := []struct {
+		font string
+		text string
+	}{
+		{"F2", "Ditandatangani secara elektronik"},
+		{"F1", "oleh: " + s.SignerName},
+		{"F1", "Nomor: " + s.LetterNumber},
+		{"F1", "Tanggal: " + s.SignedAt.Format("02-01-2006 15:04 MST")},
+		{"F1", "Kode verifikasi:"},
+		{"F2", s.Code},
+		{"F1", "Pindai QR code untuk memeriksa keaslian"},
+		{"F1", "dokumen ini."},
+	}
+	textX := boxX + 8 + qrSize + 8
+	textY := boxY + boxHeight - 16
+	content.WriteString("BT 0 g\n")
+	for i, line := range lines {
+		fmt.Fprintf(&content, "/%s %d Tf 1 0 0 1 %d %d Tm (%s) Tj\n",
+			line.font, fontSize, textX, textY-i*(fontSize+4), pdfString(line.text))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return pdf.Bytes(), nil
+}
+
+// pdfString meng-escape teks untuk string literal PDF dengan encoding
+// WinAnsi. Teks dipotong agar tidak keluar dari kotak, karakter di luar
+// Latin-1 diganti tanda tanya.
+func pdfString(text string) string {
+	runes := []rune(text)
+	if len(runes) > maxLineLength {
+		runes = append(runes[:maxLineLength-3], []rune("...")...)
+	}
+	var b strings.Builder
+	for _, r := range runes {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x100:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package esign
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"teks biasa", "Nomor: 001/460/DINSOS", "Nomor: 001/460/DINSOS"},
+		{"kurung dan backslash", `Surat (a)\b`, `Surat \(a\)\\b`},
+		{"karakter kontrol", "baris\nbaru\ttab", "baris baru tab"},
+		{"latin-1", "Kabupaten Kubu Raya é", "Kabupaten Kubu Raya \xe9"},
+		{"di luar latin-1", "tanda ✓", "tanda ?"},
+		{"dipotong", strings.Repeat("a", 50), strings.Repeat("a", maxLineLength-3) + "..."},
+		{"pas batas", strings.Repeat("b", maxLineLength), strings.Repeat("b", maxLineLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pdfString(tt.in); got != tt.want {
+				t.Errorf("pdfString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStampPage(t *testing.T) {
+	pdf, err := stampPage(Stamp{
+		LetterNumber: "001/460/DINSOS/III/2025",
+		SignerName:   "Kepala Dinas (Plt.)",
+		SignedAt:     time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC),
+		Code:         "ABCD1234",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("header atau trailer PDF tidak valid")
+	}
+	for _, text := range []string{"(oleh: Kepala Dinas \\(Plt.\\))", "(ABCD1234)", "(Tanggal: 14-03-2025 09:30 UTC)"} {
+		if !bytes.Contains(pdf, []byte(text)) {
+			t.Errorf("PDF tidak memuat %s", text)
+		}
+	}
+
+	// startxref harus menunjuk tabel xref, dan setiap entri xref menunjuk objeknya
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("startxref tidak ditemukan")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d tidak menunjuk tabel xref", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	if len(entries) != 6 {
+		t.Fatalf("jumlah objek = %d, want 6", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("offset objek %d salah", i+1)
+		}
+	}
+
+	// Panjang stream sesuai isi
+	sm := regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*)endstream`).FindSubmatch(pdf)
+	if sm == nil {
+		t.Fatal("stream konten tidak ditemukan")
+	}
+	if length, _ := strconv.Atoi(string(sm[1])); length != len(sm[2]) {
+		t.Errorf("/Length = %d, isi stream %d byte", length, len(sm[2]))
+	}
+}
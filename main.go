@@ -2,32 +2,70 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"dinsos_kuburaya/antivirus"
 	"dinsos_kuburaya/config"
+	"dinsos_kuburaya/controllers"
+	"dinsos_kuburaya/esign"
 	"dinsos_kuburaya/middleware"
 	"dinsos_kuburaya/models"
+	"dinsos_kuburaya/ocr"
+	"dinsos_kuburaya/preview"
 	"dinsos_kuburaya/routes"
+	"dinsos_kuburaya/scheduler"
+	"dinsos_kuburaya/storage"
 )
 
 func main() {
 	r := gin.Default()
-	r.MaxMultipartMemory = 100 << 20
+	// File multipart di atas batas ini ditulis ke file sementara, bukan memori.
+	// File besar sebaiknya memakai upload bertahap di /api/uploads.
+	r.MaxMultipartMemory = 8 << 20
 
 	config.ConnectDatabase()
 
 	if err := config.DB.AutoMigrate(
 		&models.User{},
 		&models.Document{},
+		&models.Tag{},
+		&models.DocumentTag{},
+		&models.Folder{},
+		&models.DocumentRelation{},
 		&models.SecretToken{},
 		&models.SuperiorOrder{},
-		&models.DocumentStaff{},
+		&models.DispositionReminder{},
 		&models.Notification{},
 		&models.ActivityLog{},
+		&models.Upload{},
+		&models.DocumentVersion{},
+		&models.DocumentPreview{},
+		&models.DocumentAttachment{},
+		&models.LetterNumberCounter{},
+		&models.LetterNumberFormat{},
+		&models.LetterNumber{},
+		&models.LetterWorkflowLog{},
+		&models.LetterComment{},
+		&models.DocumentSignature{},
+		&models.RetentionPolicy{},
+		&models.DisposalProposal{},
+		&models.DisposalItem{},
+		&models.DocumentImport{},
+		&models.DocumentImportRow{},
 	); err != nil {
 		log.Fatal("Gagal migrasi tabel:", err)
 	}
+	if err := models.MigrateData(config.DB); err != nil {
+		log.Fatal("Gagal migrasi data:", err)
+	}
+
+	storage.Init()
+	antivirus.Init()
+	ocr.Init()
+	preview.Init()
+	esign.Init()
 
 	// === SEEDING ADMIN PERTAMA ===
 
@@ -64,8 +102,25 @@ func main() {
 		routes.SuperiorOrderRoutes(api)
 		routes.NotificationRoutes(api)
 		routes.ActivityLogRoutes(api)
+		routes.FileRoutes(api)
+		routes.UploadRoutes(api)
+		routes.RecycleBinRoutes(api)
+		routes.NumberingRoutes(api)
+		routes.RetentionRoutes(api)
+		routes.OrganizationRoutes(api)
+		routes.ImportRoutes(api)
 	}
 
+	// ============================
+	// JOB TERJADWAL
+	// ============================
+	scheduler.Every("purge-recycle-bin", controllers.RecycleBinPurgeInterval(), controllers.PurgeRecycleBin)
+	scheduler.Every("text-extraction", 5*time.Minute, controllers.ProcessPendingExtractions)
+	scheduler.Every("document-previews", 5*time.Minute, controllers.ProcessPendingPreviews)
+	scheduler.Every("retention-check", controllers.RetentionCheckInterval(), controllers.CheckRetentionSchedule)
+	scheduler.Every("document-imports", time.Minute, controllers.ResumeDocumentImports)
+	scheduler.Every("disposition-reminders", controllers.DispositionReminderInterval(), controllers.SendDispositionReminders)
+
 	// ============================\
 	// RUN SERVER
 	// ============================
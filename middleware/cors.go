@@ -10,9 +10,9 @@ import (
 func CORSMiddleware() gin.HandlerFunc {
 	config := cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Upload-Offset", "Range", "If-Range", "If-Modified-Since"},
+		ExposeHeaders:    []string{"Content-Length", "Location", "Upload-Offset", "Upload-Length", "Upload-Expires", "Content-Disposition", "Content-Range", "Accept-Ranges"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DispositionReminder mencatat pengingat batas waktu disposisi yang sudah
+// dikirim sehingga tidak terkirim dua kali, termasuk setelah server restart.
+// Kind: due_soon (mendekati batas waktu), overdue (lewat batas waktu) dan
+// escalated (diteruskan ke pemberi disposisi). DueDate ikut disimpan agar
+// pengingat dikirim lagi jika batas waktunya diubah.
+type DispositionReminder struct {
+	ID          string        `gorm:"type:char(36);primaryKey" json:"id"`
+	OrderID     string        `gorm:"type:char(36);not null;uniqueIndex:idx_disposition_reminder" json:"order_id"`
+	Order       SuperiorOrder `gorm:"foreignKey:OrderID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Kind        string        `gorm:"type:enum('due_soon','overdue','escalated');not null;uniqueIndex:idx_disposition_reminder" json:"kind"`
+	DueDate     time.Time     `gorm:"type:date;not null;uniqueIndex:idx_disposition_reminder" json:"due_date"`
+	RecipientID *string       `gorm:"type:char(36);index" json:"recipient_id"` // kosong: dikirim ke semua admin
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+func (r *DispositionReminder) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.NewString()
+	return
+}
@@ -8,18 +8,69 @@ import (
 )
 
 type Document struct {
-	ID           string    `gorm:"type:char(36);primaryKey" json:"id"`
-	FileURL      string    `gorm:"type:text" json:"file_url"`
-	Sender       string    `gorm:"type:varchar(255)" json:"sender"`
-	FileName     string    `gorm:"type:varchar(255)" json:"file_name"`
-	Subject      string    `gorm:"type:varchar(255)" json:"subject"`
-	LetterType   string    `gorm:"type:enum('masuk','keluar')" json:"letter_type"`
-	UserID       *string   `gorm:"type:char(36)" json:"user_id"`
-	User         User      `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"user"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	PublicID     string    `gorm:"type:varchar(255)" json:"public_id"`
-	ResourceType string    `gorm:"type:varchar(50)" json:"resource_type"`
+	ID             string    `gorm:"type:char(36);primaryKey" json:"id"`
+	FileURL        string    `gorm:"type:text" json:"-"` // URL storage, tidak dikirim ke client
+	Sender         string    `gorm:"type:varchar(255);index:ft_documents_text,class:FULLTEXT,priority:1" json:"sender"`
+	FileName       string    `gorm:"type:varchar(255);index:ft_documents_text,class:FULLTEXT,priority:3" json:"file_name"`
+	Subject        string    `gorm:"type:varchar(255);index:ft_documents_text,class:FULLTEXT,priority:2" json:"subject"`
+	LetterType     string    `gorm:"type:enum('masuk','keluar')" json:"letter_type"`
+	UserID         *string   `gorm:"type:char(36)" json:"user_id"`
+	User           User      `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"user"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	StorageKey     string    `gorm:"type:varchar(500)" json:"storage_key"`
+	StorageBackend string    `gorm:"type:varchar(20)" json:"storage_backend"`
+
+	// Origin: admin (dulu tabel documents) atau staff (dulu tabel document_staffs).
+	// Pemilik adalah UserID. Visibility public terbuka untuk semua user yang login,
+	// private hanya untuk admin, pemilik dan penerima disposisi.
+	Origin     string `gorm:"type:enum('admin','staff');default:'admin';index" json:"origin"`
+	Visibility string `gorm:"type:enum('public','private');default:'public';index" json:"visibility"`
+
+	// Status alur persetujuan surat keluar, kosong untuk dokumen di luar alur:
+	// draft, submitted, revision_requested, approved, numbered, sent
+	WorkflowStatus string `gorm:"type:varchar(20);default:'';index" json:"workflow_status"`
+
+	// Nomor dari buku register surat, diberikan saat dokumen dibuat
+	// (surat keluar dalam alur persetujuan: saat diberi nomor setelah disetujui)
+	RegisterNumber string `gorm:"type:varchar(100);index" json:"register_number"`
+
+	// Data agenda surat: nomor dan tanggal surat, klasifikasi, sifat, jumlah lampiran
+	LetterMetadata
+
+	// Folder tempat dokumen disimpan, kosong berarti belum dimasukkan ke folder.
+	// Tag disimpan di tabel document_tags.
+	FolderID *string `gorm:"type:char(36);index" json:"folder_id"`
+
+	// Retensi arsip sesuai JRA, dihitung job terjadwal dari kode klasifikasi dan
+	// tanggal surat: active, inactive, expired (siap dimusnahkan), permanent,
+	// proposed (masuk usulan pemusnahan). Kosong jika tidak ada JRA yang cocok.
+	RetentionPolicyID    *string    `gorm:"type:char(36);index" json:"retention_policy_id"`
+	RetentionStatus      string     `gorm:"type:varchar(20);default:'';index" json:"retention_status"`
+	RetentionActiveUntil *time.Time `gorm:"type:date" json:"retention_active_until"`
+	RetentionExpiresAt   *time.Time `gorm:"type:date" json:"retention_expires_at"`
+
+	// Hasil ekstraksi teks (pdftotext/OCR), ikut dicari lewat FULLTEXT
+	ExtractedText    string     `gorm:"type:longtext;index:ft_documents_text,class:FULLTEXT,priority:4" json:"-"`
+	ExtractionStatus string     `gorm:"type:enum('pending','processing','completed','failed','skipped');default:'pending'" json:"extraction_status"`
+	ExtractionError  string     `gorm:"type:text" json:"extraction_error,omitempty"`
+	ExtractedAt      *time.Time `json:"extracted_at"`
+	// Waktu proses ekstraksi terakhir dimulai, untuk mendeteksi proses yang terhenti
+	ExtractionStartedAt *time.Time `json:"-"`
+
+	// Thumbnail dan preview halaman, disimpan di tabel document_previews
+	PreviewStatus string `gorm:"type:enum('pending','processing','completed','failed','skipped');default:'pending'" json:"preview_status"`
+	PreviewPages  int    `gorm:"default:0" json:"preview_pages"`
+	ThumbnailURL  string `gorm:"-" json:"thumbnail_url,omitempty"`
+	// Waktu pembuatan preview terakhir dimulai, untuk mendeteksi proses yang terhenti
+	PreviewStartedAt *time.Time `json:"-"`
+
+	// Soft delete: dokumen masuk recycle bin dan baru dihapus permanen oleh job purge
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedByID *string        `gorm:"type:char(36)" json:"deleted_by_id,omitempty"`
+
+	// Skor relevansi hasil pencarian FULLTEXT, tidak disimpan di tabel
+	SearchScore float64 `gorm:"column:score;->;-:migration" json:"-"`
 }
 
 func (d *Document) BeforeCreate(tx *gorm.DB) (err error) {
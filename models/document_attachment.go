@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentAttachment adalah file lampiran surat, diurutkan berdasarkan Position.
+type DocumentAttachment struct {
+	ID             string    `gorm:"type:char(36);primaryKey" json:"id"`
+	DocumentID     string    `gorm:"type:char(36);not null;index:idx_document_attachment" json:"document_id"`
+	Position       int       `gorm:"not null;default:0" json:"position"`
+	FileName       string    `gorm:"type:varchar(255)" json:"file_name"`
+	FileURL        string    `gorm:"type:text" json:"-"`
+	StorageKey     string    `gorm:"type:varchar(500)" json:"-"`
+	StorageBackend string    `gorm:"type:varchar(20)" json:"-"`
+	Size           int64     `json:"size"`
+	ContentType    string    `gorm:"type:varchar(100)" json:"content_type"`
+	UploadedByID   *string   `gorm:"type:char(36)" json:"uploaded_by_id"`
+	UploadedBy     User      `gorm:"foreignKey:UploadedByID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"uploaded_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (a *DocumentAttachment) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.NewString()
+	return
+}
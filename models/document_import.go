@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentImport adalah satu proses impor register surat lama dari spreadsheet
+// dan arsip ZIP berisi file scan. Status:
+//   - validated: selesai dry run, menunggu dimulai
+//   - running: sedang diimpor di background (dilanjutkan otomatis setelah restart)
+//   - paused: dihentikan sementara, bisa dilanjutkan
+//   - completed: semua baris sudah diproses
+//   - failed: proses berhenti karena error di luar baris (mis. arsip hilang)
+type DocumentImport struct {
+	ID              string     `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID          string     `gorm:"type:char(36);not null;index" json:"user_id"`
+	User            User       `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Status          string     `gorm:"type:enum('validated','running','paused','completed','failed');default:'validated';index" json:"status"`
+	SpreadsheetName string     `gorm:"type:varchar(255)" json:"spreadsheet_name"`
+	ArchiveName     string     `gorm:"type:varchar(255)" json:"archive_name"`
+	SpreadsheetPath string     `gorm:"type:varchar(500)" json:"-"` // lokasi file di server selama impor
+	ArchivePath     string     `gorm:"type:varchar(500)" json:"-"`
+	Mapping         string     `gorm:"type:text" json:"-"` // JSON field dokumen -> judul kolom
+	DefaultType     string     `gorm:"type:varchar(10)" json:"default_letter_type"`
+	Visibility      string     `gorm:"type:varchar(10);default:'public'" json:"visibility"`
+	TotalRows       int        `json:"total_rows"`
+	ValidRows       int        `json:"valid_rows"`
+	InvalidRows     int        `json:"invalid_rows"`
+	ImportedRows    int        `json:"imported_rows"`
+	FailedRows      int        `json:"failed_rows"`
+	Error           string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt       *time.Time `json:"started_at"`
+	HeartbeatAt     *time.Time `json:"heartbeat_at"` // diperbarui worker setiap baris
+	FinishedAt      *time.Time `json:"finished_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (i *DocumentImport) BeforeCreate(tx *gorm.DB) (err error) {
+	i.ID = uuid.NewString()
+	return
+}
+
+// DocumentImportRow adalah hasil validasi dan impor satu baris spreadsheet.
+// Status: valid, invalid, imported, failed. Data berisi nilai baris yang sudah
+// dipetakan (JSON), DocumentID terisi setelah baris berhasil diimpor.
+type DocumentImportRow struct {
+	ID         string         `gorm:"type:char(36);primaryKey" json:"id"`
+	ImportID   string         `gorm:"type:char(36);not null;uniqueIndex:idx_import_row" json:"import_id"`
+	Import     DocumentImport `gorm:"foreignKey:ImportID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	RowNumber  int            `gorm:"not null;uniqueIndex:idx_import_row" json:"row_number"`
+	Status     string         `gorm:"type:enum('valid','invalid','imported','failed');index" json:"status"`
+	Subject    string         `gorm:"type:varchar(255)" json:"subject"`
+	FileName   string         `gorm:"type:varchar(500)" json:"file_name"` // path di dalam arsip ZIP
+	Data       string         `gorm:"type:text" json:"-"`
+	Errors     string         `gorm:"type:text" json:"-"` // JSON daftar pesan error
+	DocumentID *string        `gorm:"type:char(36)" json:"document_id"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+func (r *DocumentImportRow) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.NewString()
+	return
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentPreview adalah gambar JPEG yang dirender dari file aktif dokumen.
+// Page 0 adalah thumbnail, page 1 dan seterusnya adalah preview halaman.
+type DocumentPreview struct {
+	ID             string    `gorm:"type:char(36);primaryKey" json:"id"`
+	DocumentID     string    `gorm:"type:char(36);not null;uniqueIndex:idx_document_preview" json:"document_id"`
+	Page           int       `gorm:"not null;uniqueIndex:idx_document_preview" json:"page"`
+	Width          int       `json:"width"`
+	Height         int       `json:"height"`
+	StorageKey     string    `gorm:"type:varchar(500)" json:"-"`
+	StorageBackend string    `gorm:"type:varchar(20)" json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (p *DocumentPreview) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.NewString()
+	return
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentRelation menghubungkan dua surat: DocumentID adalah surat yang
+// membalas (reply_to), menindaklanjuti (follow_up_of) atau menggantikan
+// (supersedes) RelatedDocumentID.
+type DocumentRelation struct {
+	ID                string    `gorm:"type:char(36);primaryKey" json:"id"`
+	DocumentID        string    `gorm:"type:char(36);not null;uniqueIndex:idx_document_relation" json:"document_id"`
+	Document          Document  `gorm:"foreignKey:DocumentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	RelatedDocumentID string    `gorm:"type:char(36);not null;uniqueIndex:idx_document_relation;index" json:"related_document_id"`
+	RelatedDocument   Document  `gorm:"foreignKey:RelatedDocumentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Type              string    `gorm:"type:enum('reply_to','follow_up_of','supersedes');not null;uniqueIndex:idx_document_relation" json:"type"`
+	CreatedByID       *string   `gorm:"type:char(36)" json:"created_by_id"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func (r *DocumentRelation) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.NewString()
+	return
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentSignature adalah catatan verifikasi surat keluar yang sudah diberi
+// cap tanda tangan elektronik. Data surat disalin ke sini agar verifikasi
+// lewat QR code tetap bisa dilakukan meskipun dokumennya sudah dihapus
+// permanen; karena itu DocumentID sengaja tidak memakai foreign key. Saat
+// dokumen di-purge atau dimusnahkan, file PDF bercap dan .p7s ikut dihapus
+// dan PurgedAt diisi.
+type DocumentSignature struct {
+	ID               string  `gorm:"type:char(36);primaryKey" json:"id"`
+	DocumentID       string  `gorm:"type:char(36);not null;uniqueIndex" json:"document_id"`
+	VerificationCode string  `gorm:"type:varchar(32);not null;uniqueIndex" json:"verification_code"`
+	LetterNumber     string  `gorm:"type:varchar(100)" json:"letter_number"`
+	Subject          string  `gorm:"type:varchar(255)" json:"subject"`
+	SignerID         *string `gorm:"type:char(36)" json:"signer_id"`
+	Signer           User    `gorm:"foreignKey:SignerID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"signer"`
+	// Nama disalin karena user penanda tangan bisa berganti nama atau dihapus
+	SignerName string `gorm:"type:varchar(255)" json:"signer_name"`
+
+	// PDF yang sudah diberi cap dan tanda tangan CMS terpisah (.p7s) atas PDF tersebut
+	FileName                string `gorm:"type:varchar(255)" json:"file_name"`
+	StorageKey              string `gorm:"type:varchar(500)" json:"-"`
+	StorageBackend          string `gorm:"type:varchar(20)" json:"-"`
+	SignatureStorageKey     string `gorm:"type:varchar(500)" json:"-"`
+	SignatureStorageBackend string `gorm:"type:varchar(20)" json:"-"`
+
+	// SHA256 adalah hash PDF bercap yang dikirim ke penerima, OriginalSHA256 hash file sebelum dicap
+	SHA256                 string `gorm:"type:char(64);index" json:"sha256"`
+	OriginalSHA256         string `gorm:"type:char(64)" json:"original_sha256"`
+	CertificateSubject     string `gorm:"type:varchar(500)" json:"certificate_subject"`
+	CertificateFingerprint string `gorm:"type:char(64)" json:"certificate_fingerprint"`
+
+	SignedAt  time.Time  `json:"signed_at"`
+	PurgedAt  *time.Time `json:"purged_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (s *DocumentSignature) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.NewString()
+	return
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentVersion menyimpan setiap file yang pernah diupload untuk sebuah
+// Document. Versi dengan nomor terbesar adalah file aktif.
+type DocumentVersion struct {
+	ID             string    `gorm:"type:char(36);primaryKey" json:"id"`
+	DocumentID     string    `gorm:"type:char(36);not null;uniqueIndex:idx_document_version" json:"document_id"`
+	VersionNumber  int       `gorm:"not null;uniqueIndex:idx_document_version" json:"version_number"`
+	FileName       string    `gorm:"type:varchar(255)" json:"file_name"`
+	FileURL        string    `gorm:"type:text" json:"-"`
+	StorageKey     string    `gorm:"type:varchar(500)" json:"storage_key"`
+	StorageBackend string    `gorm:"type:varchar(20)" json:"storage_backend"`
+	Size           int64     `json:"size"`
+	ContentType    string    `gorm:"type:varchar(100)" json:"content_type"`
+	ChangeNote     string    `gorm:"type:text" json:"change_note"`
+	UploadedByID   *string   `gorm:"type:char(36)" json:"uploaded_by_id"`
+	UploadedBy     User      `gorm:"foreignKey:UploadedByID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"uploaded_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (v *DocumentVersion) BeforeCreate(tx *gorm.DB) (err error) {
+	v.ID = uuid.NewString()
+	return
+}
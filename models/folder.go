@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Folder adalah map bertingkat untuk menyusun dokumen. ParentID kosong
+// berarti folder di tingkat teratas. Satu dokumen berada di paling banyak
+// satu folder (Document.FolderID).
+type Folder struct {
+	ID          string    `gorm:"type:char(36);primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(150);not null" json:"name"`
+	ParentID    *string   `gorm:"type:char(36);index" json:"parent_id"`
+	CreatedByID *string   `gorm:"type:char(36)" json:"created_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (f *Folder) BeforeCreate(tx *gorm.DB) (err error) {
+	f.ID = uuid.NewString()
+	return
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// LetterMetadata adalah data buku agenda surat. Di-embed di Document sehingga
+// kolomnya ada langsung di tabel documents.
+type LetterMetadata struct {
+	// Nomor surat sesuai yang tertulis di surat pengirim
+	SenderLetterNumber string     `gorm:"type:varchar(100);index" json:"sender_letter_number"`
+	LetterDate         *time.Time `gorm:"type:date;index" json:"letter_date"`
+	ReceivedDate       *time.Time `gorm:"type:date;index" json:"received_date"`
+	// Kode klasifikasi arsip, mis. 460 atau 400.7.1
+	ClassificationCode string `gorm:"type:varchar(50);index" json:"classification_code"`
+	Urgency            string `gorm:"type:enum('biasa','segera','sangat_segera');default:'biasa'" json:"urgency"`
+	Confidentiality    string `gorm:"type:enum('biasa','terbatas','rahasia','sangat_rahasia');default:'biasa'" json:"confidentiality"`
+	PageCount          int    `gorm:"default:0" json:"page_count"`
+	AttachmentCount    int    `gorm:"default:0" json:"attachment_count"`
+
+	// Batas waktu balasan untuk surat masuk yang perlu dijawab
+	ReplyRequiredBy *time.Time `gorm:"type:date;index" json:"reply_required_by"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LetterNumberCounter menyimpan nomor urut terakhir per tahun dan jenis surat.
+// Baris ini dikunci (SELECT ... FOR UPDATE) setiap kali nomor diambil.
+type LetterNumberCounter struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Year       int       `gorm:"not null;uniqueIndex:idx_letter_counter" json:"year"`
+	LetterType string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_letter_counter" json:"letter_type"`
+	LastSeq    int       `gorm:"not null;default:0" json:"last_seq"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// LetterNumberFormat adalah template nomor surat per jenis surat yang bisa diubah admin.
+type LetterNumberFormat struct {
+	LetterType  string    `gorm:"type:varchar(20);primaryKey" json:"letter_type"`
+	Template    string    `gorm:"type:varchar(255);not null" json:"template"`
+	UpdatedByID *string   `gorm:"type:char(36)" json:"updated_by_id"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// LetterNumber adalah satu baris buku register (buku agenda). Nomor yang
+// dibatalkan tetap tercatat agar urutan nomor tidak pernah bolong tanpa keterangan.
+type LetterNumber struct {
+	ID                 string     `gorm:"type:char(36);primaryKey" json:"id"`
+	Number             string     `gorm:"type:varchar(100);not null;index" json:"number"`
+	Year               int        `gorm:"not null;uniqueIndex:idx_letter_number_seq" json:"year"`
+	LetterType         string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_letter_number_seq" json:"letter_type"`
+	Seq                int        `gorm:"not null;uniqueIndex:idx_letter_number_seq" json:"seq"`
+	ClassificationCode string     `gorm:"type:varchar(50)" json:"classification_code"`
+	Status             string     `gorm:"type:enum('reserved','used','cancelled');default:'reserved';index" json:"status"`
+	DocumentID         *string    `gorm:"type:char(36);index" json:"document_id"`
+	Note               string     `gorm:"type:text" json:"note"`
+	ReservedByID       *string    `gorm:"type:char(36)" json:"reserved_by_id"`
+	ReservedBy         User       `gorm:"foreignKey:ReservedByID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"reserved_by"`
+	UsedAt             *time.Time `json:"used_at"`
+	CancelledAt        *time.Time `json:"cancelled_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+func (n *LetterNumber) BeforeCreate(tx *gorm.DB) (err error) {
+	n.ID = uuid.NewString()
+	return
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LetterWorkflowLog mencatat setiap perpindahan status surat keluar
+// (draft, submitted, revision_requested, approved, numbered, sent).
+type LetterWorkflowLog struct {
+	ID         string    `gorm:"type:char(36);primaryKey" json:"id"`
+	DocumentID string    `gorm:"type:char(36);not null;index" json:"document_id"`
+	Action     string    `gorm:"type:varchar(30)" json:"action"`
+	FromStatus string    `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus   string    `gorm:"type:varchar(20)" json:"to_status"`
+	Note       string    `gorm:"type:text" json:"note"`
+	ActorID    *string   `gorm:"type:char(36)" json:"actor_id"`
+	Actor      User      `gorm:"foreignKey:ActorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"actor"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (l *LetterWorkflowLog) BeforeCreate(tx *gorm.DB) (err error) {
+	l.ID = uuid.NewString()
+	return
+}
+
+// LetterComment adalah komentar pemeriksa atau pembuat pada draft surat keluar.
+type LetterComment struct {
+	ID         string    `gorm:"type:char(36);primaryKey" json:"id"`
+	DocumentID string    `gorm:"type:char(36);not null;index" json:"document_id"`
+	UserID     *string   `gorm:"type:char(36)" json:"user_id"`
+	User       User      `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"user"`
+	Message    string    `gorm:"type:text;not null" json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (c *LetterComment) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.NewString()
+	return
+}
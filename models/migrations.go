@@ -0,0 +1,297 @@
+package models
+
+import (
+	"path"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MigrateData menjalankan migrasi data yang tidak bisa ditangani AutoMigrate.
+// Dipanggil setelah AutoMigrate dan aman dijalankan berulang kali.
+func MigrateData(db *gorm.DB) error {
+	for _, table := range []string{"documents", "document_staffs"} {
+		if err := migrateCloudinaryColumns(db, table); err != nil {
+			return err
+		}
+	}
+	if err := mergeDocumentStaffs(db); err != nil {
+		return err
+	}
+	if err := dropDocumentSourceColumns(db); err != nil {
+		return err
+	}
+	if err := backfillDocumentVersions(db); err != nil {
+		return err
+	}
+	if err := uniqueSuperiorOrders(db); err != nil {
+		return err
+	}
+	return dropObsoleteIndexes(db)
+}
+
+// dropObsoleteIndexes menghapus index yang sudah diganti index baru,
+// mis. FULLTEXT lama yang belum mencakup extracted_text.
+func dropObsoleteIndexes(db *gorm.DB) error {
+	obsolete := []struct {
+		model interface{}
+		name  string
+	}{
+		{&Document{}, "ft_documents_search"},
+	}
+
+	m := db.Migrator()
+	for _, idx := range obsolete {
+		if m.HasIndex(idx.model, idx.name) {
+			if err := m.DropIndex(idx.model, idx.name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// migrateCloudinaryColumns memindahkan public_id/resource_type lama ke
+// storage_key/storage_backend lalu menghapus kolom lama. Tabel yang tidak lagi
+// di-AutoMigrate (document_staffs versi baseline) belum punya storage_key;
+// kolom lamanya dibaca langsung oleh mergeDocumentStaffs.
+func migrateCloudinaryColumns(db *gorm.DB, table string) error {
+	m := db.Migrator()
+	if !m.HasColumn(table, "public_id") || !m.HasColumn(table, "storage_key") {
+		return nil
+	}
+
+	err := db.Table(table).
+		Where("public_id <> '' AND (storage_key IS NULL OR storage_key = '')").
+		UpdateColumns(map[string]interface{}{
+			"storage_key":     gorm.Expr("CONCAT(COALESCE(NULLIF(resource_type, ''), 'raw'), '/', public_id)"),
+			"storage_backend": "cloudinary",
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := m.DropColumn(table, "public_id"); err != nil {
+		return err
+	}
+	if m.HasColumn(table, "resource_type") {
+		return m.DropColumn(table, "resource_type")
+	}
+	return nil
+}
+
+// legacyDocumentStaff adalah baris tabel document_staffs sebelum digabung ke documents.
+// Kolom yang belum ada di tabel lama (deployment yang sangat lama) dibiarkan kosong.
+type legacyDocumentStaff struct {
+	ID             string
+	UserID         string
+	FileURL        string
+	Sender         string
+	Subject        string
+	LetterType     string
+	FileName       string // berisi URL file, bukan nama file
+	StorageKey     string
+	StorageBackend string
+	PublicID       string // kolom Cloudinary baseline, sebelum ada storage_key
+	ResourceType   string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	RegisterNumber string
+	LetterMetadata
+
+	ExtractedText    string
+	ExtractionStatus string
+	ExtractionError  string
+	ExtractedAt      *time.Time
+	PreviewStatus    string
+	PreviewPages     int
+
+	DeletedAt   *time.Time
+	DeletedByID *string
+}
+
+func (legacyDocumentStaff) TableName() string { return "document_staffs" }
+
+// Tabel lama disimpan dengan nama ini setelah isinya dipindahkan
+const legacyDocumentStaffsTable = "document_staffs_legacy"
+
+// mergeDocumentStaffs memindahkan isi document_staffs ke documents dengan ID yang
+// sama (origin staff, visibility private), sehingga versi, preview, lampiran dan
+// nomor register tetap terhubung. Tabel lama di-rename, bukan dihapus.
+func mergeDocumentStaffs(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable("document_staffs") {
+		return nil
+	}
+
+	var rows []legacyDocumentStaff
+	err := db.FindInBatches(&rows, 200, func(tx *gorm.DB, batch int) error {
+		docs := make([]Document, 0, len(rows))
+		for _, r := range rows {
+			docs = append(docs, r.toDocument())
+		}
+		// ID yang sudah ada dilewati sehingga migrasi yang terhenti bisa diulang.
+		// Hook BeforeCreate dilewati agar ID lama tidak diganti.
+		return db.Session(&gorm.Session{SkipHooks: true}).
+			Omit(clause.Associations).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&docs).Error
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return m.RenameTable("document_staffs", legacyDocumentStaffsTable)
+}
+
+func (r legacyDocumentStaff) toDocument() Document {
+	// Dokumen staff menyimpan URL di file_name, nama file dibuat dari subjek
+	name := r.Subject
+	if name == "" {
+		name = "dokumen"
+	}
+	if r.StorageKey == "" && r.PublicID != "" {
+		resourceType := r.ResourceType
+		if resourceType == "" {
+			resourceType = "raw"
+		}
+		r.StorageKey = resourceType + "/" + r.PublicID
+		r.StorageBackend = "cloudinary"
+	}
+	ext := path.Ext(r.StorageKey)
+	if ext == "" {
+		ext = path.Ext(r.FileName)
+	}
+
+	fileURL := r.FileURL
+	if fileURL == "" {
+		fileURL = r.FileName
+	}
+
+	// Kolom letter_type di documents bertipe enum, default staff adalah keluar
+	letterType := r.LetterType
+	if letterType != "masuk" && letterType != "keluar" {
+		letterType = "keluar"
+	}
+
+	doc := Document{
+		ID:               r.ID,
+		FileURL:          fileURL,
+		Sender:           r.Sender,
+		FileName:         name + ext,
+		Subject:          r.Subject,
+		LetterType:       letterType,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+		StorageKey:       r.StorageKey,
+		StorageBackend:   r.StorageBackend,
+		Origin:           "staff",
+		Visibility:       "private",
+		RegisterNumber:   r.RegisterNumber,
+		LetterMetadata:   r.LetterMetadata,
+		ExtractedText:    r.ExtractedText,
+		ExtractionStatus: r.ExtractionStatus,
+		ExtractionError:  r.ExtractionError,
+		ExtractedAt:      r.ExtractedAt,
+		PreviewStatus:    r.PreviewStatus,
+		PreviewPages:     r.PreviewPages,
+		DeletedByID:      r.DeletedByID,
+	}
+	if r.UserID != "" {
+		userID := r.UserID
+		doc.UserID = &userID
+	}
+	if r.DeletedAt != nil {
+		doc.DeletedAt = gorm.DeletedAt{Time: *r.DeletedAt, Valid: true}
+	}
+	return doc
+}
+
+// dropDocumentSourceColumns menghapus kolom document_source ("document" atau
+// "document_staff") yang tidak diperlukan lagi setelah kedua tabel digabung.
+// ID dokumen tidak berubah, jadi relasi cukup lewat document_id.
+func dropDocumentSourceColumns(db *gorm.DB) error {
+	m := db.Migrator()
+	for _, table := range []string{"document_versions", "document_previews", "document_attachments", "letter_numbers"} {
+		if m.HasColumn(table, "document_source") {
+			if err := m.DropColumn(table, "document_source"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Satu user hanya menerima satu disposisi per dokumen, baik langsung maupun
+// lanjutan. Index sementara (document_id, parent_order_id, user_id) dari versi
+// sebelumnya diganti karena tidak berlaku untuk disposisi langsung.
+const (
+	superiorOrderUniqueIndex = "idx_superior_orders_document_user"
+	superiorOrderParentIndex = "idx_superior_orders_assignment"
+)
+
+// uniqueSuperiorOrders menghapus disposisi ganda (document_id, user_id) yang
+// tersisa dari versi lama, lalu membuat unique index-nya. Index tidak ditulis di
+// tag model karena AutoMigrate akan gagal selama data ganda masih ada.
+// Disposisi paling awal dipertahankan dan turunan dari disposisi yang dihapus
+// dipindah ke bawahnya agar tidak ikut terhapus oleh cascade.
+func uniqueSuperiorOrders(db *gorm.DB) error {
+	m := db.Migrator()
+	if m.HasIndex(&SuperiorOrder{}, superiorOrderParentIndex) {
+		if err := m.DropIndex(&SuperiorOrder{}, superiorOrderParentIndex); err != nil {
+			return err
+		}
+	}
+	if m.HasIndex(&SuperiorOrder{}, superiorOrderUniqueIndex) {
+		return nil
+	}
+	var orders []SuperiorOrder
+	if err := db.Select("id", "document_id", "user_id").Order("created_at ASC, id ASC").Find(&orders).Error; err != nil {
+		return err
+	}
+	keep := map[string]string{}
+	dups := map[string]string{}
+	for _, o := range orders {
+		key := o.DocumentID + "/" + o.UserID
+		if id, ok := keep[key]; ok {
+			dups[o.ID] = id
+			continue
+		}
+		keep[key] = o.ID
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		ids := make([]string, 0, len(dups))
+		for dupID, keepID := range dups {
+			err := tx.Exec("UPDATE superior_orders SET parent_order_id = IF(id = ?, NULL, ?) WHERE parent_order_id = ?",
+				keepID, keepID, dupID).Error
+			if err != nil {
+				return err
+			}
+			ids = append(ids, dupID)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Where("id IN ?", ids).Delete(&SuperiorOrder{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	return db.Exec("CREATE UNIQUE INDEX " + superiorOrderUniqueIndex + " ON superior_orders (document_id, user_id)").Error
+}
+
+// backfillDocumentVersions mencatat file yang sedang aktif sebagai versi 1
+// untuk dokumen yang dibuat sebelum riwayat versi ada. Dokumen baru sudah
+// mendapat versi 1 saat diupload.
+func backfillDocumentVersions(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO document_versions
+		(id, document_id, version_number, file_name, file_url, storage_key, storage_backend,
+		 size, content_type, change_note, uploaded_by_id, created_at)
+		SELECT UUID(), d.id, 1, d.file_name, d.file_url, d.storage_key, d.storage_backend,
+		 0, '', 'Versi awal', d.user_id, d.updated_at
+		FROM documents d
+		WHERE (COALESCE(d.storage_key, '') <> '' OR COALESCE(d.file_url, '') <> '')
+		AND NOT EXISTS (SELECT 1 FROM document_versions v WHERE v.document_id = d.id)`).Error
+}
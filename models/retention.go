@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RetentionPolicy adalah satu baris Jadwal Retensi Arsip (JRA): masa simpan
+// aktif dan inaktif (dalam tahun) serta nasib akhir arsip untuk sebuah kode
+// klasifikasi. Kode berlaku juga untuk turunannya (400 berlaku untuk 400.7.1)
+// selama tidak ada kode yang lebih spesifik.
+type RetentionPolicy struct {
+	ID                 string    `gorm:"type:char(36);primaryKey" json:"id"`
+	ClassificationCode string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"classification_code"`
+	Description        string    `gorm:"type:varchar(255)" json:"description"`
+	ActiveYears        int       `gorm:"not null;default:0" json:"active_years"`
+	InactiveYears      int       `gorm:"not null;default:0" json:"inactive_years"`
+	FinalDisposition   string    `gorm:"type:enum('musnah','permanen');not null;default:'musnah'" json:"final_disposition"`
+	Note               string    `gorm:"type:text" json:"note"`
+	UpdatedByID        *string   `gorm:"type:char(36)" json:"updated_by_id"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func (p *RetentionPolicy) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.NewString()
+	return
+}
+
+// DisposalProposal adalah usulan pemusnahan arsip. Setelah disetujui admin,
+// dokumen di dalamnya dihapus permanen dan usulan ini menjadi berita acara
+// pemusnahan dengan nomor RecordNumber.
+type DisposalProposal struct {
+	ID           string         `gorm:"type:char(36);primaryKey" json:"id"`
+	Title        string         `gorm:"type:varchar(255)" json:"title"`
+	Note         string         `gorm:"type:text" json:"note"`
+	Status       string         `gorm:"type:enum('pending','approved','rejected');default:'pending';index" json:"status"`
+	ProposedByID *string        `gorm:"type:char(36)" json:"proposed_by_id"`
+	ProposedBy   User           `gorm:"foreignKey:ProposedByID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"proposed_by"`
+	ReviewedByID *string        `gorm:"type:char(36)" json:"reviewed_by_id"`
+	ReviewedBy   User           `gorm:"foreignKey:ReviewedByID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"reviewed_by"`
+	ReviewNote   string         `gorm:"type:text" json:"review_note"`
+	ReviewedAt   *time.Time     `json:"reviewed_at"`
+	RecordNumber string         `gorm:"type:varchar(100)" json:"record_number"` // nomor berita acara pemusnahan
+	ItemCount    int            `gorm:"default:0" json:"item_count"`
+	Items        []DisposalItem `gorm:"foreignKey:ProposalID" json:"items,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+func (p *DisposalProposal) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.NewString()
+	return
+}
+
+// DisposalItem adalah satu arsip dalam usulan pemusnahan. Data dokumen
+// disalin karena dokumennya sudah tidak ada setelah dimusnahkan.
+type DisposalItem struct {
+	ID                 string     `gorm:"type:char(36);primaryKey" json:"id"`
+	ProposalID         string     `gorm:"type:char(36);not null;index" json:"proposal_id"`
+	DocumentID         string     `gorm:"type:char(36);not null;index" json:"document_id"`
+	RegisterNumber     string     `gorm:"type:varchar(100)" json:"register_number"`
+	SenderLetterNumber string     `gorm:"type:varchar(100)" json:"sender_letter_number"`
+	Subject            string     `gorm:"type:varchar(255)" json:"subject"`
+	Sender             string     `gorm:"type:varchar(255)" json:"sender"`
+	LetterType         string     `gorm:"type:varchar(20)" json:"letter_type"`
+	ClassificationCode string     `gorm:"type:varchar(50)" json:"classification_code"`
+	LetterDate         *time.Time `gorm:"type:date" json:"letter_date"`
+	FileName           string     `gorm:"type:varchar(255)" json:"file_name"`
+	RetentionExpiresAt *time.Time `gorm:"type:date" json:"retention_expires_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+func (i *DisposalItem) BeforeCreate(tx *gorm.DB) (err error) {
+	i.ID = uuid.NewString()
+	return
+}
@@ -7,6 +7,8 @@ import (
 	"gorm.io/gorm"
 )
 
+// SuperiorOrder adalah disposisi surat kepada satu user. Pasangan
+// (document_id, user_id) unik, index-nya dibuat di MigrateData.
 type SuperiorOrder struct {
 	ID         string    `gorm:"type:char(36);primaryKey" json:"id"`
 	DocumentID string    `gorm:"type:char(36);not null" json:"document_id"`
@@ -15,6 +17,27 @@ type SuperiorOrder struct {
 	User       User      `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Isi disposisi: instruksi bebas, pilihan instruksi baku (untuk_diketahui,
+	// tindak_lanjuti, ...), batas waktu dan prioritas (biasa, segera, sangat_segera)
+	Instruction        string     `gorm:"type:text" json:"instruction"`
+	InstructionOptions []string   `gorm:"type:text;serializer:json" json:"instruction_options"`
+	DueDate            *time.Time `gorm:"type:date;index" json:"due_date"`
+	Priority           string     `gorm:"type:enum('biasa','segera','sangat_segera');default:'biasa'" json:"priority"`
+	CreatedByID        *string    `gorm:"type:char(36);index" json:"created_by_id"`
+
+	// Disposisi lanjutan: penerima meneruskan disposisinya ke bawahan.
+	// Kosong untuk disposisi langsung dari admin; CreatedByID adalah penerus.
+	ParentOrderID *string        `gorm:"type:char(36);index" json:"parent_order_id"`
+	ParentOrder   *SuperiorOrder `gorm:"foreignKey:ParentOrderID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	// Status penanganan oleh penerima: sent -> read -> in_progress -> completed.
+	// ResponseNote berisi laporan/tanggapan penerima.
+	Status       string     `gorm:"type:enum('sent','read','in_progress','completed');default:'sent';index" json:"status"`
+	ResponseNote string     `gorm:"type:text" json:"response_note"`
+	ReadAt       *time.Time `json:"read_at"`
+	StartedAt    *time.Time `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
 }
 
 func (s *SuperiorOrder) BeforeCreate(tx *gorm.DB) (err error) {
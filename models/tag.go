@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tag adalah label bebas untuk mengelompokkan dokumen (mis. program, tahun
+// anggaran, skema bantuan). Dipakai bersama oleh dokumen admin dan staff.
+type Tag struct {
+	ID          string    `gorm:"type:char(36);primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Color       string    `gorm:"type:varchar(20)" json:"color"`
+	CreatedByID *string   `gorm:"type:char(36)" json:"created_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) (err error) {
+	t.ID = uuid.NewString()
+	return
+}
+
+// DocumentTag menghubungkan dokumen dengan tag. Baris ikut terhapus saat
+// dokumen di-purge atau tag dihapus.
+type DocumentTag struct {
+	DocumentID string    `gorm:"type:char(36);primaryKey" json:"document_id"`
+	Document   Document  `gorm:"foreignKey:DocumentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	TagID      string    `gorm:"type:char(36);primaryKey;index" json:"tag_id"`
+	Tag        Tag       `gorm:"foreignKey:TagID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"tag"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Upload adalah sesi upload bertahap (resumable). Potongan file ditulis ke
+// folder staging di disk sampai lengkap, lalu dipakai oleh endpoint dokumen.
+type Upload struct {
+	ID          string    `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID      string    `gorm:"type:char(36);not null;index" json:"user_id"`
+	User        User      `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	FileName    string    `gorm:"type:varchar(255)" json:"file_name"`
+	ContentType string    `gorm:"type:varchar(100)" json:"content_type"`
+	Size        int64     `json:"size"`
+	Offset      int64     `json:"offset"`
+	Status      string    `gorm:"type:enum('uploading','completed','consumed');default:'uploading'" json:"status"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (u *Upload) BeforeCreate(tx *gorm.DB) (err error) {
+	u.ID = uuid.NewString()
+	return
+}
@@ -15,6 +15,9 @@ type User struct {
 	Role      string    `gorm:"type:enum('admin','staff')" json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Staff yang ditunjuk boleh memeriksa dan menyetujui surat keluar seperti admin
+	CanApproveLetters bool `gorm:"default:false" json:"can_approve_letters"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
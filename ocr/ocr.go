@@ -0,0 +1,215 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrUnsupported dikembalikan untuk tipe file yang tidak bisa diekstrak (mis. dokumen Office).
+var ErrUnsupported = errors.New("tipe file tidak didukung untuk ekstraksi teks")
+
+// PDF dengan teks lebih sedikit dari ini dianggap hasil scan dan di-OCR
+const minPDFTextLength = 20
+
+// Result adalah teks hasil ekstraksi satu file.
+type Result struct {
+	Text   string `json:"text"`
+	Method string `json:"method"` // pdftotext atau tesseract
+}
+
+// Config berisi lokasi program eksternal dan batasan ekstraksi.
+type Config struct {
+	Tesseract string
+	PDFToText string
+	PDFToPPM  string
+	Languages string
+	Timeout   time.Duration
+	MaxPages  int
+}
+
+var (
+	config  Config
+	enabled bool
+)
+
+// Enabled bernilai true jika ekstraksi aktif dan minimal satu program tersedia.
+func Enabled() bool {
+	return enabled
+}
+
+// Init membaca konfigurasi dari environment:
+//
+//   - OCR_ENABLED: false untuk mematikan ekstraksi (default aktif)
+//   - TESSERACT_PATH, PDFTOTEXT_PATH, PDFTOPPM_PATH: lokasi program (default dari PATH)
+//   - OCR_LANGUAGES: bahasa tesseract (default ind+eng)
+//   - OCR_TIMEOUT_SECONDS: batas waktu per file (default 300)
+//   - OCR_MAX_PAGES: jumlah halaman PDF hasil scan yang di-OCR (default 20)
+func Init() {
+	config = Config{
+		Tesseract: envOrDefault("TESSERACT_PATH", "tesseract"),
+		PDFToText: envOrDefault("PDFTOTEXT_PATH", "pdftotext"),
+		PDFToPPM:  envOrDefault("PDFTOPPM_PATH", "pdftoppm"),
+		Languages: envOrDefault("OCR_LANGUAGES", "ind+eng"),
+		Timeout:   time.Duration(envInt("OCR_TIMEOUT_SECONDS", 300)) * time.Second,
+		MaxPages:  envInt("OCR_MAX_PAGES", 20),
+	}
+
+	if os.Getenv("OCR_ENABLED") == "false" {
+		enabled = false
+		log.Println("⚠️  Ekstraksi teks/OCR dimatikan (OCR_ENABLED=false)")
+		return
+	}
+
+	var found []string
+	for _, bin := range []string{config.Tesseract, config.PDFToText} {
+		if _, err := exec.LookPath(bin); err == nil {
+			found = append(found, filepath.Base(bin))
+		}
+	}
+	enabled = len(found) > 0
+	if !enabled {
+		log.Println("⚠️  Ekstraksi teks/OCR tidak aktif: tesseract dan pdftotext tidak ditemukan")
+		return
+	}
+	log.Printf("✅ Ekstraksi teks aktif: %s\n", strings.Join(found, ", "))
+}
+
+// Extract mengambil teks dari file di path. ext menentukan cara ekstraksi:
+// PDF dicoba dengan pdftotext dulu, jika kosong (hasil scan) tiap halaman
+// dirender lalu di-OCR; gambar langsung di-OCR dengan tesseract.
+func Extract(ctx context.Context, path, ext string) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	switch strings.ToLower(ext) {
+	case ".pdf":
+		return extractPDF(ctx, path)
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".tif", ".tiff", ".bmp":
+		text, err := tesseract(ctx, path)
+		return Result{Text: text, Method: "tesseract"}, err
+	default:
+		return Result{}, ErrUnsupported
+	}
+}
+
+func extractPDF(ctx context.Context, path string) (Result, error) {
+	if _, err := exec.LookPath(config.PDFToText); err == nil {
+		out, err := run(ctx, config.PDFToText, "-layout", "-enc", "UTF-8", path, "-")
+		if err != nil {
+			return Result{}, err
+		}
+		text := cleanText(out)
+		if utf8.RuneCountInString(text) >= minPDFTextLength {
+			return Result{Text: text, Method: "pdftotext"}, nil
+		}
+	}
+
+	// PDF tanpa lapisan teks: render halaman ke PNG lalu OCR satu per satu
+	dir, err := os.MkdirTemp("", "ocr-pages-")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"-r", "300", "-gray", "-png"}
+	if config.MaxPages > 0 {
+		args = append(args, "-l", strconv.Itoa(config.MaxPages))
+	}
+	args = append(args, path, filepath.Join(dir, "page"))
+	if _, err := run(ctx, config.PDFToPPM, args...); err != nil {
+		return Result{}, err
+	}
+
+	pages, err := filepath.Glob(filepath.Join(dir, "page*.png"))
+	if err != nil {
+		return Result{}, err
+	}
+	// pdftoppm memberi nomor dengan lebar sama (page-01.png), jadi urutan string sudah benar
+	sort.Strings(pages)
+
+	var texts []string
+	for _, page := range pages {
+		text, err := tesseract(ctx, page)
+		if err != nil {
+			return Result{}, err
+		}
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return Result{Text: strings.Join(texts, "\n\n"), Method: "tesseract"}, nil
+}
+
+func tesseract(ctx context.Context, path string) (string, error) {
+	out, err := run(ctx, config.Tesseract, path, "stdout", "-l", config.Languages)
+	if err != nil {
+		return "", err
+	}
+	return cleanText(out), nil
+}
+
+func run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s melebihi batas waktu %s", filepath.Base(name), config.Timeout)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 300 {
+			msg = msg[:300]
+		}
+		return nil, fmt.Errorf("%s gagal: %v %s", filepath.Base(name), err, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// cleanText membuang karakter tidak valid dan baris kosong berulang.
+func cleanText(out []byte) string {
+	text := strings.ToValidUTF8(string(out), "")
+	text = strings.ReplaceAll(text, "\f", "\n")
+
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
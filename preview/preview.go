@@ -0,0 +1,212 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// Decoder format gambar yang bisa diupload
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrUnsupported dikembalikan untuk file yang tidak bisa dibuatkan preview.
+var ErrUnsupported = errors.New("tipe file tidak didukung untuk preview")
+
+// Page adalah satu gambar JPEG hasil render. Number 0 adalah thumbnail,
+// 1 dan seterusnya adalah halaman preview.
+type Page struct {
+	Number int
+	Image  []byte
+	Width  int
+	Height int
+}
+
+// Config berisi ukuran gambar dan lokasi pdftoppm.
+type Config struct {
+	PDFToPPM       string
+	ThumbnailWidth int
+	PreviewWidth   int
+	MaxPages       int
+	Quality        int
+	Timeout        time.Duration
+}
+
+var config = Config{
+	PDFToPPM:       "pdftoppm",
+	ThumbnailWidth: 320,
+	PreviewWidth:   1024,
+	MaxPages:       3,
+	Quality:        80,
+	Timeout:        2 * time.Minute,
+}
+
+// Init membaca konfigurasi dari environment:
+//
+//   - PDFTOPPM_PATH: lokasi pdftoppm untuk render halaman PDF (default dari PATH)
+//   - THUMBNAIL_WIDTH: lebar thumbnail dalam piksel (default 320)
+//   - PREVIEW_WIDTH: lebar gambar preview halaman (default 1024)
+//   - PREVIEW_MAX_PAGES: jumlah halaman PDF yang dibuatkan preview (default 3)
+func Init() {
+	config.PDFToPPM = envOrDefault("PDFTOPPM_PATH", "pdftoppm")
+	config.ThumbnailWidth = envInt("THUMBNAIL_WIDTH", 320)
+	config.PreviewWidth = envInt("PREVIEW_WIDTH", 1024)
+	config.MaxPages = envInt("PREVIEW_MAX_PAGES", 3)
+
+	if _, err := exec.LookPath(config.PDFToPPM); err != nil {
+		log.Println("⚠️  pdftoppm tidak ditemukan, preview hanya dibuat untuk gambar")
+		return
+	}
+	log.Println("✅ Preview dokumen aktif untuk PDF dan gambar")
+}
+
+// Generate membuat thumbnail dan preview halaman dari file di path.
+func Generate(ctx context.Context, path, ext string) (Page, []Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	var sources []image.Image
+	var err error
+	switch strings.ToLower(ext) {
+	case ".pdf":
+		sources, err = renderPDF(ctx, path)
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		var img image.Image
+		img, err = decodeImage(path)
+		sources = []image.Image{img}
+	default:
+		return Page{}, nil, ErrUnsupported
+	}
+	if err != nil {
+		return Page{}, nil, err
+	}
+	if len(sources) == 0 {
+		return Page{}, nil, fmt.Errorf("tidak ada halaman yang bisa dirender")
+	}
+
+	thumb, err := encodePage(0, sources[0], config.ThumbnailWidth)
+	if err != nil {
+		return Page{}, nil, err
+	}
+
+	var pages []Page
+	for i, src := range sources {
+		page, err := encodePage(i+1, src, config.PreviewWidth)
+		if err != nil {
+			return Page{}, nil, err
+		}
+		pages = append(pages, page)
+	}
+	return thumb, pages, nil
+}
+
+// renderPDF merender beberapa halaman pertama PDF ke PNG dengan pdftoppm.
+func renderPDF(ctx context.Context, path string) ([]image.Image, error) {
+	if _, err := exec.LookPath(config.PDFToPPM); err != nil {
+		return nil, ErrUnsupported
+	}
+
+	dir, err := os.MkdirTemp("", "preview-pages-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, config.PDFToPPM,
+		"-png",
+		"-f", "1", "-l", strconv.Itoa(config.MaxPages),
+		"-scale-to-x", strconv.Itoa(config.PreviewWidth), "-scale-to-y", "-1",
+		path, filepath.Join(dir, "page"),
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("pdftoppm melebihi batas waktu %s", config.Timeout)
+		}
+		return nil, fmt.Errorf("pdftoppm gagal: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "page*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var images []image.Image
+	for _, f := range files {
+		img, err := decodeImage(f)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+func decodeImage(path string) (image.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca gambar: %v", err)
+	}
+	// Foto dari HP sering disimpan miring dengan tag orientasi EXIF
+	return applyOrientation(img, jpegOrientation(data)), nil
+}
+
+// encodePage mengecilkan gambar ke lebar tertentu (tidak pernah diperbesar)
+// di atas latar putih, lalu meng-encode ke JPEG.
+func encodePage(number int, src image.Image, width int) (Page, error) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > width {
+		h = h * width / w
+		w = width
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// Latar putih agar PNG transparan tidak menjadi hitam di JPEG
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: config.Quality}); err != nil {
+		return Page{}, err
+	}
+	return Page{Number: number, Image: buf.Bytes(), Width: w, Height: h}, nil
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
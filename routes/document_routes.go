@@ -14,10 +14,52 @@ func DocumentRoutes(r *gin.RouterGroup) {
 		// SEMUA USER (Staff + Admin) - Read & Download
 		documents.GET("", controllers.GetDocuments)
 		documents.GET("/", controllers.GetDocuments)
+		documents.GET("/awaiting-reply", controllers.GetAwaitingReplies)
 		documents.GET("/:id", controllers.GetDocumentByID)
 
 		// Download
 		documents.GET("/:id/download", controllers.DownloadDocument)
+		documents.GET("/:id/download-link", controllers.CreateDownloadLink)
+
+		// Riwayat versi file (hak ubah dicek di controller)
+		documents.GET("/:id/versions", controllers.GetDocumentVersions)
+		documents.GET("/:id/versions/:version_id/download", controllers.DownloadDocumentVersion)
+		documents.POST("/:id/versions/:version_id/restore", controllers.RestoreDocumentVersion)
+
+		// Status ekstraksi teks/OCR dan jalankan ulang
+		documents.GET("/:id/extraction", controllers.GetTextExtraction)
+		documents.POST("/:id/extraction", controllers.RetryTextExtraction)
+
+		// Thumbnail dan preview halaman
+		documents.GET("/:id/previews", controllers.GetDocumentPreviews)
+		documents.POST("/:id/previews", controllers.RegenerateDocumentPreviews)
+
+		// Alur persetujuan surat keluar (hak aksi dicek di controller)
+		documents.GET("/:id/workflow", controllers.GetLetterWorkflow)
+		documents.POST("/:id/workflow/:action", controllers.TransitionLetterWorkflow)
+		documents.POST("/:id/comments", controllers.AddLetterComment)
+
+		// Tag dan folder (hak ubah dicek di controller)
+		documents.PUT("/:id/tags", controllers.SetDocumentTags)
+		documents.PUT("/:id/folder", controllers.SetDocumentFolder)
+
+		// Hubungan antar surat dan rangkaian (thread) surat
+		documents.GET("/:id/relations", controllers.GetDocumentRelations)
+		documents.POST("/:id/relations", controllers.CreateDocumentRelation)
+		documents.DELETE("/:id/relations/:relation_id", controllers.DeleteDocumentRelation)
+		documents.GET("/:id/thread", controllers.GetDocumentThread)
+
+		// Tanda tangan elektronik surat keluar (hak menandatangani dicek di controller)
+		documents.POST("/:id/sign", controllers.SignDocument)
+		documents.GET("/:id/signature", controllers.GetDocumentSignature)
+		documents.GET("/:id/signature/:file", controllers.DownloadSignedDocument)
+
+		// Lampiran surat (hak ubah dicek di controller)
+		documents.GET("/:id/attachments", controllers.GetDocumentAttachments)
+		documents.POST("/:id/attachments", controllers.AddDocumentAttachments)
+		documents.PUT("/:id/attachments", controllers.ReorderDocumentAttachments)
+		documents.DELETE("/:id/attachments/:attachment_id", controllers.DeleteDocumentAttachment)
+		documents.GET("/:id/attachments/:attachment_id/download", controllers.DownloadDocumentAttachment)
 
 		// HANYA ADMIN - Create, Update, Delete
 		documents.POST("", middleware.AdminOnly(), controllers.CreateDocument)
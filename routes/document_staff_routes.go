@@ -14,9 +14,51 @@ func DocumentStaffRoutes(r *gin.RouterGroup) {
 		// STAFF & ADMIN - Read
 		docStaff.GET("", controllers.GetDocumentStaffs)
 		docStaff.GET("/", controllers.GetDocumentStaffs)
+		docStaff.GET("/awaiting-reply", controllers.GetAwaitingReplies)
 		docStaff.GET("/:id", controllers.GetDocumentStaffByID)
 
 		docStaff.GET("/:id/download", controllers.DownloadDocumentStaff)
+		docStaff.GET("/:id/download-link", controllers.CreateDownloadLink)
+
+		// Riwayat versi file (hak ubah dicek di controller)
+		docStaff.GET("/:id/versions", controllers.GetDocumentVersions)
+		docStaff.GET("/:id/versions/:version_id/download", controllers.DownloadDocumentVersion)
+		docStaff.POST("/:id/versions/:version_id/restore", controllers.RestoreDocumentVersion)
+
+		// Status ekstraksi teks/OCR dan jalankan ulang
+		docStaff.GET("/:id/extraction", controllers.GetTextExtraction)
+		docStaff.POST("/:id/extraction", controllers.RetryTextExtraction)
+
+		// Thumbnail dan preview halaman
+		docStaff.GET("/:id/previews", controllers.GetDocumentPreviews)
+		docStaff.POST("/:id/previews", controllers.RegenerateDocumentPreviews)
+
+		// Alur persetujuan surat keluar (hak aksi dicek di controller)
+		docStaff.GET("/:id/workflow", controllers.GetLetterWorkflow)
+		docStaff.POST("/:id/workflow/:action", controllers.TransitionLetterWorkflow)
+		docStaff.POST("/:id/comments", controllers.AddLetterComment)
+
+		// Tag dan folder (hak ubah dicek di controller)
+		docStaff.PUT("/:id/tags", controllers.SetDocumentTags)
+		docStaff.PUT("/:id/folder", controllers.SetDocumentFolder)
+
+		// Hubungan antar surat dan rangkaian (thread) surat
+		docStaff.GET("/:id/relations", controllers.GetDocumentRelations)
+		docStaff.POST("/:id/relations", controllers.CreateDocumentRelation)
+		docStaff.DELETE("/:id/relations/:relation_id", controllers.DeleteDocumentRelation)
+		docStaff.GET("/:id/thread", controllers.GetDocumentThread)
+
+		// Tanda tangan elektronik surat keluar (hak menandatangani dicek di controller)
+		docStaff.POST("/:id/sign", controllers.SignDocument)
+		docStaff.GET("/:id/signature", controllers.GetDocumentSignature)
+		docStaff.GET("/:id/signature/:file", controllers.DownloadSignedDocument)
+
+		// Lampiran surat (hak ubah dicek di controller)
+		docStaff.GET("/:id/attachments", controllers.GetDocumentAttachments)
+		docStaff.POST("/:id/attachments", controllers.AddDocumentAttachments)
+		docStaff.PUT("/:id/attachments", controllers.ReorderDocumentAttachments)
+		docStaff.DELETE("/:id/attachments/:attachment_id", controllers.DeleteDocumentAttachment)
+		docStaff.GET("/:id/attachments/:attachment_id/download", controllers.DownloadDocumentAttachment)
 
 		// STAFF - Create
 		docStaff.POST("", controllers.CreateDocumentStaff)
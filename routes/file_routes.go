@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"dinsos_kuburaya/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func FileRoutes(r *gin.RouterGroup) {
+	// Tidak pakai Auth, akses dijaga oleh signature + expires di query string
+	r.GET("/files/*key", controllers.ServeSignedFile)
+
+	// Link download dokumen sementara, hak akses user dicek ulang setiap request
+	r.GET("/downloads/:kind/:id", controllers.ServeSignedDownload)
+
+	// Gambar thumbnail/preview untuk tag <img>, dijaga signature yang sama
+	r.GET("/previews/:id/:page", controllers.ServeSignedPreview)
+
+	// Verifikasi surat bertanda tangan elektronik dari QR code, terbuka untuk publik
+	r.GET("/verify/:code", controllers.VerifyDocumentSignature)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"dinsos_kuburaya/controllers"
+	"dinsos_kuburaya/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ImportRoutes(router *gin.RouterGroup) {
+	imports := router.Group("/imports")
+	imports.Use(middleware.AuthMiddleware(), middleware.AdminOnly())
+	{
+		// Impor register surat lama: validasi (dry run) lalu jalankan di background
+		imports.GET("", controllers.GetDocumentImports)
+		imports.POST("", controllers.CreateDocumentImport)
+		imports.GET("/:id", controllers.GetDocumentImport)
+		imports.GET("/:id/rows", controllers.GetDocumentImportRows)
+		imports.POST("/:id/start", controllers.StartDocumentImport)
+		imports.POST("/:id/pause", controllers.PauseDocumentImport)
+		imports.DELETE("/:id", controllers.DeleteDocumentImport)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"dinsos_kuburaya/controllers"
+	"dinsos_kuburaya/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NumberingRoutes(router *gin.RouterGroup) {
+	numbering := router.Group("/numbering")
+	numbering.Use(middleware.AuthMiddleware())
+	{
+		// Format nomor surat hanya diatur admin
+		numbering.GET("/formats", middleware.AdminOnly(), controllers.GetLetterNumberFormats)
+		numbering.PUT("/formats/:letter_type", middleware.AdminOnly(), controllers.UpdateLetterNumberFormat)
+
+		// Reservasi nomor untuk draft surat
+		numbering.POST("/reservations", controllers.ReserveLetterNumber)
+		numbering.DELETE("/reservations/:id", controllers.CancelLetterNumberReservation)
+
+		// Buku register (staff hanya melihat nomor miliknya) dan pencarian nomor
+		numbering.GET("/register", controllers.GetLetterNumbers)
+		numbering.GET("/lookup", controllers.LookupLetterNumber)
+	}
+}
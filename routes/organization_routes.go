@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"dinsos_kuburaya/controllers"
+	"dinsos_kuburaya/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationRoutes berisi tag dan folder yang dipakai bersama dokumen admin dan staff.
+func OrganizationRoutes(router *gin.RouterGroup) {
+	tags := router.Group("/tags")
+	tags.Use(middleware.AuthMiddleware())
+	{
+		// Daftar tag beserta jumlah dokumen untuk sidebar
+		tags.GET("", controllers.GetTags)
+		tags.POST("", controllers.CreateTag)
+		tags.PUT("/:id", middleware.AdminOnly(), controllers.UpdateTag)
+		tags.DELETE("/:id", middleware.AdminOnly(), controllers.DeleteTag)
+	}
+
+	folders := router.Group("/folders")
+	folders.Use(middleware.AuthMiddleware())
+	{
+		// Pohon folder beserta jumlah dokumen
+		folders.GET("", controllers.GetFolders)
+		folders.POST("", controllers.CreateFolder)
+		folders.PUT("/:id", middleware.AdminOnly(), controllers.UpdateFolder)
+		folders.DELETE("/:id", middleware.AdminOnly(), controllers.DeleteFolder)
+	}
+}
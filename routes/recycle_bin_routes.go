@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"dinsos_kuburaya/controllers"
+	"dinsos_kuburaya/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RecycleBinRoutes(router *gin.RouterGroup) {
+	bin := router.Group("/recycle-bin")
+	bin.Use(middleware.AuthMiddleware(), middleware.AdminOnly())
+	{
+		bin.GET("", controllers.GetRecycleBin)
+		bin.GET("/", controllers.GetRecycleBin)
+		bin.POST("/:id/restore", controllers.RestoreFromRecycleBin)
+	}
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"dinsos_kuburaya/controllers"
+	"dinsos_kuburaya/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RetentionRoutes(router *gin.RouterGroup) {
+	retention := router.Group("/retention")
+	retention.Use(middleware.AuthMiddleware(), middleware.AdminOnly())
+	{
+		// Jadwal Retensi Arsip (JRA) per kode klasifikasi
+		retention.GET("/policies", controllers.GetRetentionPolicies)
+		retention.POST("/policies", controllers.CreateRetentionPolicy)
+		retention.PUT("/policies/:id", controllers.UpdateRetentionPolicy)
+		retention.DELETE("/policies/:id", controllers.DeleteRetentionPolicy)
+
+		// Hitung ulang status retensi sekarang (biasanya dijalankan job terjadwal)
+		retention.POST("/check", controllers.RunRetentionCheck)
+
+		// Usulan pemusnahan dan berita acara
+		retention.GET("/disposals", controllers.GetDisposalProposals)
+		retention.POST("/disposals", controllers.CreateDisposalProposal)
+		retention.GET("/disposals/:id", controllers.GetDisposalProposal)
+		retention.POST("/disposals/:id/approve", controllers.ApproveDisposalProposal)
+		retention.POST("/disposals/:id/reject", controllers.RejectDisposalProposal)
+	}
+}
@@ -23,6 +23,21 @@ func SuperiorOrderRoutes(router *gin.RouterGroup) {
 		superior.POST("/", middleware.AdminOnly(), controllers.CreateSuperiorOrder)
 		superior.GET("/", middleware.AdminOnly(), controllers.GetSuperiorOrders)
 
+		// Pilihan instruksi, prioritas dan status untuk form disposisi
+		superior.GET("/instruction-options", controllers.GetDispositionInstructionOptions)
+
+		// Inbox disposisi milik user yang login (staff maupun admin)
+		superior.GET("/mine", controllers.GetMySuperiorOrders)
+		superior.GET("/mine/:order_id", controllers.GetMySuperiorOrder)
+		superior.POST("/mine/:order_id/forward", controllers.ForwardSuperiorOrder)
+		superior.POST("/mine/:order_id/:action", controllers.UpdateMySuperiorOrder)
+
+		// Alur disposisi bertingkat per dokumen (hak lihat dokumen dicek di controller)
+		superior.GET("/:id/tree", controllers.GetSuperiorOrderTree)
+
+		// Penerima disposisi (atau admin) mengubah status dan mengisi laporan
+		superior.POST("/orders/:order_id/status", controllers.UpdateSuperiorOrderStatus)
+
 		// Routes dengan parameter
 		superior.GET("/:id", middleware.AdminOnly(), controllers.GetSuperiorOrdersByDocument)
 		superior.PUT("/:id", middleware.AdminOnly(), controllers.UpdateSuperiorOrder)
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"dinsos_kuburaya/controllers"
+	"dinsos_kuburaya/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func UploadRoutes(router *gin.RouterGroup) {
+	uploads := router.Group("/uploads")
+	uploads.Use(middleware.AuthMiddleware())
+	{
+		// Upload bertahap: buat sesi, kirim potongan file, lalu pakai upload_id
+		// di endpoint create/update dokumen
+		uploads.POST("", controllers.CreateUpload)
+		uploads.POST("/", controllers.CreateUpload)
+		uploads.HEAD("/:id", controllers.GetUpload)
+		uploads.GET("/:id", controllers.GetUpload)
+		uploads.PATCH("/:id", controllers.PatchUpload)
+		uploads.DELETE("/:id", controllers.DeleteUpload)
+	}
+}
@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+)
+
+// Every menjalankan job secara berkala di background. Job pertama dijalankan
+// segera setelah server start, dan panic di dalam job tidak menghentikan server.
+func Every(name string, interval time.Duration, job func()) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			run(name, job)
+			<-ticker.C
+		}
+	}()
+	log.Printf("⏱️ Job %s dijadwalkan setiap %s", name, interval)
+}
+
+func run(name string, job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Warning: job %s panic: %v", name, r)
+		}
+	}()
+	job()
+}
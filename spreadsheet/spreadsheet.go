@@ -0,0 +1,92 @@
+// Package spreadsheet membaca tabel dari file CSV dan XLSX (sheet pertama)
+// menjadi baris-baris teks, dipakai untuk impor register surat lama.
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxRows membatasi jumlah baris yang dibaca agar file rusak/terlalu besar
+// tidak menghabiskan memori.
+const MaxRows = 100000
+
+// ErrUnsupported dikembalikan untuk ekstensi selain .csv dan .xlsx.
+var ErrUnsupported = errors.New("format spreadsheet harus .csv atau .xlsx")
+
+// Read membaca seluruh baris dari file. Baris kosong di akhir dibuang dan
+// setiap sel sudah di-trim.
+func Read(path string) ([][]string, error) {
+	var (
+		rows [][]string
+		err  error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = readCSV(path)
+	case ".xlsx":
+		rows, err = readXLSX(path)
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	for len(rows) > 0 && isBlank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
+
+func isBlank(row []string) bool {
+	for _, v := range row {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// readCSV menerima pemisah koma maupun titik koma (ekspor Excel berbahasa
+// Indonesia memakai titik koma), ditentukan dari baris pertama.
+func readCSV(path string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	first, _ := bufio.NewReader(bytes.NewReader(data)).ReadString('\n')
+	r := csv.NewReader(bytes.NewReader(data))
+	if strings.Count(first, ";") > strings.Count(first, ",") {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV tidak valid: %v", err)
+		}
+		if len(rows) >= MaxRows {
+			return nil, fmt.Errorf("spreadsheet melebihi %d baris", MaxRows)
+		}
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dinsos_kuburaya/config"
+)
+
+// Cloudinary membungkus helper config.UploadToCloudinary / DeleteFromCloudinary.
+//
+// File baru diupload sebagai type=authenticated sehingga tidak bisa dibuka
+// lewat URL publik, dan key yang disimpan berbentuk
+// "<resource_type>/authenticated/<public_id>". Key lama "<resource_type>/<public_id>"
+// adalah file type=upload dan tetap bisa dibuka serta dihapus.
+type Cloudinary struct {
+	cloudName string
+	client    *http.Client
+}
+
+// Delivery type untuk upload baru
+const cloudinaryDeliveryType = "authenticated"
+
+// Delivery type yang dikenali sebagai bagian kedua key
+var cloudinaryDeliveryTypes = map[string]bool{"upload": true, "authenticated": true, "private": true}
+
+func NewCloudinaryFromEnv() (*Cloudinary, error) {
+	cloudName := os.Getenv("CLOUDINARY_CLOUD_NAME")
+	if cloudName == "" || os.Getenv("CLOUDINARY_API_KEY") == "" || os.Getenv("CLOUDINARY_API_SECRET") == "" {
+		return nil, fmt.Errorf("Cloudinary credentials tidak lengkap")
+	}
+	return &Cloudinary{
+		cloudName: cloudName,
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (c *Cloudinary) Name() string { return "cloudinary" }
+
+func (c *Cloudinary) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error) {
+	resourceType := "raw"
+	if strings.HasPrefix(contentType, "image/") {
+		resourceType = "image"
+	}
+
+	result, err := config.UploadToCloudinary(r, filepath.Base(key), path.Dir(key), resourceType, cloudinaryDeliveryType)
+	if err != nil {
+		return Object{}, err
+	}
+	deliveryType := result.Type
+	if deliveryType == "" {
+		deliveryType = cloudinaryDeliveryType
+	}
+
+	// secure_url file authenticated tidak bisa dibuka tanpa tanda tangan,
+	// sehingga URL dikosongkan seperti backend tanpa URL publik
+	return Object{
+		Key:         result.ResourceType + "/" + deliveryType + "/" + result.PublicID,
+		Backend:     c.Name(),
+		Size:        size,
+		ContentType: contentType,
+		ModTime:     time.Now(),
+	}, nil
+}
+
+func (c *Cloudinary) Delete(ctx context.Context, key string) error {
+	resourceType, deliveryType, publicID := splitCloudinaryKey(key)
+	return config.DeleteFromCloudinary(publicID, resourceType, deliveryType)
+}
+
+func (c *Cloudinary) Stat(ctx context.Context, key string) (Object, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.deliveryURL(key), nil)
+	if err != nil {
+		return Object{}, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Object{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Object{}, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Object{}, fmt.Errorf("cloudinary stat gagal: %d", resp.StatusCode)
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Object{
+		Key:         key,
+		Backend:     c.Name(),
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ModTime:     modTime,
+		URL:         c.publicURL(key),
+	}, nil
+}
+
+func (c *Cloudinary) Open(ctx context.Context, key string) (io.ReadSeekCloser, Object, error) {
+	obj, err := c.Stat(ctx, key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+
+	fetch := func(ctx context.Context, offset int64) (io.ReadCloser, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.deliveryURL(key), nil)
+		if err != nil {
+			return nil, err
+		}
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+			resp.Body.Close()
+			return nil, fmt.Errorf("cloudinary download gagal: %d", resp.StatusCode)
+		}
+		return resp.Body, nil
+	}
+
+	return newRemoteFile(ctx, obj.Size, fetch), obj, nil
+}
+
+// SignedURL membuat private download URL Cloudinary (endpoint download API)
+// yang ditandatangani dengan API secret dan tidak berlaku setelah expires_at.
+func (c *Cloudinary) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	resourceType, deliveryType, publicID := splitCloudinaryKey(key)
+	params := map[string]string{
+		"public_id":  publicID,
+		"type":       deliveryType,
+		"timestamp":  strconv.FormatInt(time.Now().Unix(), 10),
+		"expires_at": strconv.FormatInt(time.Now().Add(expires).Unix(), 10),
+	}
+	params["signature"] = cloudinarySignature(params, os.Getenv("CLOUDINARY_API_SECRET"))
+	params["api_key"] = os.Getenv("CLOUDINARY_API_KEY")
+
+	q := url.Values{}
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	return fmt.Sprintf("https://api.cloudinary.com/v1_1/%s/%s/download?%s", c.cloudName, resourceType, q.Encode()), nil
+}
+
+// cloudinarySignature: SHA-1 dari parameter yang diurutkan menurut nama
+// ("a=1&b=2") diikuti API secret.
+func cloudinarySignature(params map[string]string, secret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+params[k])
+	}
+	sum := sha1.Sum([]byte(strings.Join(parts, "&") + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// deliveryURL adalah URL CDN untuk mengambil isi file. File selain type=upload
+// memakai signed delivery URL (s--<signature>--) yang dibuat dengan API secret.
+func (c *Cloudinary) deliveryURL(key string) string {
+	resourceType, deliveryType, publicID := splitCloudinaryKey(key)
+	if deliveryType == "upload" {
+		return fmt.Sprintf("https://res.cloudinary.com/%s/%s/upload/%s", c.cloudName, resourceType, publicID)
+	}
+	signature := cloudinaryDeliverySignature(publicID, os.Getenv("CLOUDINARY_API_SECRET"))
+	return fmt.Sprintf("https://res.cloudinary.com/%s/%s/%s/s--%s--/%s", c.cloudName, resourceType, deliveryType, signature, publicID)
+}
+
+// publicURL hanya diisi untuk file lama type=upload. Signed delivery URL tidak
+// pernah kedaluwarsa sehingga tidak boleh ikut dikirim ke client.
+func (c *Cloudinary) publicURL(key string) string {
+	if _, deliveryType, _ := splitCloudinaryKey(key); deliveryType != "upload" {
+		return ""
+	}
+	return c.deliveryURL(key)
+}
+
+// cloudinaryDeliverySignature: 8 karakter pertama base64 URL-safe dari SHA-1
+// path yang diminta (tanpa transformasi berarti public_id) diikuti API secret.
+func cloudinaryDeliverySignature(toSign, secret string) string {
+	sum := sha1.Sum([]byte(toSign + secret))
+	return base64.URLEncoding.EncodeToString(sum[:])[:8]
+}
+
+// splitCloudinaryKey memecah key menjadi resource type, delivery type dan
+// public_id. Key lama tanpa delivery type berarti type=upload.
+func splitCloudinaryKey(key string) (resourceType, deliveryType, publicID string) {
+	resourceType, rest, found := strings.Cut(key, "/")
+	if !found {
+		return "raw", "upload", key
+	}
+	if t, id, ok := strings.Cut(rest, "/"); ok && cloudinaryDeliveryTypes[t] {
+		return resourceType, t, id
+	}
+	return resourceType, "upload", rest
+}
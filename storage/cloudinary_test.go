@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Contoh signature dari dokumentasi Cloudinary "Generating authentication signatures".
+func TestCloudinarySignature(t *testing.T) {
+	params := map[string]string{
+		"timestamp": "1315060510",
+		"public_id": "sample_image",
+		"eager":     "w_400,h_300,c_pad|w_260,h_200,c_crop",
+	}
+	want := "bfd09f95f331f558cbd1320e67aa8d488770583e"
+	if got := cloudinarySignature(params, "abcd"); got != want {
+		t.Fatalf("signature = %s, want %s", got, want)
+	}
+}
+
+func TestCloudinarySignedURL(t *testing.T) {
+	t.Setenv("CLOUDINARY_API_KEY", "key")
+	t.Setenv("CLOUDINARY_API_SECRET", "secret")
+	c := &Cloudinary{cloudName: "demo"}
+
+	link, err := c.SignedURL(context.Background(), "raw/authenticated/documents/surat.pdf", 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(link, "https://api.cloudinary.com/v1_1/demo/raw/download?") {
+		t.Fatalf("URL = %s", link)
+	}
+	q := u.Query()
+	if q.Get("public_id") != "documents/surat.pdf" || q.Get("type") != "authenticated" || q.Get("api_key") != "key" || q.Get("expires_at") == "" {
+		t.Fatalf("query tidak lengkap: %v", q)
+	}
+
+	signed := map[string]string{}
+	for _, k := range []string{"public_id", "type", "timestamp", "expires_at"} {
+		signed[k] = q.Get(k)
+	}
+	if q.Get("signature") != cloudinarySignature(signed, "secret") {
+		t.Fatal("signature tidak sesuai parameter")
+	}
+}
+
+func TestSplitCloudinaryKey(t *testing.T) {
+	tests := []struct {
+		key                                  string
+		resourceType, deliveryType, publicID string
+	}{
+		{"raw/authenticated/documents/surat.pdf", "raw", "authenticated", "documents/surat.pdf"},
+		{"image/private/documents/scan", "image", "private", "documents/scan"},
+		{"raw/documents/surat.pdf", "raw", "upload", "documents/surat.pdf"}, // key lama
+		{"image/upload/documents/scan", "image", "upload", "documents/scan"},
+		{"surat.pdf", "raw", "upload", "surat.pdf"},
+	}
+	for _, tt := range tests {
+		rt, dt, id := splitCloudinaryKey(tt.key)
+		if rt != tt.resourceType || dt != tt.deliveryType || id != tt.publicID {
+			t.Errorf("splitCloudinaryKey(%q) = %s, %s, %s", tt.key, rt, dt, id)
+		}
+	}
+}
+
+func TestCloudinaryDeliveryURL(t *testing.T) {
+	t.Setenv("CLOUDINARY_API_SECRET", "secret")
+	c := &Cloudinary{cloudName: "demo"}
+
+	sum := sha1.Sum([]byte("documents/surat.pdf" + "secret"))
+	signature := base64.URLEncoding.EncodeToString(sum[:])[:8]
+	tests := []struct {
+		key        string
+		want       string
+		wantPublic string
+	}{
+		{
+			"raw/authenticated/documents/surat.pdf",
+			"https://res.cloudinary.com/demo/raw/authenticated/s--" + signature + "--/documents/surat.pdf",
+			"",
+		},
+		{
+			"raw/documents/surat.pdf",
+			"https://res.cloudinary.com/demo/raw/upload/documents/surat.pdf",
+			"https://res.cloudinary.com/demo/raw/upload/documents/surat.pdf",
+		},
+	}
+	for _, tt := range tests {
+		if got := c.deliveryURL(tt.key); got != tt.want {
+			t.Errorf("deliveryURL(%q) = %s, want %s", tt.key, got, tt.want)
+		}
+		if got := c.publicURL(tt.key); got != tt.wantPublic {
+			t.Errorf("publicURL(%q) = %s, want %s", tt.key, got, tt.wantPublic)
+		}
+	}
+}
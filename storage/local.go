@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local menyimpan file di filesystem server, cocok untuk on-premise dan test.
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) (*Local, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{root: abs}, nil
+}
+
+func (l *Local) Name() string { return "local" }
+
+// path mengubah key menjadi path absolut dan menolak key yang keluar dari root.
+func (l *Local) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	full := filepath.Join(l.root, clean)
+	if !strings.HasPrefix(full, l.root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("key tidak valid: %s", key)
+	}
+	return full, nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error) {
+	full, err := l.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return Object{}, err
+	}
+
+	// Tulis ke file sementara dulu agar file setengah jadi tidak pernah terlihat
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		return Object{}, err
+	}
+	written, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return Object{}, fmt.Errorf("gagal menulis file: %v", err)
+	}
+	if size >= 0 && written != size {
+		os.Remove(tmp.Name())
+		return Object{}, fmt.Errorf("ukuran file tidak sesuai: %d dari %d byte", written, size)
+	}
+	if err := os.Rename(tmp.Name(), full); err != nil {
+		os.Remove(tmp.Name())
+		return Object{}, err
+	}
+
+	return l.Stat(ctx, key)
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	full, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Open(ctx context.Context, key string) (io.ReadSeekCloser, Object, error) {
+	full, err := l.path(key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	f, err := os.Open(full)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, Object{}, ErrNotFound
+		}
+		return nil, Object{}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, Object{}, err
+	}
+	return f, l.object(key, info), nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (Object, error) {
+	full, err := l.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Object{}, ErrNotFound
+		}
+		return Object{}, err
+	}
+	return l.object(key, info), nil
+}
+
+// SignedURL mengarah ke endpoint /api/files milik aplikasi ini karena
+// filesystem lokal tidak bisa diakses langsung dari luar.
+func (l *Local) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	exp := time.Now().Add(expires).Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(exp, 10))
+	q.Set("signature", Sign(key, exp))
+	return os.Getenv("STORAGE_PUBLIC_URL") + "/api/files/" + key + "?" + q.Encode(), nil
+}
+
+func (l *Local) object(key string, info os.FileInfo) Object {
+	return Object{
+		Key:         key,
+		Backend:     l.Name(),
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(key)),
+		ModTime:     info.ModTime(),
+	}
+}
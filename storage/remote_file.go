@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// fetchFunc membuka stream dari offset tertentu sampai akhir file.
+type fetchFunc func(ctx context.Context, offset int64) (io.ReadCloser, error)
+
+// remoteFile membuat backend HTTP terlihat seperti file yang bisa di-seek.
+// Request ke backend baru dikirim saat Read, sehingga Seek (misalnya oleh
+// http.ServeContent untuk Range request) tidak mengunduh ulang seluruh file.
+type remoteFile struct {
+	ctx    context.Context
+	fetch  fetchFunc
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func newRemoteFile(ctx context.Context, size int64, fetch fetchFunc) *remoteFile {
+	return &remoteFile{ctx: ctx, fetch: fetch, size: size}
+}
+
+func (f *remoteFile) Read(p []byte) (int, error) {
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		body, err := f.fetch(f.ctx, f.offset)
+		if err != nil {
+			return 0, err
+		}
+		f.body = body
+	}
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *remoteFile) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = f.offset + offset
+	case io.SeekEnd:
+		next = f.size + offset
+	default:
+		return 0, errors.New("whence tidak valid")
+	}
+	if next < 0 {
+		return 0, errors.New("offset negatif")
+	}
+	if next != f.offset && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.offset = next
+	return next, nil
+}
+
+func (f *remoteFile) Close() error {
+	if f.body == nil {
+		return nil
+	}
+	err := f.body.Close()
+	f.body = nil
+	return err
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"time"
+)
+
+// signingKey dibaca dari STORAGE_SIGNING_KEY atau JWT_SECRET. Tidak ada nilai
+// default: key yang tertulis di kode membuat siapa pun bisa memalsukan link.
+func signingKey() []byte {
+	key := os.Getenv("STORAGE_SIGNING_KEY")
+	if key == "" {
+		key = os.Getenv("JWT_SECRET")
+	}
+	return []byte(key)
+}
+
+// SigningEnabled bernilai false jika belum ada secret; link bertanda tangan
+// dinonaktifkan dan semua signature ditolak.
+func SigningEnabled() bool {
+	return len(signingKey()) > 0
+}
+
+// Sign menghasilkan signature HMAC-SHA256 untuk payload dan waktu kedaluwarsa.
+// Mengembalikan string kosong jika link bertanda tangan dinonaktifkan.
+func Sign(payload string, expires int64) string {
+	key := signingKey()
+	if len(key) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify memeriksa signature dan memastikan link belum kedaluwarsa.
+func Verify(payload string, expires int64, signature string) bool {
+	if !SigningEnabled() || time.Now().Unix() > expires {
+		return false
+	}
+	expected := Sign(payload, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
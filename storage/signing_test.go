@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	t.Setenv("STORAGE_SIGNING_KEY", "rahasia")
+	future := time.Now().Add(time.Minute).Unix()
+	past := time.Now().Add(-time.Minute).Unix()
+	sig := Sign("document:1:user", future)
+
+	tests := []struct {
+		name    string
+		payload string
+		expires int64
+		sig     string
+		want    bool
+	}{
+		{"valid", "document:1:user", future, sig, true},
+		{"payload lain", "document:2:user", future, sig, false},
+		{"expires diubah", "document:1:user", future + 60, sig, false},
+		{"kedaluwarsa", "document:1:user", past, Sign("document:1:user", past), false},
+		{"signature kosong", "document:1:user", future, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.payload, tt.expires, tt.sig); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigningDisabledWithoutSecret(t *testing.T) {
+	t.Setenv("STORAGE_SIGNING_KEY", "")
+	t.Setenv("JWT_SECRET", "")
+	expires := time.Now().Add(time.Minute).Unix()
+
+	if SigningEnabled() {
+		t.Fatal("SigningEnabled = true tanpa secret")
+	}
+	if sig := Sign("document:1:user", expires); sig != "" {
+		t.Fatalf("Sign = %q, want kosong", sig)
+	}
+	if Verify("document:1:user", expires, "") {
+		t.Fatal("Verify menerima signature kosong tanpa secret")
+	}
+}
+
+func TestSigningFallsBackToJWTSecret(t *testing.T) {
+	t.Setenv("STORAGE_SIGNING_KEY", "")
+	t.Setenv("JWT_SECRET", "jwt")
+	expires := time.Now().Add(time.Minute).Unix()
+
+	if !Verify("document:1:user", expires, Sign("document:1:user", expires)) {
+		t.Fatal("signature dengan JWT_SECRET ditolak")
+	}
+}
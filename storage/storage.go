@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// Object adalah metadata file yang tersimpan di sebuah backend.
+type Object struct {
+	Key         string    `json:"key"`
+	Backend     string    `json:"backend"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ModTime     time.Time `json:"mod_time"`
+	// URL publik jika backend menyediakannya (mis. Cloudinary), kosong jika tidak
+	URL string `json:"url"`
+}
+
+// Backend adalah kontrak yang harus dipenuhi setiap driver penyimpanan file.
+//
+// Put boleh menormalisasi key (Cloudinary membuat public_id sendiri), jadi
+// pemanggil wajib menyimpan Object.Key yang dikembalikan, bukan key yang dikirim.
+type Backend interface {
+	Name() string
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error)
+	Delete(ctx context.Context, key string) error
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, Object, error)
+	Stat(ctx context.Context, key string) (Object, error)
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+var ErrNotFound = errors.New("file tidak ditemukan di storage")
+
+var (
+	backends       = map[string]Backend{}
+	defaultBackend Backend
+)
+
+// Register mendaftarkan backend agar bisa dicari lewat Get.
+func Register(b Backend) {
+	backends[b.Name()] = b
+}
+
+// Get mengembalikan backend berdasarkan nama yang tersimpan di record dokumen.
+func Get(name string) (Backend, error) {
+	b, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("storage backend %q tidak dikonfigurasi", name)
+	}
+	return b, nil
+}
+
+// Default mengembalikan backend yang dipakai untuk upload baru.
+func Default() Backend {
+	return defaultBackend
+}
+
+// Init membaca konfigurasi dari environment dan menyiapkan semua backend.
+//
+// STORAGE_DRIVER menentukan backend untuk upload baru (local, s3, cloudinary).
+// Backend lain yang kredensialnya lengkap tetap didaftarkan supaya file lama
+// masih bisa dibuka dan dihapus.
+func Init() {
+	local, err := NewLocal(envOrDefault("STORAGE_LOCAL_DIR", "uploads"))
+	if err != nil {
+		log.Fatal("❌ Gagal menyiapkan local storage:", err)
+	}
+	Register(local)
+
+	if cld, err := NewCloudinaryFromEnv(); err == nil {
+		Register(cld)
+	}
+	if s3, err := NewS3FromEnv(); err == nil {
+		Register(s3)
+	}
+
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		driver = "local"
+		if _, ok := backends["cloudinary"]; ok {
+			driver = "cloudinary"
+		}
+	}
+
+	b, err := Get(driver)
+	if err != nil {
+		log.Fatal("❌ STORAGE_DRIVER tidak valid:", err)
+	}
+	defaultBackend = b
+	log.Printf("✅ Storage aktif: %s\n", driver)
+	if !SigningEnabled() {
+		log.Println("⚠️ STORAGE_SIGNING_KEY dan JWT_SECRET kosong, link download bertanda tangan dinonaktifkan")
+	}
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}